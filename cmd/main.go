@@ -2,21 +2,40 @@ package main
 
 import (
 	"context"
+	"flag"
 	"net/http"
 	"os"
-	"os/signal"
-	"time"
+	"runtime"
 
 	"github.com/go-kit/log"
 	"github.com/joho/godotenv"
-	"github.com/kelseyhightower/envconfig"
+	"github.com/markhaur/trivia/pkg"
+	"github.com/markhaur/trivia/pkg/config"
+	"github.com/markhaur/trivia/pkg/cors"
 	"github.com/markhaur/trivia/pkg/factlist"
+	"github.com/markhaur/trivia/pkg/filesystem"
+	"github.com/markhaur/trivia/pkg/health"
 	"github.com/markhaur/trivia/pkg/inmem"
+	"github.com/markhaur/trivia/pkg/lifecycle"
+	"github.com/markhaur/trivia/pkg/logging"
+	"github.com/markhaur/trivia/pkg/requestid"
+	"github.com/markhaur/trivia/pkg/securityheaders"
+	"github.com/markhaur/trivia/pkg/tlsconfig"
+	"github.com/markhaur/trivia/pkg/tracing"
+	"github.com/markhaur/trivia/pkg/users"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// Build metadata, injected at link time with -ldflags "-X main.version=..."
+var (
+	version   = "dev"
+	commit    = "unknown"
+	buildDate = "unknown"
 )
 
 func main() {
 	logger := log.NewJSONLogger(os.Stderr)
-	defer logger.Log("msg", "terminated")
 
 	path, found := os.LookupEnv("TRIVIAAPP_CONFIG_PATH")
 	if found {
@@ -25,51 +44,207 @@ func main() {
 		}
 	}
 
-	var config struct {
-		ServerAddress           string        `envconfig:"TRIVIA_SERVER_ADDRESS" default:"localhost:8082"`
-		ServerWriteTimeout      time.Duration `envconfig:"TRIVIA_SERVER_WRITE_TIMEOUT" default:"15s"`
-		ServerReadTimeout       time.Duration `envconfig:"TRIVIA_SERVER_READ_TIMEOUT" default:"15s"`
-		ServerIdleTimeout       time.Duration `envconfig:"TRIVIA_SERVER_IDLE_TIMEOUT" default:"60s"`
-		GracefulShutdownTimeout time.Duration `envconfig:"TRIVIA_GRACEFUL_SHUTDOWN_TIMEOUT" default:"30s"`
-		DBSource                string        `envconfig:"TRIVIA_DB_SOURCE"`
-		DBConnectTimeout        time.Duration `envconfig:"TRIVIA_DB_CONNECT_TIMEOUT"`
+	configPath := flag.String("config", os.Getenv("TRIVIA_CONFIG_FILE"), "path to a YAML or TOML config file, overridden by environment variables")
+	printConfig := flag.Bool("print-config", false, "print the effective config with secrets redacted and exit")
+	flag.Parse()
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		logger.Log("msg", "could not load config", "err", err)
+		os.Exit(1)
+	}
+	if *printConfig {
+		if err := cfg.Print(os.Stdout); err != nil {
+			logger.Log("msg", "could not print config", "err", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	logLevel, err := logging.NewLevel(cfg.Log.Level)
+	if err != nil {
+		logger.Log("msg", "could not create logger", "err", err)
+		os.Exit(1)
+	}
+	logger, err = logging.NewWithLevel(os.Stderr, cfg.Log.Format, logLevel)
+	if err != nil {
+		log.NewJSONLogger(os.Stderr).Log("msg", "could not create logger", "err", err)
+		os.Exit(1)
+	}
+
+	tracerProvider, err := tracing.NewTracerProvider(context.Background(), tracing.Config{
+		ServiceName:  cfg.Tracing.ServiceName,
+		Exporter:     cfg.Tracing.Exporter,
+		OTLPEndpoint: cfg.Tracing.OTLPEndpoint,
+		OTLPInsecure: cfg.Tracing.OTLPInsecure,
+	})
+	if err != nil {
+		logger.Log("msg", "could not create tracer provider", "err", err)
+		os.Exit(1)
 	}
-	if err := envconfig.Process("TRIVIAAPP", &config); err != nil {
-		logger.Log("msg", "could not load env vars", "err", err)
+	otel.SetTracerProvider(tracerProvider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	tracer := tracerProvider.Tracer("github.com/markhaur/trivia")
+
+	// every part of the application tells the time with the same clock
+	clock := pkg.SystemClock
+
+	var trivias pkg.FactRepository
+	trivias = inmem.NewFactRepository(inmem.WithClock(clock))
+	trivias = tracing.NewFactRepository(trivias, tracer)
+
+	blobs, err := filesystem.NewBlobStore(cfg.Attachment.Dir)
+	if err != nil {
+		logger.Log("msg", "could not create blob store", "err", err)
 		os.Exit(1)
 	}
+	attachmentPolicy := pkg.DefaultAttachmentPolicy()
+	attachmentPolicy.MaxSize = cfg.Attachment.MaxSize
 
-	trivias := inmem.NewFactRepository()
+	// every service of the factlist package is configured alike, sharing the repositories
+	opts := []factlist.Option{
+		factlist.WithPolicy(pkg.FactPolicy{
+			MaxQuestionLength: cfg.Fact.MaxQuestionLength,
+			MaxAnswerLength:   cfg.Fact.MaxAnswerLength,
+			Blocklist:         cfg.Fact.Blocklist,
+			MaxSources:        cfg.Fact.MaxSources,
+		}),
+		factlist.WithDefaultLanguage(cfg.Fact.DefaultLanguage),
+		factlist.WithClock(clock),
+		factlist.WithReviewers(cfg.Moderation.Reviewers...),
+		factlist.WithHistory(inmem.NewHistoryRepository()),
+		factlist.WithDuplicateDetection(
+			pkg.DuplicateDetector{Threshold: cfg.Duplicate.Threshold},
+			pkg.DuplicateMode(cfg.Duplicate.Mode),
+		),
+		factlist.WithAttachments(blobs, attachmentPolicy),
+		factlist.WithStudy(inmem.NewCardRepository()),
+		factlist.WithAnalytics(inmem.NewAnalyticsSink(cfg.Analytics.Capacity)),
+		factlist.WithCollections(inmem.NewCollectionRepository()),
+		factlist.WithSheetTemplates(inmem.NewSheetTemplateRepository()),
+	}
+	index := inmem.NewSearchIndex()
 
 	var service factlist.Service
-	service = factlist.NewService(trivias)
+	service = factlist.NewService(trivias, opts...)
+	service = factlist.IndexingMiddleware(index, clock, logger)(service)
 	service = factlist.LoggingMiddleware(logger)(service)
+	service = factlist.TracingMiddleware(tracer)(service)
 
-	mux := http.NewServeMux()
-	mux.Handle("/factlist/v1/", factlist.NewServer(service, logger))
+	var moderation factlist.ModerationService
+	moderation = factlist.NewModerationService(trivias, opts...)
+	moderation = factlist.ModerationIndexingMiddleware(index, logger)(moderation)
+	moderation = factlist.ModerationLoggingMiddleware(logger)(moderation)
+	moderation = factlist.ModerationTracingMiddleware(tracer)(moderation)
 
-	server := &http.Server{
-		Addr:         config.ServerAddress,
-		WriteTimeout: config.ServerWriteTimeout,
-		ReadTimeout:  config.ServerReadTimeout,
-		IdleTimeout:  config.ServerIdleTimeout,
-		Handler:      mux,
+	var attachments factlist.AttachmentService
+	attachments = factlist.NewAttachmentService(trivias, opts...)
+	attachments = factlist.AttachmentLoggingMiddleware(logger)(attachments)
+	attachments = factlist.AttachmentTracingMiddleware(tracer)(attachments)
+
+	var study factlist.StudyService
+	study = factlist.NewStudyService(trivias, opts...)
+	study = factlist.StudyLoggingMiddleware(logger)(study)
+	study = factlist.StudyTracingMiddleware(tracer)(study)
+
+	var analytics factlist.AnalyticsService
+	analytics = factlist.NewAnalyticsService(trivias, opts...)
+	analytics = factlist.AnalyticsLoggingMiddleware(logger)(analytics)
+	analytics = factlist.AnalyticsTracingMiddleware(tracer)(analytics)
+
+	var collections factlist.CollectionService
+	collections = factlist.NewCollectionService(trivias, opts...)
+	collections = factlist.CollectionLoggingMiddleware(logger)(collections)
+	collections = factlist.CollectionTracingMiddleware(tracer)(collections)
+
+	var sheets factlist.SheetService
+	sheets = factlist.NewSheetService(trivias, opts...)
+	sheets = factlist.SheetLoggingMiddleware(logger)(sheets)
+	sheets = factlist.SheetTracingMiddleware(tracer)(sheets)
+
+	var userService users.Service
+	userService = users.NewService(inmem.NewUserRepository(), inmem.NewSessionRepository(), users.WithSessionTTL(cfg.Session.TTL))
+	userService = users.LoggingMiddleware(logger)(userService)
+	userService = users.TracingMiddleware(tracer)(userService)
+
+	readiness := health.NewReadiness()
+	readiness.Register("facts", trivias)
+	healthServer := health.NewServer(readiness, health.BuildInfo{
+		Version:   version,
+		Commit:    commit,
+		BuildDate: buildDate,
+		GoVersion: runtime.Version(),
+	})
+
+	// health probes can't be expected to present a client certificate, so only the service routes require one
+	protect := func(next http.Handler) http.Handler { return next }
+	if cfg.Server.TLS.ClientCAFile != "" {
+		protect = tlsconfig.RequireClientCert
 	}
 
-	sig := make(chan os.Signal, 1)
-	signal.Notify(sig, os.Interrupt)
+	factlistServer := factlist.NewServer(service, logger,
+		factlist.WithModerationService(moderation),
+		factlist.WithAttachmentService(attachments),
+		factlist.WithStudyService(study),
+		factlist.WithAnalyticsService(analytics),
+		factlist.WithCollectionService(collections),
+		factlist.WithSheetService(sheets),
+	)
+
+	var handler http.Handler
+	mux := http.NewServeMux()
+	mux.Handle("/factlist/v1/", protect(users.AuthenticationMiddleware(userService)(factlistServer)))
+	mux.Handle("/users/v1/", protect(users.NewServer(userService, logger)))
+	mux.Handle("/healthz", healthServer)
+	mux.Handle("/readyz", healthServer)
+	mux.Handle("/version", healthServer)
+	handler = cors.Middleware(cors.Config{
+		AllowedOrigins:   cfg.CORS.AllowedOrigins,
+		AllowedMethods:   cfg.CORS.AllowedMethods,
+		AllowedHeaders:   cfg.CORS.AllowedHeaders,
+		ExposedHeaders:   cors.DefaultConfig().ExposedHeaders,
+		AllowCredentials: cfg.CORS.AllowCredentials,
+		MaxAge:           cfg.CORS.MaxAge,
+	})(mux)
+	handler = securityheaders.Middleware(securityheaders.Config{
+		ContentSecurityPolicy: cfg.Security.ContentSecurityPolicy,
+		HSTSMaxAge:            cfg.Security.HSTSMaxAge,
+	})(handler)
+	handler = requestid.Middleware(handler)
 
-	go func() {
-		logger.Log("transport", "http", "address", config.ServerAddress, "msg", "listening")
-		if err := server.ListenAndServe(); err != http.ErrServerClosed {
-			logger.Log("transport", "http", "address", config.ServerAddress, "msg", "failed", "err", err)
-			sig <- os.Interrupt
+	server := &http.Server{
+		Addr:         cfg.Server.Address,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
+		Handler:      handler,
+	}
+	if cfg.Server.TLS.Enabled() {
+		server.TLSConfig, err = tlsconfig.NewServerConfig(cfg.Server.TLS.CertFile, cfg.Server.TLS.KeyFile, cfg.Server.TLS.ClientCAFile, logger)
+		if err != nil {
+			logger.Log("msg", "could not load tls certificate", "err", err)
+			os.Exit(1)
 		}
-	}()
+	}
 
-	logger.Log("received", <-sig, "msg", "terminating")
-	if err := server.Shutdown(context.Background()); err != nil {
-		logger.Log("msg", "could not shutdown http server", "err", err)
+	manager := lifecycle.NewManager(logger, cfg.Server.GracefulShutdownTimeout)
+	manager.BeforeShutdown(readiness.Drain)
+	manager.DelayShutdown(cfg.Server.ShutdownDelay)
+	manager.Add("http", lifecycle.HTTPServer(server))
+	manager.Add("trash-purger", factlist.NewTrashPurger(service, clock, cfg.Trash.Retention, cfg.Trash.PurgeInterval, logger))
+	manager.Add("scheduler", factlist.NewScheduler(trivias, factlist.LogPublisher(logger), clock, cfg.Schedule.Interval, logger))
+	if cfg.LinkCheck.Interval > 0 {
+		client := factlist.NewLinkCheckClient(cfg.LinkCheck.Timeout)
+		manager.Add("link-checker", factlist.NewLinkChecker(trivias, service, client, cfg.LinkCheck.Interval, logger))
 	}
+	manager.Add("config-reloader", config.NewReloader(*configPath, cfg, func(c config.Config) {
+		logLevel.Set(c.Log.Level)
+	}, logger))
+	manager.AddFlusher("facts", trivias)
+	manager.AfterShutdown("tracing", tracerProvider.Shutdown)
 
+	logger.Log("transport", "http", "address", cfg.Server.Address, "tls", cfg.Server.TLS.Enabled(), "mtls", cfg.Server.TLS.ClientCAFile != "", "msg", "listening")
+	code := manager.Run(context.Background())
+	logger.Log("msg", "terminated", "code", code)
+	os.Exit(code)
 }
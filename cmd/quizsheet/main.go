@@ -0,0 +1,192 @@
+// Command quizsheet renders a selection of trivia into a printable quiz sheet
+// with the sheet endpoint of a running trivia server.
+//
+//	quizsheet -category geography -difficulty easy -rounds 3 -o quiz.md -answers answers.md
+//
+// The questions and their answer key are written to a single file unless
+// -answers is given, and a custom template can be uploaded with -upload
+// before rendering with it.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type options struct {
+	addr       string
+	category   string
+	difficulty string
+	collection int64
+	random     int
+	rounds     int
+	numbering  string
+	format     string
+	template   string
+	upload     string
+	title      string
+	lang       string
+	token      string
+	output     string
+	answers    string
+}
+
+func main() {
+	var opts options
+	flag.StringVar(&opts.addr, "addr", "http://localhost:8082", "base URL of the trivia server")
+	flag.StringVar(&opts.category, "category", "", "only include trivia of this category")
+	flag.StringVar(&opts.difficulty, "difficulty", "", "only include trivia of this difficulty: easy, medium or hard")
+	flag.Int64Var(&opts.collection, "collection", 0, "only include trivia of this collection, in its order")
+	flag.IntVar(&opts.random, "random", 0, "pick this many of the matching trivia at random")
+	flag.IntVar(&opts.rounds, "rounds", 1, "number of rounds the questions are split into")
+	flag.StringVar(&opts.numbering, "numbering", "continuous", "question numbering: continuous or per-round")
+	flag.StringVar(&opts.format, "format", "", "sheet format: markdown, html or text (default markdown, or the format of -template)")
+	flag.StringVar(&opts.template, "template", "", "name of a custom sheet template to render with")
+	flag.StringVar(&opts.upload, "upload", "", "upload this file as the custom template named by -template before rendering")
+	flag.StringVar(&opts.title, "title", "", "title of the quiz sheet")
+	flag.StringVar(&opts.lang, "lang", "", "languages to render trivia in, as an Accept-Language header")
+	flag.StringVar(&opts.token, "token", "", "session token to authenticate with")
+	flag.StringVar(&opts.output, "o", "", "file to write the quiz sheet to (default stdout)")
+	flag.StringVar(&opts.answers, "answers", "", "file to write the answer key to, leaving it out of -o")
+	flag.Parse()
+
+	if err := run(&http.Client{Timeout: 30 * time.Second}, opts); err != nil {
+		fmt.Fprintln(os.Stderr, "quizsheet:", err)
+		os.Exit(1)
+	}
+}
+
+func run(client *http.Client, opts options) error {
+	if opts.upload != "" {
+		if opts.template == "" {
+			return errors.New("-upload needs -template to name the uploaded template")
+		}
+		if err := uploadTemplate(client, opts); err != nil {
+			return err
+		}
+	}
+
+	if opts.answers == "" {
+		return render(client, opts, "", opts.output)
+	}
+	if err := render(client, opts, "questions", opts.output); err != nil {
+		return err
+	}
+	return render(client, opts, "answers", opts.answers)
+}
+
+// uploadTemplate stores the file named by -upload as the custom template named by -template
+func uploadTemplate(client *http.Client, opts options) error {
+	source, err := os.ReadFile(opts.upload)
+	if err != nil {
+		return fmt.Errorf("could not read template: %v", err)
+	}
+	format := opts.format
+	if format == "" {
+		format = "markdown"
+	}
+	body, err := json.Marshal(map[string]string{"format": format, "source": string(source)})
+	if err != nil {
+		return err
+	}
+
+	req, err := newRequest(opts, "PUT", "/factlist/v1/sheet/templates/"+url.PathEscape(opts.template), strings.NewReader(string(body)))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("could not upload template: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return responseError("could not upload template", resp)
+	}
+	return nil
+}
+
+// render writes part of the quiz sheet, or all of it if part is empty, to the file named path or to stdout
+func render(client *http.Client, opts options, part, path string) error {
+	q := url.Values{}
+	set := func(key, value string) {
+		if value != "" {
+			q.Set(key, value)
+		}
+	}
+	set("category", opts.category)
+	set("difficulty", opts.difficulty)
+	if opts.collection != 0 {
+		q.Set("collection", strconv.FormatInt(opts.collection, 10))
+	}
+	if opts.random != 0 {
+		q.Set("random", strconv.Itoa(opts.random))
+	}
+	q.Set("rounds", strconv.Itoa(opts.rounds))
+	set("numbering", opts.numbering)
+	set("format", opts.format)
+	set("template", opts.template)
+	set("title", opts.title)
+	set("part", part)
+
+	req, err := newRequest(opts, "GET", "/factlist/v1/sheet?"+q.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("could not request quiz sheet: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return responseError("could not render quiz sheet", resp)
+	}
+
+	var w io.Writer = os.Stdout
+	if path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("could not create %s: %v", path, err)
+		}
+		defer f.Close()
+		w = f
+	}
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return fmt.Errorf("could not write quiz sheet: %v", err)
+	}
+	return nil
+}
+
+func newRequest(opts options, method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(method, strings.TrimSuffix(opts.addr, "/")+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if opts.lang != "" {
+		req.Header.Set("Accept-Language", opts.lang)
+	}
+	if opts.token != "" {
+		req.Header.Set("Authorization", "Bearer "+opts.token)
+	}
+	return req, nil
+}
+
+// responseError turns the JSON error of a failed response into an error
+func responseError(msg string, resp *http.Response) error {
+	var body struct {
+		Error string `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil || body.Error == "" {
+		return fmt.Errorf("%s: %s", msg, resp.Status)
+	}
+	return fmt.Errorf("%s: %s", msg, body.Error)
+}
@@ -0,0 +1,122 @@
+package pkg
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// Attempt is an answer a player gave to the question of a Fact
+type Attempt struct {
+	FactID int64
+	// Player is the author of the attempt, empty if anonymous
+	Player  string
+	Answer  string
+	Correct bool
+	// Latency is how long the player took to answer
+	Latency time.Duration
+	At      time.Time
+}
+
+// AnalyticsSink is the interface answer attempts are recorded to
+type AnalyticsSink interface {
+	Record(context.Context, Attempt) error
+	// Attempts returns the attempts the sink still holds, oldest first
+	Attempts(context.Context) ([]Attempt, error)
+}
+
+// AnswerCount is how many times a wrong answer was given
+type AnswerCount struct {
+	Answer string
+	Count  int
+}
+
+// AnswerStats summarizes the attempts at answering a Fact
+type AnswerStats struct {
+	FactID   int64
+	Attempts int
+	Correct  int
+	// MedianLatency is the median time players took to answer
+	MedianLatency time.Duration
+	// WrongAnswers lists the wrong answers given, most common first
+	WrongAnswers []AnswerCount
+}
+
+// Accuracy is the share of attempts that were correct
+func (s AnswerStats) Accuracy() float64 {
+	if s.Attempts == 0 {
+		return 0
+	}
+	return float64(s.Correct) / float64(s.Attempts)
+}
+
+// Accepts reports whether answer matches the answer of the fact, or of any of
+// its translations, ignoring case, punctuation and surrounding whitespace
+func (f Fact) Accepts(answer string) bool {
+	answer = normalizeText(answer)
+	if answer == "" {
+		return false
+	}
+	if answer == normalizeText(f.Answer) {
+		return true
+	}
+	for _, t := range f.Translations {
+		if answer == normalizeText(t.Answer) {
+			return true
+		}
+	}
+	return false
+}
+
+// SummarizeAttempts returns the statistics of every fact attempted, keyed by fact ID.
+// Wrong answers are grouped regardless of case and punctuation; blank ones are left out.
+func SummarizeAttempts(attempts []Attempt) map[int64]AnswerStats {
+	var (
+		stats     = make(map[int64]AnswerStats)
+		latencies = make(map[int64][]time.Duration)
+		wrong     = make(map[int64]map[string]int)
+	)
+	for _, attempt := range attempts {
+		s := stats[attempt.FactID]
+		s.FactID = attempt.FactID
+		s.Attempts++
+		latencies[attempt.FactID] = append(latencies[attempt.FactID], attempt.Latency)
+		if attempt.Correct {
+			s.Correct++
+		} else if answer := normalizeText(attempt.Answer); answer != "" {
+			if wrong[attempt.FactID] == nil {
+				wrong[attempt.FactID] = make(map[string]int)
+			}
+			wrong[attempt.FactID][answer]++
+		}
+		stats[attempt.FactID] = s
+	}
+
+	for id, s := range stats {
+		s.MedianLatency = median(latencies[id])
+		for answer, count := range wrong[id] {
+			s.WrongAnswers = append(s.WrongAnswers, AnswerCount{Answer: answer, Count: count})
+		}
+		sort.Slice(s.WrongAnswers, func(i, j int) bool {
+			if s.WrongAnswers[i].Count != s.WrongAnswers[j].Count {
+				return s.WrongAnswers[i].Count > s.WrongAnswers[j].Count
+			}
+			return s.WrongAnswers[i].Answer < s.WrongAnswers[j].Answer
+		})
+		stats[id] = s
+	}
+	return stats
+}
+
+func median(durations []time.Duration) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
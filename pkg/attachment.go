@@ -0,0 +1,64 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+var (
+	ErrAttachmentNotFound   = errors.New("attachment not found")
+	ErrAttachmentTooLarge   = errors.New("attachment is too large")
+	ErrUnsupportedMediaType = errors.New("attachment must be an image or audio file")
+	ErrBlobNotFound         = errors.New("blob not found")
+	ErrInvalidBlobKey       = errors.New("invalid blob key")
+)
+
+// Attachment is an image or audio file shown or played along with a Fact
+type Attachment struct {
+	ID          string
+	Filename    string
+	ContentType string
+	Size        int64
+	// Checksum is the hex encoded SHA-256 of the content
+	Checksum  string
+	CreatedAt time.Time
+}
+
+// AttachmentPolicy holds the rules every Attachment must satisfy before it is stored
+type AttachmentPolicy struct {
+	// MaxSize is the largest attachment accepted, in bytes
+	MaxSize int64
+	// ContentTypes lists the media types accepted, as detected from the content
+	ContentTypes []string
+}
+
+func DefaultAttachmentPolicy() AttachmentPolicy {
+	return AttachmentPolicy{
+		MaxSize: 10 << 20,
+		ContentTypes: []string{
+			"image/gif", "image/jpeg", "image/png", "image/webp",
+			"audio/mpeg", "audio/wave", "audio/aiff", "application/ogg",
+		},
+	}
+}
+
+// Allows reports whether contentType is one of the accepted media types
+func (p AttachmentPolicy) Allows(contentType string) bool {
+	for _, allowed := range p.ContentTypes {
+		if allowed == contentType {
+			return true
+		}
+	}
+	return false
+}
+
+// BlobStore is the interface used to persist the content of Attachment(s)
+type BlobStore interface {
+	Put(ctx context.Context, key string, content io.Reader) error
+	// Get returns the content stored under key, or ErrBlobNotFound
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the content stored under key. Deleting a missing key is not an error.
+	Delete(ctx context.Context, key string) error
+}
@@ -0,0 +1,103 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	ErrCollectionNotFound = errors.New("collection not found")
+	ErrSlugTaken          = errors.New("slug is already taken")
+)
+
+const (
+	maxCollectionNameLength        = 100
+	maxCollectionDescriptionLength = 1000
+	// MaxCollectionFacts bounds the number of facts a collection may hold
+	MaxCollectionFacts = 200
+)
+
+// Collection is a curated, ordered set of facts a quiz host assembles for an event
+type Collection struct {
+	ID          int64
+	Name        string
+	Description string
+	// FactIDs lists the facts of the collection in the order they are asked
+	FactIDs []int64
+	// Owner is the username of the user who created the collection
+	Owner string
+	// Slug is the public name the collection is shared under, empty if it isn't shared
+	Slug      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Shared reports whether anyone knowing its slug may see the collection
+func (c Collection) Shared() bool { return c.Slug != "" }
+
+// Normalize trims the name and description of the collection
+func (c *Collection) Normalize() {
+	c.Name = strings.TrimSpace(c.Name)
+	c.Description = strings.TrimSpace(c.Description)
+}
+
+// Validate checks the name, description and fact IDs of the collection
+func (c Collection) Validate() error {
+	verr := &ValidationError{Subject: "collection"}
+	switch n := utf8.RuneCountInString(c.Name); {
+	case n == 0:
+		verr.Add("name", "must not be blank")
+	case n > maxCollectionNameLength:
+		verr.Add("name", fmt.Sprintf("must be at most %d characters", maxCollectionNameLength))
+	}
+	if utf8.RuneCountInString(c.Description) > maxCollectionDescriptionLength {
+		verr.Add("description", fmt.Sprintf("must be at most %d characters", maxCollectionDescriptionLength))
+	}
+	if len(c.FactIDs) > MaxCollectionFacts {
+		verr.Add("factIds", fmt.Sprintf("must list at most %d trivia", MaxCollectionFacts))
+	}
+	seen := make(map[int64]bool, len(c.FactIDs))
+	for i, id := range c.FactIDs {
+		if seen[id] {
+			verr.Add(fmt.Sprintf("factIds[%d]", i), "is listed more than once")
+		}
+		seen[id] = true
+	}
+	return verr.Err()
+}
+
+// Slugify turns name into lower-case words of letters and digits joined by hyphens
+func Slugify(name string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			if hyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			hyphen = false
+			b.WriteRune(r)
+		default:
+			hyphen = true
+		}
+	}
+	return b.String()
+}
+
+// CollectionRepository is the interface used to persist the Collection(s).
+// Insert and Update fail with ErrSlugTaken if another collection is shared
+// under the same slug.
+type CollectionRepository interface {
+	Insert(context.Context, *Collection) error
+	FindAll(context.Context) ([]Collection, error)
+	FindByID(context.Context, int64) (*Collection, error)
+	FindBySlug(context.Context, string) (*Collection, error)
+	Update(context.Context, *Collection) error
+	DeleteByID(context.Context, int64) error
+}
@@ -0,0 +1,399 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/kelseyhightower/envconfig"
+	"github.com/markhaur/trivia/pkg"
+	"github.com/markhaur/trivia/pkg/cors"
+	"github.com/markhaur/trivia/pkg/logging"
+	"github.com/markhaur/trivia/pkg/securityheaders"
+	"github.com/markhaur/trivia/pkg/tracing"
+	"gopkg.in/yaml.v3"
+)
+
+// envPrefix is the prefix envconfig tries before falling back to the TRIVIA_ names of the settings
+const envPrefix = "TRIVIAAPP"
+
+// redacted replaces the value of secret settings when the configuration is printed
+const redacted = "REDACTED"
+
+// Config holds every setting of the trivia server. Settings are read from
+// their defaults, then from an optional YAML or TOML file, then from the
+// environment, each layer overriding the one before.
+type Config struct {
+	Server     Server     `yaml:"server" toml:"server"`
+	DB         DB         `yaml:"db" toml:"db"`
+	Fact       Fact       `yaml:"fact" toml:"fact"`
+	Moderation Moderation `yaml:"moderation" toml:"moderation"`
+	Attachment Attachment `yaml:"attachment" toml:"attachment"`
+	Duplicate  Duplicate  `yaml:"duplicate" toml:"duplicate"`
+	Trash      Trash      `yaml:"trash" toml:"trash"`
+	Schedule   Schedule   `yaml:"schedule" toml:"schedule"`
+	LinkCheck  LinkCheck  `yaml:"linkCheck" toml:"linkCheck"`
+	Session    Session    `yaml:"session" toml:"session"`
+	Analytics  Analytics  `yaml:"analytics" toml:"analytics"`
+	CORS       CORS       `yaml:"cors" toml:"cors"`
+	Security   Security   `yaml:"security" toml:"security"`
+	Log        Log        `yaml:"log" toml:"log"`
+	Tracing    Tracing    `yaml:"tracing" toml:"tracing"`
+}
+
+type Server struct {
+	Address                 string        `yaml:"address" toml:"address" envconfig:"TRIVIA_SERVER_ADDRESS"`
+	WriteTimeout            time.Duration `yaml:"writeTimeout" toml:"writeTimeout" envconfig:"TRIVIA_SERVER_WRITE_TIMEOUT"`
+	ReadTimeout             time.Duration `yaml:"readTimeout" toml:"readTimeout" envconfig:"TRIVIA_SERVER_READ_TIMEOUT"`
+	IdleTimeout             time.Duration `yaml:"idleTimeout" toml:"idleTimeout" envconfig:"TRIVIA_SERVER_IDLE_TIMEOUT"`
+	GracefulShutdownTimeout time.Duration `yaml:"gracefulShutdownTimeout" toml:"gracefulShutdownTimeout" envconfig:"TRIVIA_GRACEFUL_SHUTDOWN_TIMEOUT"`
+	// ShutdownDelay is how long /readyz reports the server as draining before it stops accepting connections
+	ShutdownDelay time.Duration `yaml:"shutdownDelay" toml:"shutdownDelay" envconfig:"TRIVIA_SHUTDOWN_DELAY"`
+	TLS           TLS           `yaml:"tls" toml:"tls"`
+}
+
+// TLS holds the certificate the server is served with over HTTPS, plain HTTP
+// unless CertFile is set. Rotated certificates are picked up without a
+// restart. If ClientCAFile is set, clients of the API, but not health
+// probes, must present a certificate signed by one of its CAs.
+type TLS struct {
+	CertFile     string `yaml:"certFile" toml:"certFile" envconfig:"TRIVIA_TLS_CERT_FILE"`
+	KeyFile      string `yaml:"keyFile" toml:"keyFile" envconfig:"TRIVIA_TLS_KEY_FILE"`
+	ClientCAFile string `yaml:"clientCAFile" toml:"clientCAFile" envconfig:"TRIVIA_TLS_CLIENT_CA_FILE"`
+}
+
+// Enabled reports whether the server is served over HTTPS
+func (t TLS) Enabled() bool { return t.CertFile != "" }
+
+// DB holds the settings of a database backend. They are validated but not
+// used yet, as every repository is kept in memory.
+type DB struct {
+	Source         string        `yaml:"source" toml:"source" envconfig:"TRIVIA_DB_SOURCE" secret:"true"`
+	ConnectTimeout time.Duration `yaml:"connectTimeout" toml:"connectTimeout" envconfig:"TRIVIA_DB_CONNECT_TIMEOUT"`
+}
+
+type Fact struct {
+	MaxQuestionLength int      `yaml:"maxQuestionLength" toml:"maxQuestionLength" envconfig:"TRIVIA_FACT_MAX_QUESTION_LENGTH"`
+	MaxAnswerLength   int      `yaml:"maxAnswerLength" toml:"maxAnswerLength" envconfig:"TRIVIA_FACT_MAX_ANSWER_LENGTH"`
+	Blocklist         []string `yaml:"blocklist" toml:"blocklist" envconfig:"TRIVIA_FACT_BLOCKLIST"`
+	MaxSources        int      `yaml:"maxSources" toml:"maxSources" envconfig:"TRIVIA_FACT_MAX_SOURCES"`
+	DefaultLanguage   string   `yaml:"defaultLanguage" toml:"defaultLanguage" envconfig:"TRIVIA_FACT_DEFAULT_LANGUAGE"`
+}
+
+// Moderation holds the usernames of the users who may review submitted facts
+type Moderation struct {
+	Reviewers []string `yaml:"reviewers" toml:"reviewers" envconfig:"TRIVIA_MODERATION_REVIEWERS"`
+}
+
+type Attachment struct {
+	Dir     string `yaml:"dir" toml:"dir" envconfig:"TRIVIA_ATTACHMENT_DIR"`
+	MaxSize int64  `yaml:"maxSize" toml:"maxSize" envconfig:"TRIVIA_ATTACHMENT_MAX_SIZE"`
+}
+
+type Duplicate struct {
+	Mode      string  `yaml:"mode" toml:"mode" envconfig:"TRIVIA_DUPLICATE_MODE"`
+	Threshold float64 `yaml:"threshold" toml:"threshold" envconfig:"TRIVIA_DUPLICATE_THRESHOLD"`
+}
+
+type Trash struct {
+	Retention     time.Duration `yaml:"retention" toml:"retention" envconfig:"TRIVIA_TRASH_RETENTION"`
+	PurgeInterval time.Duration `yaml:"purgeInterval" toml:"purgeInterval" envconfig:"TRIVIA_TRASH_PURGE_INTERVAL"`
+}
+
+type Schedule struct {
+	Interval time.Duration `yaml:"interval" toml:"interval" envconfig:"TRIVIA_SCHEDULE_INTERVAL"`
+}
+
+// LinkCheck holds the settings of the source link checker, which only runs if Interval is set
+type LinkCheck struct {
+	Interval time.Duration `yaml:"interval" toml:"interval" envconfig:"TRIVIA_LINK_CHECK_INTERVAL"`
+	Timeout  time.Duration `yaml:"timeout" toml:"timeout" envconfig:"TRIVIA_LINK_CHECK_TIMEOUT"`
+}
+
+type Session struct {
+	TTL time.Duration `yaml:"ttl" toml:"ttl" envconfig:"TRIVIA_SESSION_TTL"`
+}
+
+type Analytics struct {
+	Capacity int `yaml:"capacity" toml:"capacity" envconfig:"TRIVIA_ANALYTICS_CAPACITY"`
+}
+
+// CORS holds which browser apps served from other origins may call the API,
+// none unless AllowedOrigins is set
+type CORS struct {
+	AllowedOrigins   []string      `yaml:"allowedOrigins" toml:"allowedOrigins" envconfig:"TRIVIA_CORS_ALLOWED_ORIGINS"`
+	AllowedMethods   []string      `yaml:"allowedMethods" toml:"allowedMethods" envconfig:"TRIVIA_CORS_ALLOWED_METHODS"`
+	AllowedHeaders   []string      `yaml:"allowedHeaders" toml:"allowedHeaders" envconfig:"TRIVIA_CORS_ALLOWED_HEADERS"`
+	AllowCredentials bool          `yaml:"allowCredentials" toml:"allowCredentials" envconfig:"TRIVIA_CORS_ALLOW_CREDENTIALS"`
+	MaxAge           time.Duration `yaml:"maxAge" toml:"maxAge" envconfig:"TRIVIA_CORS_MAX_AGE"`
+}
+
+// Security holds the values of the security headers sent to browsers
+type Security struct {
+	ContentSecurityPolicy string        `yaml:"contentSecurityPolicy" toml:"contentSecurityPolicy" envconfig:"TRIVIA_SECURITY_CONTENT_SECURITY_POLICY"`
+	HSTSMaxAge            time.Duration `yaml:"hstsMaxAge" toml:"hstsMaxAge" envconfig:"TRIVIA_SECURITY_HSTS_MAX_AGE"`
+}
+
+type Log struct {
+	Level  string `yaml:"level" toml:"level" envconfig:"TRIVIA_LOG_LEVEL" reload:"true"`
+	Format string `yaml:"format" toml:"format" envconfig:"TRIVIA_LOG_FORMAT"`
+}
+
+type Tracing struct {
+	ServiceName  string `yaml:"serviceName" toml:"serviceName" envconfig:"TRIVIA_TRACING_SERVICE_NAME"`
+	Exporter     string `yaml:"exporter" toml:"exporter" envconfig:"TRIVIA_TRACING_EXPORTER"`
+	OTLPEndpoint string `yaml:"otlpEndpoint" toml:"otlpEndpoint" envconfig:"TRIVIA_TRACING_OTLP_ENDPOINT"`
+	OTLPInsecure bool   `yaml:"otlpInsecure" toml:"otlpInsecure" envconfig:"TRIVIA_TRACING_OTLP_INSECURE"`
+}
+
+// Default returns the settings used unless a file or the environment says otherwise
+func Default() Config {
+	corsDefaults, securityDefaults := cors.DefaultConfig(), securityheaders.DefaultConfig()
+	return Config{
+		Server: Server{
+			Address:                 "localhost:8082",
+			WriteTimeout:            15 * time.Second,
+			ReadTimeout:             15 * time.Second,
+			IdleTimeout:             60 * time.Second,
+			GracefulShutdownTimeout: 30 * time.Second,
+			ShutdownDelay:           5 * time.Second,
+		},
+		Fact: Fact{
+			MaxQuestionLength: 500,
+			MaxAnswerLength:   200,
+			MaxSources:        10,
+			DefaultLanguage:   "en",
+		},
+		Attachment: Attachment{Dir: "data/attachments", MaxSize: 10 << 20},
+		Duplicate:  Duplicate{Mode: string(pkg.DuplicatesWarn), Threshold: 0.6},
+		Trash:      Trash{Retention: 720 * time.Hour, PurgeInterval: time.Hour},
+		Schedule:   Schedule{Interval: time.Minute},
+		LinkCheck:  LinkCheck{Timeout: 10 * time.Second},
+		Session:    Session{TTL: 720 * time.Hour},
+		Analytics:  Analytics{Capacity: 10000},
+		CORS: CORS{
+			AllowedMethods: corsDefaults.AllowedMethods,
+			AllowedHeaders: corsDefaults.AllowedHeaders,
+			MaxAge:         corsDefaults.MaxAge,
+		},
+		Security: Security{
+			ContentSecurityPolicy: securityDefaults.ContentSecurityPolicy,
+			HSTSMaxAge:            securityDefaults.HSTSMaxAge,
+		},
+		Log: Log{Level: "info", Format: logging.FormatJSON},
+		Tracing: Tracing{
+			ServiceName:  "trivia",
+			Exporter:     tracing.ExporterNone,
+			OTLPEndpoint: "localhost:4318",
+			OTLPInsecure: true,
+		},
+	}
+}
+
+// Load returns the default settings overridden by the YAML or TOML file at
+// path, if path isn't empty, and then by the environment. It fails if the
+// file can't be read or the resulting settings are invalid.
+func Load(path string) (Config, error) {
+	c := Default()
+	if path != "" {
+		if err := decodeFile(path, &c); err != nil {
+			return Config{}, err
+		}
+	}
+	if err := envconfig.Process(envPrefix, &c); err != nil {
+		return Config{}, fmt.Errorf("could not read environment: %v", err)
+	}
+	if err := c.Validate(); err != nil {
+		return Config{}, err
+	}
+	c.Fact.DefaultLanguage, _ = pkg.ParseLanguage(c.Fact.DefaultLanguage)
+	return c, nil
+}
+
+// decodeFile reads the settings of the file at path into c, telling its
+// format from its extension and rejecting settings c doesn't have
+func decodeFile(path string, c *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("could not read config file: %v", err)
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(c); err != nil && err != io.EOF {
+			return fmt.Errorf("could not parse %s: %v", path, err)
+		}
+	case ".toml":
+		md, err := toml.Decode(string(data), c)
+		if err != nil {
+			return fmt.Errorf("could not parse %s: %v", path, err)
+		}
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			return fmt.Errorf("could not parse %s: unknown setting %q", path, undecoded[0].String())
+		}
+	default:
+		return fmt.Errorf("config file %s must have a .yaml, .yml or .toml extension, not %q", path, ext)
+	}
+	return nil
+}
+
+// Validate returns a *pkg.ValidationError listing every invalid setting, or nil
+func (c Config) Validate() error {
+	verr := &pkg.ValidationError{Subject: "config"}
+	positive := func(field string, d time.Duration) {
+		if d <= 0 {
+			verr.Add(field, "must be a positive duration")
+		}
+	}
+	nonNegative := func(field string, d time.Duration) {
+		if d < 0 {
+			verr.Add(field, "must not be negative")
+		}
+	}
+
+	if strings.TrimSpace(c.Server.Address) == "" {
+		verr.Add("server.address", "must not be blank")
+	}
+	nonNegative("server.writeTimeout", c.Server.WriteTimeout)
+	nonNegative("server.readTimeout", c.Server.ReadTimeout)
+	nonNegative("server.idleTimeout", c.Server.IdleTimeout)
+	positive("server.gracefulShutdownTimeout", c.Server.GracefulShutdownTimeout)
+	nonNegative("server.shutdownDelay", c.Server.ShutdownDelay)
+	switch tls := c.Server.TLS; {
+	case tls.CertFile != "" && tls.KeyFile == "":
+		verr.Add("server.tls.keyFile", "must be set along with server.tls.certFile")
+	case tls.CertFile == "" && tls.KeyFile != "":
+		verr.Add("server.tls.certFile", "must be set along with server.tls.keyFile")
+	case tls.CertFile == "" && tls.ClientCAFile != "":
+		verr.Add("server.tls.clientCAFile", "needs server.tls.certFile to verify client certificates")
+	}
+	nonNegative("db.connectTimeout", c.DB.ConnectTimeout)
+
+	if c.Fact.MaxQuestionLength <= 0 {
+		verr.Add("fact.maxQuestionLength", "must be a positive number")
+	}
+	if c.Fact.MaxAnswerLength <= 0 {
+		verr.Add("fact.maxAnswerLength", "must be a positive number")
+	}
+	if c.Fact.MaxSources < 0 {
+		verr.Add("fact.maxSources", "must not be negative")
+	}
+	if _, err := pkg.ParseLanguage(c.Fact.DefaultLanguage); err != nil {
+		verr.Add("fact.defaultLanguage", "must be a valid language tag")
+	}
+
+	if strings.TrimSpace(c.Attachment.Dir) == "" {
+		verr.Add("attachment.dir", "must not be blank")
+	}
+	if c.Attachment.MaxSize <= 0 {
+		verr.Add("attachment.maxSize", "must be a positive number of bytes")
+	}
+
+	if !pkg.DuplicateMode(c.Duplicate.Mode).Valid() {
+		verr.Add("duplicate.mode", "must be off, warn or reject")
+	}
+	if c.Duplicate.Threshold <= 0 || c.Duplicate.Threshold > 1 {
+		verr.Add("duplicate.threshold", "must be greater than 0 and at most 1")
+	}
+
+	positive("trash.retention", c.Trash.Retention)
+	positive("trash.purgeInterval", c.Trash.PurgeInterval)
+	positive("schedule.interval", c.Schedule.Interval)
+	nonNegative("linkCheck.interval", c.LinkCheck.Interval)
+	positive("linkCheck.timeout", c.LinkCheck.Timeout)
+	positive("session.ttl", c.Session.TTL)
+	if c.Analytics.Capacity <= 0 {
+		verr.Add("analytics.capacity", "must be a positive number")
+	}
+
+	for i, origin := range c.CORS.AllowedOrigins {
+		if origin != "*" && !strings.Contains(origin, "://") {
+			verr.Add(fmt.Sprintf("cors.allowedOrigins[%d]", i), "must be * or a scheme and host such as https://quiz.example.com")
+		}
+		if origin == "*" && c.CORS.AllowCredentials {
+			verr.Add(fmt.Sprintf("cors.allowedOrigins[%d]", i), "must not be * when credentials are allowed")
+		}
+	}
+	nonNegative("cors.maxAge", c.CORS.MaxAge)
+	nonNegative("security.hstsMaxAge", c.Security.HSTSMaxAge)
+
+	if !logging.ValidLevel(c.Log.Level) {
+		verr.Add("log.level", "must be debug, info, warn or error")
+	}
+	if !logging.ValidFormat(c.Log.Format) {
+		verr.Add("log.format", "must be json or logfmt")
+	}
+
+	switch c.Tracing.Exporter {
+	case tracing.ExporterNone, tracing.ExporterStdout:
+	case tracing.ExporterOTLP:
+		if strings.TrimSpace(c.Tracing.OTLPEndpoint) == "" {
+			verr.Add("tracing.otlpEndpoint", "must not be blank when exporting with otlp")
+		}
+	default:
+		verr.Add("tracing.exporter", "must be none, stdout or otlp")
+	}
+	return verr.Err()
+}
+
+// Redacted returns a copy of c whose secret settings are replaced, so that it can be printed or logged
+func (c Config) Redacted() Config {
+	walk(reflect.ValueOf(&c).Elem(), "", func(_ string, field reflect.StructField, v reflect.Value) {
+		if field.Tag.Get("secret") == "true" && v.Kind() == reflect.String && v.String() != "" {
+			v.SetString(redacted)
+		}
+	})
+	return c
+}
+
+// Print writes c as YAML with its secret settings redacted
+func (c Config) Print(w io.Writer) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(c.Redacted()); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// Changes lists the settings that differ between c and next by their file
+// names, split into the ones that can be applied while the server runs and
+// the ones that need a restart
+func (c Config) Changes(next Config) (reloadable, restart []string) {
+	values := make(map[string]interface{})
+	walk(reflect.ValueOf(&c).Elem(), "", func(name string, _ reflect.StructField, v reflect.Value) {
+		values[name] = v.Interface()
+	})
+	walk(reflect.ValueOf(&next).Elem(), "", func(name string, field reflect.StructField, v reflect.Value) {
+		if reflect.DeepEqual(values[name], v.Interface()) {
+			return
+		}
+		if field.Tag.Get("reload") == "true" {
+			reloadable = append(reloadable, name)
+		} else {
+			restart = append(restart, name)
+		}
+	})
+	return reloadable, restart
+}
+
+// walk calls fn with every setting of the sections of v, named after their file keys
+func walk(v reflect.Value, prefix string, fn func(name string, field reflect.StructField, v reflect.Value)) {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		name := prefix + field.Tag.Get("yaml")
+		if field.Type.Kind() == reflect.Struct && field.Type != reflect.TypeOf(time.Time{}) {
+			walk(v.Field(i), name+".", fn)
+			continue
+		}
+		fn(name, field, v.Field(i))
+	}
+}
@@ -0,0 +1,118 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"syscall"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+)
+
+// Reloader is a background worker that loads the configuration again
+// whenever the process receives SIGHUP and applies the settings that can
+// change while the server runs, such as the log level
+type Reloader struct {
+	path   string
+	apply  func(Config)
+	logger log.Logger
+
+	mu      sync.Mutex
+	current Config
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewReloader returns a Reloader of the configuration at path, currently
+// current, which calls apply with every configuration it reloads
+func NewReloader(path string, current Config, apply func(Config), logger log.Logger) *Reloader {
+	return &Reloader{
+		path:    path,
+		apply:   apply,
+		logger:  logger,
+		current: current,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// Run reloads the configuration on every SIGHUP until Shutdown is called or ctx is done
+func (r *Reloader) Run(ctx context.Context) error {
+	defer close(r.done)
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGHUP)
+	defer signal.Stop(sig)
+
+	for {
+		select {
+		case <-sig:
+			r.Reload()
+		case <-r.stop:
+			return nil
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// Shutdown stops the reloader
+func (r *Reloader) Shutdown(ctx context.Context) error {
+	close(r.stop)
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Reload loads the configuration again and applies the settings that can be
+// reloaded, keeping the current configuration if the new one is invalid.
+// Changes to other settings are reported but only take effect on restart.
+// It returns the configuration in effect afterwards.
+func (r *Reloader) Reload() Config {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	next, err := Load(r.path)
+	if err != nil {
+		level.Error(r.logger).Log("msg", "could not reload config", "err", err)
+		return r.current
+	}
+
+	reloadable, restart := r.current.Changes(next)
+	if len(restart) > 0 {
+		level.Warn(r.logger).Log("msg", "config changes need a restart", "settings", fmt.Sprint(restart))
+	}
+	if len(reloadable) == 0 {
+		level.Info(r.logger).Log("msg", "reloaded config", "changed", "none")
+		return r.current
+	}
+
+	current := reflect.ValueOf(&r.current).Elem()
+	walk(reflect.ValueOf(&next).Elem(), "", func(name string, field reflect.StructField, v reflect.Value) {
+		if field.Tag.Get("reload") == "true" {
+			lookup(current, name).Set(v)
+		}
+	})
+	r.apply(r.current)
+	level.Info(r.logger).Log("msg", "reloaded config", "changed", fmt.Sprint(reloadable))
+	return r.current
+}
+
+// lookup returns the setting of v with the given file name
+func lookup(v reflect.Value, name string) reflect.Value {
+	var found reflect.Value
+	walk(v, "", func(n string, _ reflect.StructField, setting reflect.Value) {
+		if n == name {
+			found = setting
+		}
+	})
+	return found
+}
@@ -0,0 +1,153 @@
+package cors
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config tells which cross-origin requests browsers may make
+type Config struct {
+	// AllowedOrigins lists the origins allowed to call the API, such as
+	// https://quiz.example.com. An origin may contain a single * matching any
+	// non-empty text, e.g. https://*.example.com, and * alone allows every
+	// origin. Cross-origin requests are refused while the list is empty.
+	AllowedOrigins []string
+	AllowedMethods []string
+	// AllowedHeaders lists the request headers allowed besides the CORS-safelisted ones; * allows any
+	AllowedHeaders []string
+	// ExposedHeaders lists the response headers scripts may read besides the CORS-safelisted ones
+	ExposedHeaders   []string
+	AllowCredentials bool
+	// MaxAge tells how long browsers may cache the answer to a preflight request
+	MaxAge time.Duration
+}
+
+// DefaultConfig returns the methods and headers the trivia API is used with, allowing no origin
+func DefaultConfig() Config {
+	return Config{
+		AllowedMethods: []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete},
+		AllowedHeaders: []string{"Accept-Language", "Authorization", "Content-Type", "If-Match", "If-None-Match", "X-Request-ID"},
+		ExposedHeaders: []string{"Content-Language", "ETag", "Location", "Retry-After", "X-Request-ID"},
+		MaxAge:         10 * time.Minute,
+	}
+}
+
+// Middleware adds the CORS headers of config to the responses to requests
+// from allowed origins and answers their preflight requests itself. Other
+// requests are passed on untouched, except for preflight requests from
+// origins that aren't allowed, which are refused with 403 Forbidden.
+func Middleware(config Config) func(http.Handler) http.Handler {
+	var (
+		methods   = strings.Join(config.AllowedMethods, ", ")
+		exposed   = strings.Join(config.ExposedHeaders, ", ")
+		maxAge    = strconv.Itoa(int(config.MaxAge.Seconds()))
+		anyOrigin = contains(config.AllowedOrigins, "*")
+		anyHeader = contains(config.AllowedHeaders, "*")
+	)
+
+	return func(next http.Handler) http.Handler {
+		if len(config.AllowedOrigins) == 0 {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			h.Add("Vary", "Origin")
+			origin := r.Header.Get("Origin")
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !anyOrigin && !matchOrigin(config.AllowedOrigins, origin) {
+				if preflight {
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if anyOrigin && !config.AllowCredentials {
+				h.Set("Access-Control-Allow-Origin", "*")
+			} else {
+				h.Set("Access-Control-Allow-Origin", origin)
+			}
+			if config.AllowCredentials {
+				h.Set("Access-Control-Allow-Credentials", "true")
+			}
+
+			if !preflight {
+				if exposed != "" {
+					h.Set("Access-Control-Expose-Headers", exposed)
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h.Add("Vary", "Access-Control-Request-Method")
+			h.Add("Vary", "Access-Control-Request-Headers")
+			method := r.Header.Get("Access-Control-Request-Method")
+			if !contains(config.AllowedMethods, method) {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			requested := r.Header.Get("Access-Control-Request-Headers")
+			for _, header := range strings.Split(requested, ",") {
+				header = strings.TrimSpace(header)
+				if header != "" && !anyHeader && !containsFold(config.AllowedHeaders, header) {
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
+			}
+
+			h.Set("Access-Control-Allow-Methods", methods)
+			if requested != "" {
+				h.Set("Access-Control-Allow-Headers", requested)
+			}
+			if config.MaxAge > 0 {
+				h.Set("Access-Control-Max-Age", maxAge)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
+
+// matchOrigin reports whether origin matches one of the allowed origins, any
+// * in them matching a non-empty text
+func matchOrigin(allowed []string, origin string) bool {
+	origin = strings.ToLower(origin)
+	for _, pattern := range allowed {
+		pattern = strings.ToLower(pattern)
+		prefix, suffix, wildcard := strings.Cut(pattern, "*")
+		switch {
+		case !wildcard && pattern == origin:
+			return true
+		case wildcard && len(origin) > len(prefix)+len(suffix) &&
+			strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix):
+			return true
+		}
+	}
+	return false
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
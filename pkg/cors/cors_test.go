@@ -0,0 +1,167 @@
+package cors_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/markhaur/trivia/pkg/cors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	config := cors.DefaultConfig()
+	config.AllowedOrigins = []string{"https://quiz.example.com", "https://*.preview.example.com"}
+	config.AllowCredentials = true
+
+	tt := []struct {
+		Name            string
+		Config          cors.Config
+		Method          string
+		Headers         map[string]string
+		ExpectedStatus  int
+		ExpectedHeaders map[string]string
+	}{
+		{
+			Name:           "Passes requests without an origin through",
+			Config:         config,
+			Method:         "GET",
+			ExpectedStatus: http.StatusOK,
+			ExpectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "",
+				"Vary":                        "Origin",
+			},
+		},
+		{
+			Name:           "Allows requests from an allowed origin",
+			Config:         config,
+			Method:         "GET",
+			Headers:        map[string]string{"Origin": "https://quiz.example.com"},
+			ExpectedStatus: http.StatusOK,
+			ExpectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":      "https://quiz.example.com",
+				"Access-Control-Allow-Credentials": "true",
+				"Access-Control-Expose-Headers":    "Content-Language, ETag, Location, Retry-After, X-Request-ID",
+			},
+		},
+		{
+			Name:           "Allows origins matching a wildcard",
+			Config:         config,
+			Method:         "DELETE",
+			Headers:        map[string]string{"Origin": "https://pr-42.preview.example.com"},
+			ExpectedStatus: http.StatusOK,
+			ExpectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "https://pr-42.preview.example.com",
+			},
+		},
+		{
+			Name:           "Leaves out the CORS headers for other origins",
+			Config:         config,
+			Method:         "GET",
+			Headers:        map[string]string{"Origin": "https://evil.example.net"},
+			ExpectedStatus: http.StatusOK,
+			ExpectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "",
+			},
+		},
+		{
+			Name:   "Answers preflight requests",
+			Config: config,
+			Method: "OPTIONS",
+			Headers: map[string]string{
+				"Origin":                         "https://quiz.example.com",
+				"Access-Control-Request-Method":  "PATCH",
+				"Access-Control-Request-Headers": "content-type, if-match",
+			},
+			ExpectedStatus: http.StatusNoContent,
+			ExpectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":  "https://quiz.example.com",
+				"Access-Control-Allow-Methods": "GET, POST, PUT, PATCH, DELETE",
+				"Access-Control-Allow-Headers": "content-type, if-match",
+				"Access-Control-Max-Age":       "600",
+			},
+		},
+		{
+			Name:   "Refuses preflight requests from other origins",
+			Config: config,
+			Method: "OPTIONS",
+			Headers: map[string]string{
+				"Origin":                        "https://evil.example.net",
+				"Access-Control-Request-Method": "DELETE",
+			},
+			ExpectedStatus: http.StatusForbidden,
+			ExpectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "",
+			},
+		},
+		{
+			Name:   "Refuses preflight requests for other methods",
+			Config: config,
+			Method: "OPTIONS",
+			Headers: map[string]string{
+				"Origin":                        "https://quiz.example.com",
+				"Access-Control-Request-Method": "TRACE",
+			},
+			ExpectedStatus: http.StatusForbidden,
+			ExpectedHeaders: map[string]string{
+				"Access-Control-Allow-Methods": "",
+			},
+		},
+		{
+			Name:   "Refuses preflight requests for other headers",
+			Config: config,
+			Method: "OPTIONS",
+			Headers: map[string]string{
+				"Origin":                         "https://quiz.example.com",
+				"Access-Control-Request-Method":  "GET",
+				"Access-Control-Request-Headers": "X-Forwarded-For",
+			},
+			ExpectedStatus: http.StatusForbidden,
+			ExpectedHeaders: map[string]string{
+				"Access-Control-Allow-Headers": "",
+			},
+		},
+		{
+			Name:           "Allows every origin with a wildcard",
+			Config:         cors.Config{AllowedOrigins: []string{"*"}, MaxAge: time.Minute},
+			Method:         "GET",
+			Headers:        map[string]string{"Origin": "https://anywhere.example.org"},
+			ExpectedStatus: http.StatusOK,
+			ExpectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":      "*",
+				"Access-Control-Allow-Credentials": "",
+			},
+		},
+		{
+			Name:           "Does nothing without allowed origins",
+			Config:         cors.DefaultConfig(),
+			Method:         "OPTIONS",
+			Headers:        map[string]string{"Origin": "https://quiz.example.com", "Access-Control-Request-Method": "GET"},
+			ExpectedStatus: http.StatusOK,
+			ExpectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "",
+				"Vary":                        "",
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			assert := assert.New(t)
+			handler := cors.Middleware(tc.Config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(tc.Method, "/factlist/v1/fact/1", nil)
+			for k, v := range tc.Headers {
+				req.Header.Set(k, v)
+			}
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(tc.ExpectedStatus, rec.Code, "unexpected http status code")
+			for k, v := range tc.ExpectedHeaders {
+				assert.Equal(v, rec.Header().Get(k), "unexpected %s header", k)
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package pkg
+
+import "errors"
+
+var ErrInvalidDifficulty = errors.New("difficulty must be easy, medium or hard")
+
+// Difficulty is how hard a Fact is expected to be to answer
+type Difficulty string
+
+const (
+	DifficultyEasy   Difficulty = "easy"
+	DifficultyMedium Difficulty = "medium"
+	DifficultyHard   Difficulty = "hard"
+)
+
+// Valid reports whether d is a known difficulty, or unset
+func (d Difficulty) Valid() bool {
+	switch d {
+	case "", DifficultyEasy, DifficultyMedium, DifficultyHard:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,157 @@
+package pkg
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/markhaur/trivia/pkg/search"
+)
+
+var ErrInvalidDuplicateMode = errors.New("unknown duplicate mode")
+
+// DuplicateMode decides what happens when a Fact is saved with a question
+// similar to the question of a stored one
+type DuplicateMode string
+
+const (
+	DuplicatesOff    DuplicateMode = "off"
+	DuplicatesWarn   DuplicateMode = "warn"
+	DuplicatesReject DuplicateMode = "reject"
+)
+
+// Valid reports whether m is a known mode
+func (m DuplicateMode) Valid() bool {
+	switch m {
+	case DuplicatesOff, DuplicatesWarn, DuplicatesReject:
+		return true
+	}
+	return false
+}
+
+// Duplicate is a Fact suspected of asking the same question as another one
+type Duplicate struct {
+	Fact       Fact
+	Similarity float64
+}
+
+// DuplicateCluster groups facts suspected of asking the same question
+type DuplicateCluster struct {
+	Facts []Fact
+	// Similarity is the highest similarity between any two facts of the cluster
+	Similarity float64
+}
+
+// DuplicateDetector finds facts asking the same question in different words
+type DuplicateDetector struct {
+	// Threshold is the similarity, between 0 and 1, from which two questions
+	// are considered duplicates
+	Threshold float64
+}
+
+// Similar returns the existing facts whose question is similar to the
+// question of fact, most similar first
+func (d DuplicateDetector) Similar(fact Fact, existing []Fact) []Duplicate {
+	shingles := questionShingles(fact.Question)
+
+	var duplicates []Duplicate
+	for _, other := range existing {
+		if other.ID == fact.ID {
+			continue
+		}
+		if similarity := jaccard(shingles, questionShingles(other.Question)); similarity >= d.Threshold {
+			duplicates = append(duplicates, Duplicate{Fact: other, Similarity: similarity})
+		}
+	}
+	sort.SliceStable(duplicates, func(i, j int) bool { return duplicates[i].Similarity > duplicates[j].Similarity })
+	return duplicates
+}
+
+// Clusters groups facts whose questions are similar, directly or through
+// other facts of the same cluster. Facts without duplicates are left out.
+func (d DuplicateDetector) Clusters(facts []Fact) []DuplicateCluster {
+	shingles := make([]map[string]struct{}, len(facts))
+	for i, fact := range facts {
+		shingles[i] = questionShingles(fact.Question)
+	}
+
+	parent := make([]int, len(facts))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	best := make(map[int]float64)
+	for i := range facts {
+		for j := i + 1; j < len(facts); j++ {
+			similarity := jaccard(shingles[i], shingles[j])
+			if similarity < d.Threshold {
+				continue
+			}
+			ri, rj := find(i), find(j)
+			if ri != rj {
+				parent[rj] = ri
+				if best[rj] > best[ri] {
+					best[ri] = best[rj]
+				}
+			}
+			if similarity > best[ri] {
+				best[ri] = similarity
+			}
+		}
+	}
+
+	members := make(map[int][]Fact)
+	for i, fact := range facts {
+		root := find(i)
+		members[root] = append(members[root], fact)
+	}
+
+	var clusters []DuplicateCluster
+	for root, group := range members {
+		if len(group) < 2 {
+			continue
+		}
+		sort.Slice(group, func(i, j int) bool { return group[i].ID < group[j].ID })
+		clusters = append(clusters, DuplicateCluster{Facts: group, Similarity: best[root]})
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		if clusters[i].Similarity != clusters[j].Similarity {
+			return clusters[i].Similarity > clusters[j].Similarity
+		}
+		return clusters[i].Facts[0].ID < clusters[j].Facts[0].ID
+	})
+	return clusters
+}
+
+// questionShingles returns the character trigrams of the stemmed terms of
+// question, so that word order, inflection and punctuation don't matter
+func questionShingles(question string) map[string]struct{} {
+	shingles := make(map[string]struct{})
+	for _, term := range search.Terms(question) {
+		padded := []rune("$" + term + "$")
+		for i := 0; i+3 <= len(padded); i++ {
+			shingles[string(padded[i:i+3])] = struct{}{}
+		}
+	}
+	return shingles
+}
+
+// jaccard returns the size of the intersection of a and b over the size of their union
+func jaccard(a, b map[string]struct{}) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	var shared int
+	for shingle := range a {
+		if _, ok := b[shingle]; ok {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
@@ -9,6 +9,7 @@ import (
 var (
 	ErrFactNotFound      = errors.New("trivia not found")
 	ErrFactAlreadyExists = errors.New("trivia already exists")
+	ErrVersionConflict   = errors.New("trivia has been modified")
 )
 
 type Fact struct {
@@ -16,13 +17,67 @@ type Fact struct {
 	Question  string
 	Answer    string
 	CreatedAt time.Time
+	// Language is the BCP 47 tag of the language Question and Answer are written in
+	Language string
+	// Translations holds the question and answer in other languages, keyed by language tag
+	Translations map[string]Translation
+	// Category is the lower-case topic of the fact, such as "geography"
+	Category   string
+	Difficulty Difficulty
+	Status     Status
+	// Comments holds the review history of the fact, oldest first
+	Comments []ReviewComment
+	// Attachments holds the images and audio files of the fact, oldest first
+	Attachments []Attachment
+	// Sources holds the references backing up the answer
+	Sources []Source
+	// PublishAt and ExpireAt, when set, bound the time a published fact is shown to players
+	PublishAt time.Time
+	ExpireAt  time.Time
+	// Version is incremented by the repository on every write and is used
+	// for optimistic concurrency control
+	Version int64
+	// DeletedAt is set when the fact is moved to the trash
+	DeletedAt time.Time
 }
 
-// FactRepository is the interface used to persists the Fact(s)
+// Deleted reports whether the fact is in the trash
+func (f Fact) Deleted() bool { return !f.DeletedAt.IsZero() }
+
+// Published reports whether the fact may be served to players
+func (f Fact) Published() bool { return f.Status == StatusPublished }
+
+// FactRepository is the interface used to persists the Fact(s).
+// Update, DeleteByID and Restore fail with ErrVersionConflict if a non-zero
+// expected version is given and it doesn't match the stored one.
+// Deleted facts are kept in the trash, hidden from every method but
+// FindDeleted, Restore and Purge, until they are purged.
 type FactRepository interface {
 	Insert(context.Context, *Fact) error
 	FindAll(context.Context) ([]Fact, error)
 	FindByID(context.Context, int64) (*Fact, error)
 	Update(context.Context, *Fact) error
-	DeleteByID(context.Context, int64) error
+	// Modify atomically reads the fact with the given ID, applies fn to it and
+	// stores the result, unless fn returns an error
+	Modify(ctx context.Context, id int64, version int64, fn func(*Fact) error) (*Fact, error)
+	DeleteByID(ctx context.Context, id int64, version int64) error
+	// Merge atomically bumps the version of the fact with the given ID and
+	// deletes its duplicates, given as their IDs mapped to the versions
+	// expected of them. Nothing is changed if any fact is missing or modified.
+	Merge(ctx context.Context, id int64, version int64, duplicates map[int64]int64) (*Fact, error)
+	FindDeleted(context.Context) ([]Fact, error)
+	Restore(ctx context.Context, id int64, version int64) (*Fact, error)
+	// Purge permanently removes the facts deleted before the given time and returns them
+	Purge(ctx context.Context, before time.Time) ([]Fact, error)
+}
+
+// Pinger is optionally implemented by repositories that can report whether
+// their backing store is reachable
+type Pinger interface {
+	Ping(context.Context) error
+}
+
+// Flusher is optionally implemented by repositories that buffer writes
+type Flusher interface {
+	Flush(context.Context) error
 }
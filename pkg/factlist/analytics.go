@@ -0,0 +1,195 @@
+package factlist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/markhaur/trivia/pkg"
+)
+
+var (
+	ErrAnalyticsDisabled = errors.New("answer analytics are not enabled")
+	ErrInvalidLatency    = errors.New("latency must not be negative")
+)
+
+// maxAttemptLength bounds the length of the answers kept by the analytics sink
+const maxAttemptLength = 200
+
+// AnalyticsService records the answers players give and reports on them
+type AnalyticsService interface {
+	RecordAnswer(ctx context.Context, id int64, answer string, latency time.Duration) (*pkg.Fact, *pkg.Attempt, error)
+	FactStats(context.Context, int64) (*pkg.AnswerStats, error)
+	HardestFacts(ctx context.Context, minAttempts, limit int) ([]pkg.AnswerStats, error)
+	WrongAnswers(ctx context.Context, limit int) ([]pkg.AnswerStats, error)
+}
+
+// AnalyticsMiddleware describes an AnalyticsService Middleware
+type AnalyticsMiddleware func(AnalyticsService) AnalyticsService
+
+// NewAnalyticsService returns an AnalyticsService over the facts stored in
+// repository, configured by the same options as NewService. It fails with
+// ErrAnalyticsDisabled unless WithAnalytics is given.
+func NewAnalyticsService(repository pkg.FactRepository, opts ...Option) AnalyticsService {
+	return newService(repository, opts...)
+}
+
+// WithAnalytics records every answer attempt to sink
+func WithAnalytics(sink pkg.AnalyticsSink) Option {
+	return func(s *service) { s.analytics = sink }
+}
+
+// RecordAnswer checks the answer a player gave to the question of the
+// live fact with the given ID and records the attempt, attributing it to
+// the author from the context
+func (s *service) RecordAnswer(ctx context.Context, id int64, answer string, latency time.Duration) (*pkg.Fact, *pkg.Attempt, error) {
+	if s.analytics == nil {
+		return nil, nil, ErrAnalyticsDisabled
+	}
+	if latency < 0 {
+		return nil, nil, ErrInvalidLatency
+	}
+
+	fact, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !fact.Live(s.clock.Now()) {
+		return nil, nil, pkg.ErrFactNotFound
+	}
+
+	answer = strings.TrimSpace(answer)
+	if runes := []rune(answer); len(runes) > maxAttemptLength {
+		answer = string(runes[:maxAttemptLength])
+	}
+	attempt := pkg.Attempt{
+		FactID:  id,
+		Player:  pkg.AuthorFromContext(ctx),
+		Answer:  answer,
+		Correct: fact.Accepts(answer),
+		Latency: latency,
+		At:      s.clock.Now(),
+	}
+	if err := s.analytics.Record(ctx, attempt); err != nil {
+		return nil, nil, fmt.Errorf("could not record answer: %v", err)
+	}
+	return fact, &attempt, nil
+}
+
+// FactStats summarizes the recorded attempts at answering the fact with the given ID
+func (s *service) FactStats(ctx context.Context, id int64) (*pkg.AnswerStats, error) {
+	if s.analytics == nil {
+		return nil, ErrAnalyticsDisabled
+	}
+	if _, err := s.Get(ctx, id); err != nil {
+		return nil, err
+	}
+
+	stats, err := s.answerStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	fact, ok := stats[id]
+	if !ok {
+		fact = pkg.AnswerStats{FactID: id}
+	}
+	return &fact, nil
+}
+
+// HardestFacts returns up to limit facts attempted at least minAttempts
+// times, the least often answered correctly first
+func (s *service) HardestFacts(ctx context.Context, minAttempts, limit int) ([]pkg.AnswerStats, error) {
+	if s.analytics == nil {
+		return nil, ErrAnalyticsDisabled
+	}
+
+	stats, err := s.storedStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	hardest := make([]pkg.AnswerStats, 0, len(stats))
+	for _, fact := range stats {
+		if fact.Attempts >= minAttempts {
+			hardest = append(hardest, fact)
+		}
+	}
+	sort.Slice(hardest, func(i, j int) bool {
+		a, b := hardest[i], hardest[j]
+		if a.Accuracy() != b.Accuracy() {
+			return a.Accuracy() < b.Accuracy()
+		}
+		if a.Attempts != b.Attempts {
+			return a.Attempts > b.Attempts
+		}
+		return a.FactID < b.FactID
+	})
+	return truncateStats(hardest, limit), nil
+}
+
+// WrongAnswers returns up to limit facts that were answered wrongly, the ones
+// with the most common wrong answer first
+func (s *service) WrongAnswers(ctx context.Context, limit int) ([]pkg.AnswerStats, error) {
+	if s.analytics == nil {
+		return nil, ErrAnalyticsDisabled
+	}
+
+	stats, err := s.storedStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	confusing := make([]pkg.AnswerStats, 0, len(stats))
+	for _, fact := range stats {
+		if len(fact.WrongAnswers) > 0 {
+			confusing = append(confusing, fact)
+		}
+	}
+	sort.Slice(confusing, func(i, j int) bool {
+		a, b := confusing[i].WrongAnswers[0].Count, confusing[j].WrongAnswers[0].Count
+		if a != b {
+			return a > b
+		}
+		return confusing[i].FactID < confusing[j].FactID
+	})
+	return truncateStats(confusing, limit), nil
+}
+
+func (s *service) answerStats(ctx context.Context) (map[int64]pkg.AnswerStats, error) {
+	attempts, err := s.analytics.Attempts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not list answers: %v", err)
+	}
+	return pkg.SummarizeAttempts(attempts), nil
+}
+
+// storedStats returns the statistics of the facts the user may see that
+// haven't been removed since they were attempted
+func (s *service) storedStats(ctx context.Context) ([]pkg.AnswerStats, error) {
+	stats, err := s.answerStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	list, err := s.visibleFacts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	stored := make([]pkg.AnswerStats, 0, len(stats))
+	for _, fact := range list {
+		if fact, ok := stats[fact.ID]; ok {
+			stored = append(stored, fact)
+		}
+	}
+	return stored, nil
+}
+
+func truncateStats(stats []pkg.AnswerStats, limit int) []pkg.AnswerStats {
+	if limit > 0 && len(stats) > limit {
+		return stats[:limit]
+	}
+	return stats
+}
@@ -0,0 +1,111 @@
+package factlist
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/markhaur/trivia/pkg"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// AnalyticsLoggingMiddleware logs the calls made to a AnalyticsService
+func AnalyticsLoggingMiddleware(logger log.Logger) AnalyticsMiddleware {
+	return func(s AnalyticsService) AnalyticsService { return &analyticsLoggingMiddleware{logger, s} }
+}
+
+type analyticsLoggingMiddleware struct {
+	logger log.Logger
+	AnalyticsService
+}
+
+func (s *analyticsLoggingMiddleware) RecordAnswer(ctx context.Context, id int64, answer string, latency time.Duration) (_ *pkg.Fact, attempt *pkg.Attempt, err error) {
+	defer func(begin time.Time) {
+		var correct bool
+		if attempt != nil {
+			correct = attempt.Correct
+		}
+		level.Info(contextLogger(ctx, s.logger)).Log(
+			"method", "recordAnswer",
+			"id", id,
+			"correct", correct,
+			"latency", latency,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+	return s.AnalyticsService.RecordAnswer(ctx, id, answer, latency)
+}
+
+func (s *analyticsLoggingMiddleware) FactStats(ctx context.Context, id int64) (_ *pkg.AnswerStats, err error) {
+	defer func(begin time.Time) {
+		level.Info(contextLogger(ctx, s.logger)).Log(
+			"method", "factStats",
+			"id", id,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+	return s.AnalyticsService.FactStats(ctx, id)
+}
+
+func (s *analyticsLoggingMiddleware) HardestFacts(ctx context.Context, minAttempts, limit int) (_ []pkg.AnswerStats, err error) {
+	defer func(begin time.Time) {
+		level.Info(contextLogger(ctx, s.logger)).Log(
+			"method", "hardestFacts",
+			"minAttempts", minAttempts,
+			"limit", limit,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+	return s.AnalyticsService.HardestFacts(ctx, minAttempts, limit)
+}
+
+func (s *analyticsLoggingMiddleware) WrongAnswers(ctx context.Context, limit int) (_ []pkg.AnswerStats, err error) {
+	defer func(begin time.Time) {
+		level.Info(contextLogger(ctx, s.logger)).Log(
+			"method", "wrongAnswers",
+			"limit", limit,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+	return s.AnalyticsService.WrongAnswers(ctx, limit)
+}
+
+// AnalyticsTracingMiddleware starts a span for every call made to a AnalyticsService
+func AnalyticsTracingMiddleware(tracer trace.Tracer) AnalyticsMiddleware {
+	return func(s AnalyticsService) AnalyticsService { return &analyticsTracingMiddleware{tracer, s} }
+}
+
+type analyticsTracingMiddleware struct {
+	tracer trace.Tracer
+	AnalyticsService
+}
+
+func (s *analyticsTracingMiddleware) RecordAnswer(ctx context.Context, id int64, answer string, latency time.Duration) (_ *pkg.Fact, _ *pkg.Attempt, err error) {
+	ctx, span := s.tracer.Start(ctx, "factlist.RecordAnswer", trace.WithAttributes(attribute.Int64("fact.id", id)))
+	defer func() { endSpan(span, err) }()
+	return s.AnalyticsService.RecordAnswer(ctx, id, answer, latency)
+}
+
+func (s *analyticsTracingMiddleware) FactStats(ctx context.Context, id int64) (_ *pkg.AnswerStats, err error) {
+	ctx, span := s.tracer.Start(ctx, "factlist.FactStats", trace.WithAttributes(attribute.Int64("fact.id", id)))
+	defer func() { endSpan(span, err) }()
+	return s.AnalyticsService.FactStats(ctx, id)
+}
+
+func (s *analyticsTracingMiddleware) HardestFacts(ctx context.Context, minAttempts, limit int) (_ []pkg.AnswerStats, err error) {
+	ctx, span := s.tracer.Start(ctx, "factlist.HardestFacts")
+	defer func() { endSpan(span, err) }()
+	return s.AnalyticsService.HardestFacts(ctx, minAttempts, limit)
+}
+
+func (s *analyticsTracingMiddleware) WrongAnswers(ctx context.Context, limit int) (_ []pkg.AnswerStats, err error) {
+	ctx, span := s.tracer.Start(ctx, "factlist.WrongAnswers")
+	defer func() { endSpan(span, err) }()
+	return s.AnalyticsService.WrongAnswers(ctx, limit)
+}
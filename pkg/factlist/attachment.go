@@ -0,0 +1,221 @@
+package factlist
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/markhaur/trivia/pkg"
+	"github.com/markhaur/trivia/pkg/users"
+)
+
+var ErrAttachmentsDisabled = errors.New("fact attachments are not enabled")
+
+// sniffLength is the number of bytes http.DetectContentType looks at
+const sniffLength = 512
+
+// AttachmentService lets images and audio files be attached to facts
+type AttachmentService interface {
+	Attach(ctx context.Context, id int64, filename string, content io.Reader, version int64) (*pkg.Fact, *pkg.Attachment, error)
+	OpenAttachment(ctx context.Context, id int64, attachmentID string) (*pkg.Attachment, io.ReadCloser, error)
+	Detach(ctx context.Context, id int64, attachmentID string, version int64) error
+}
+
+// AttachmentMiddleware describes an AttachmentService Middleware
+type AttachmentMiddleware func(AttachmentService) AttachmentService
+
+// NewAttachmentService returns an AttachmentService over the facts stored in
+// repository, configured by the same options as NewService. It fails with
+// ErrAttachmentsDisabled unless WithAttachments is given.
+func NewAttachmentService(repository pkg.FactRepository, opts ...Option) AttachmentService {
+	return newService(repository, opts...)
+}
+
+// WithAttachments stores the content of the attachments satisfying policy in blobs
+func WithAttachments(blobs pkg.BlobStore, policy pkg.AttachmentPolicy) Option {
+	return func(s *service) { s.blobs, s.attachment = blobs, policy }
+}
+
+// Attach stores content as a new attachment of the fact with the given ID.
+// The media type is detected from the content rather than trusted from the
+// client. A non-zero version is the version the caller expects to attach to.
+// Only signed-in users may attach files, and only reviewers to published facts.
+func (s *service) Attach(ctx context.Context, id int64, filename string, content io.Reader, version int64) (*pkg.Fact, *pkg.Attachment, error) {
+	if s.blobs == nil {
+		return nil, nil, ErrAttachmentsDisabled
+	}
+	// fail before storing anything if the fact can't be attached to
+	if err := s.mayAttach(ctx, id); err != nil {
+		return nil, nil, err
+	}
+
+	head := make([]byte, sniffLength)
+	n, err := io.ReadFull(content, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, nil, fmt.Errorf("could not read attachment: %v", err)
+	}
+	contentType := http.DetectContentType(head[:n])
+	if n == 0 || !s.attachment.Allows(contentType) {
+		return nil, nil, pkg.ErrUnsupportedMediaType
+	}
+
+	key, err := newAttachmentID()
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not generate attachment id: %v", err)
+	}
+
+	// read one byte more than allowed to tell whether the content is too large
+	var (
+		checksum = sha256.New()
+		limited  = &io.LimitedReader{R: io.MultiReader(bytes.NewReader(head[:n]), content), N: s.attachment.MaxSize + 1}
+	)
+	if err := s.blobs.Put(ctx, key, io.TeeReader(limited, checksum)); err != nil {
+		return nil, nil, fmt.Errorf("could not store attachment: %v", err)
+	}
+	if limited.N == 0 {
+		s.blobs.Delete(ctx, key)
+		return nil, nil, pkg.ErrAttachmentTooLarge
+	}
+
+	attachment := pkg.Attachment{
+		ID:          key,
+		Filename:    sanitizeFilename(filename),
+		ContentType: contentType,
+		Size:        s.attachment.MaxSize + 1 - limited.N,
+		Checksum:    hex.EncodeToString(checksum.Sum(nil)),
+		CreatedAt:   s.clock.Now().UTC(),
+	}
+	fact, err := s.repository.Modify(ctx, id, version, func(fact *pkg.Fact) error {
+		if err := s.mayChange(ctx, *fact); err != nil {
+			return err
+		}
+		fact.Attachments = append(fact.Attachments, attachment)
+		return nil
+	})
+	if err != nil {
+		s.blobs.Delete(ctx, key)
+		if err == pkg.ErrFactNotFound || err == pkg.ErrVersionConflict || denied(err) {
+			return nil, nil, err
+		}
+		return nil, nil, fmt.Errorf("could not attach to fact: %v", err)
+	}
+
+	if err := s.record(ctx, pkg.ActionAttach, *fact, pkg.Change{Field: "attachments", To: attachment.ID}); err != nil {
+		return nil, nil, err
+	}
+	return fact, &attachment, nil
+}
+
+// OpenAttachment returns an attachment of the fact with the given ID along
+// with its content, which the caller must close
+func (s *service) OpenAttachment(ctx context.Context, id int64, attachmentID string) (*pkg.Attachment, io.ReadCloser, error) {
+	if s.blobs == nil {
+		return nil, nil, ErrAttachmentsDisabled
+	}
+
+	fact, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, attachment := range fact.Attachments {
+		if attachment.ID != attachmentID {
+			continue
+		}
+		content, err := s.blobs.Get(ctx, attachment.ID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not open attachment: %v", err)
+		}
+		return &attachment, content, nil
+	}
+	return nil, nil, pkg.ErrAttachmentNotFound
+}
+
+// Detach removes an attachment from the fact with the given ID and deletes
+// its content. A non-zero version is the version the caller expects to detach from.
+// Only signed-in users may detach files, and only reviewers from published facts.
+func (s *service) Detach(ctx context.Context, id int64, attachmentID string, version int64) error {
+	if s.blobs == nil {
+		return ErrAttachmentsDisabled
+	}
+	if err := s.mayAttach(ctx, id); err != nil {
+		return err
+	}
+
+	var detached pkg.Attachment
+	fact, err := s.repository.Modify(ctx, id, version, func(fact *pkg.Fact) error {
+		if err := s.mayChange(ctx, *fact); err != nil {
+			return err
+		}
+		for i, attachment := range fact.Attachments {
+			if attachment.ID == attachmentID {
+				detached = attachment
+				fact.Attachments = append(fact.Attachments[:i], fact.Attachments[i+1:]...)
+				return nil
+			}
+		}
+		return pkg.ErrAttachmentNotFound
+	})
+	if err != nil {
+		switch err {
+		case pkg.ErrFactNotFound, pkg.ErrVersionConflict, pkg.ErrAttachmentNotFound, users.ErrUnauthenticated, ErrFactPublished:
+			return err
+		}
+		return fmt.Errorf("could not detach from fact: %v", err)
+	}
+
+	if err := s.deleteBlobs(ctx, []pkg.Attachment{detached}); err != nil {
+		return err
+	}
+	return s.record(ctx, pkg.ActionDetach, *fact, pkg.Change{Field: "attachments", From: detached.ID})
+}
+
+// mayAttach fails unless the user who made the request may change the
+// attachments of the fact with the given ID
+func (s *service) mayAttach(ctx context.Context, id int64) error {
+	if _, err := authenticated(ctx); err != nil {
+		return err
+	}
+	fact, err := s.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	return s.mayChange(ctx, *fact)
+}
+
+// deleteBlobs deletes the content of attachments, if attachments are enabled
+func (s *service) deleteBlobs(ctx context.Context, attachments []pkg.Attachment) error {
+	if s.blobs == nil {
+		return nil
+	}
+	for _, attachment := range attachments {
+		if err := s.blobs.Delete(ctx, attachment.ID); err != nil {
+			return fmt.Errorf("could not delete attachment: %v", err)
+		}
+	}
+	return nil
+}
+
+func newAttachmentID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// sanitizeFilename drops any directory from a client supplied filename
+func sanitizeFilename(filename string) string {
+	filename = filepath.Base(strings.ReplaceAll(filename, `\`, "/"))
+	if filename == "." || filename == "/" {
+		return ""
+	}
+	return filename
+}
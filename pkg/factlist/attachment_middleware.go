@@ -0,0 +1,98 @@
+package factlist
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/markhaur/trivia/pkg"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// AttachmentLoggingMiddleware logs the calls made to a AttachmentService
+func AttachmentLoggingMiddleware(logger log.Logger) AttachmentMiddleware {
+	return func(s AttachmentService) AttachmentService { return &attachmentLoggingMiddleware{logger, s} }
+}
+
+type attachmentLoggingMiddleware struct {
+	logger log.Logger
+	AttachmentService
+}
+
+func (s *attachmentLoggingMiddleware) Attach(ctx context.Context, id int64, filename string, content io.Reader, version int64) (_ *pkg.Fact, _ *pkg.Attachment, err error) {
+	defer func(begin time.Time) {
+		level.Info(contextLogger(ctx, s.logger)).Log(
+			"method", "attach",
+			"id", id,
+			"filename", filename,
+			"version", version,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+	return s.AttachmentService.Attach(ctx, id, filename, content, version)
+}
+
+func (s *attachmentLoggingMiddleware) OpenAttachment(ctx context.Context, id int64, attachmentID string) (_ *pkg.Attachment, _ io.ReadCloser, err error) {
+	defer func(begin time.Time) {
+		level.Info(contextLogger(ctx, s.logger)).Log(
+			"method", "openAttachment",
+			"id", id,
+			"attachment", attachmentID,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+	return s.AttachmentService.OpenAttachment(ctx, id, attachmentID)
+}
+
+func (s *attachmentLoggingMiddleware) Detach(ctx context.Context, id int64, attachmentID string, version int64) (err error) {
+	defer func(begin time.Time) {
+		level.Info(contextLogger(ctx, s.logger)).Log(
+			"method", "detach",
+			"id", id,
+			"attachment", attachmentID,
+			"version", version,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+	return s.AttachmentService.Detach(ctx, id, attachmentID, version)
+}
+
+// AttachmentTracingMiddleware starts a span for every call made to a AttachmentService
+func AttachmentTracingMiddleware(tracer trace.Tracer) AttachmentMiddleware {
+	return func(s AttachmentService) AttachmentService { return &attachmentTracingMiddleware{tracer, s} }
+}
+
+type attachmentTracingMiddleware struct {
+	tracer trace.Tracer
+	AttachmentService
+}
+
+func (s *attachmentTracingMiddleware) Attach(ctx context.Context, id int64, filename string, content io.Reader, version int64) (_ *pkg.Fact, _ *pkg.Attachment, err error) {
+	ctx, span := s.tracer.Start(ctx, "factlist.Attach", trace.WithAttributes(attribute.Int64("fact.id", id)))
+	defer func() { endSpan(span, err) }()
+	return s.AttachmentService.Attach(ctx, id, filename, content, version)
+}
+
+func (s *attachmentTracingMiddleware) OpenAttachment(ctx context.Context, id int64, attachmentID string) (_ *pkg.Attachment, _ io.ReadCloser, err error) {
+	ctx, span := s.tracer.Start(ctx, "factlist.OpenAttachment", trace.WithAttributes(
+		attribute.Int64("fact.id", id),
+		attribute.String("attachment.id", attachmentID),
+	))
+	defer func() { endSpan(span, err) }()
+	return s.AttachmentService.OpenAttachment(ctx, id, attachmentID)
+}
+
+func (s *attachmentTracingMiddleware) Detach(ctx context.Context, id int64, attachmentID string, version int64) (err error) {
+	ctx, span := s.tracer.Start(ctx, "factlist.Detach", trace.WithAttributes(
+		attribute.Int64("fact.id", id),
+		attribute.String("attachment.id", attachmentID),
+	))
+	defer func() { endSpan(span, err) }()
+	return s.AttachmentService.Detach(ctx, id, attachmentID, version)
+}
@@ -0,0 +1,299 @@
+package factlist
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/markhaur/trivia/pkg"
+)
+
+var (
+	ErrCollectionsDisabled = errors.New("collections are not enabled")
+	ErrNotCollectionOwner  = errors.New("collection belongs to another author")
+	ErrInvalidOrder        = errors.New("order must list every trivia of the collection exactly once")
+)
+
+// slugAttempts bounds the number of random slugs tried when sharing a collection
+const slugAttempts = 5
+
+// CollectionService lets quiz hosts assemble facts into collections and share them
+type CollectionService interface {
+	CreateCollection(context.Context, pkg.Collection) (*pkg.Collection, error)
+	Collections(context.Context) ([]pkg.Collection, error)
+	GetCollection(context.Context, int64) (*pkg.Collection, []pkg.Fact, error)
+	UpdateCollection(context.Context, pkg.Collection) (*pkg.Collection, error)
+	RemoveCollection(context.Context, int64) error
+	ReorderCollection(ctx context.Context, id int64, factIDs []int64) (*pkg.Collection, error)
+	ShareCollection(ctx context.Context, id int64, shared bool) (*pkg.Collection, error)
+	SharedCollection(ctx context.Context, slug string) (*pkg.Collection, []pkg.Fact, error)
+}
+
+// CollectionMiddleware describes a CollectionService Middleware
+type CollectionMiddleware func(CollectionService) CollectionService
+
+// NewCollectionService returns a CollectionService over the facts stored in
+// repository, configured by the same options as NewService. It fails with
+// ErrCollectionsDisabled unless WithCollections is given.
+func NewCollectionService(repository pkg.FactRepository, opts ...Option) CollectionService {
+	return newService(repository, opts...)
+}
+
+// WithCollections lets quiz hosts assemble facts into collections stored in collections
+func WithCollections(collections pkg.CollectionRepository) Option {
+	return func(s *service) { s.collections = collections }
+}
+
+// CreateCollection stores a new collection owned by the authenticated user
+func (s *service) CreateCollection(ctx context.Context, collection pkg.Collection) (*pkg.Collection, error) {
+	if s.collections == nil {
+		return nil, ErrCollectionsDisabled
+	}
+	owner, err := authenticated(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now()
+	collection.ID, collection.Slug = 0, ""
+	collection.Owner = owner.Username
+	collection.CreatedAt, collection.UpdatedAt = now, now
+	if err := s.validateCollection(ctx, &collection); err != nil {
+		return nil, err
+	}
+	if err := s.collections.Insert(ctx, &collection); err != nil {
+		return nil, fmt.Errorf("could not save collection: %v", err)
+	}
+	return &collection, nil
+}
+
+// Collections returns the collections owned by the authenticated user
+func (s *service) Collections(ctx context.Context) ([]pkg.Collection, error) {
+	if s.collections == nil {
+		return nil, ErrCollectionsDisabled
+	}
+	owner, err := authenticated(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := s.collections.FindAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not list collections: %v", err)
+	}
+	owned := make([]pkg.Collection, 0, len(list))
+	for _, c := range list {
+		if c.Owner == owner.Username {
+			owned = append(owned, c)
+		}
+	}
+	return owned, nil
+}
+
+// GetCollection returns the collection with the given ID along with the facts
+// of it players may see, in order
+func (s *service) GetCollection(ctx context.Context, id int64) (*pkg.Collection, []pkg.Fact, error) {
+	collection, err := s.ownedCollection(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	facts, err := s.collectionFacts(ctx, *collection)
+	if err != nil {
+		return nil, nil, err
+	}
+	return collection, facts, nil
+}
+
+// UpdateCollection replaces the name, description and facts of the collection with the same ID
+func (s *service) UpdateCollection(ctx context.Context, collection pkg.Collection) (*pkg.Collection, error) {
+	stored, err := s.ownedCollection(ctx, collection.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	stored.Name, stored.Description, stored.FactIDs = collection.Name, collection.Description, collection.FactIDs
+	if err := s.validateCollection(ctx, stored); err != nil {
+		return nil, err
+	}
+	return s.saveCollection(ctx, stored)
+}
+
+// RemoveCollection deletes the collection with the given ID
+func (s *service) RemoveCollection(ctx context.Context, id int64) error {
+	if _, err := s.ownedCollection(ctx, id); err != nil {
+		return err
+	}
+	if err := s.collections.DeleteByID(ctx, id); err != nil {
+		if err == pkg.ErrCollectionNotFound {
+			return err
+		}
+		return fmt.Errorf("could not remove collection: %v", err)
+	}
+	return nil
+}
+
+// ReorderCollection changes the order the facts of the collection with the
+// given ID are asked in. factIDs must list every fact of the collection.
+func (s *service) ReorderCollection(ctx context.Context, id int64, factIDs []int64) (*pkg.Collection, error) {
+	stored, err := s.ownedCollection(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(factIDs) != len(stored.FactIDs) {
+		return nil, ErrInvalidOrder
+	}
+	remaining := make(map[int64]bool, len(stored.FactIDs))
+	for _, id := range stored.FactIDs {
+		remaining[id] = true
+	}
+	for _, id := range factIDs {
+		if !remaining[id] {
+			return nil, ErrInvalidOrder
+		}
+		delete(remaining, id)
+	}
+
+	stored.FactIDs = factIDs
+	return s.saveCollection(ctx, stored)
+}
+
+// ShareCollection gives the collection with the given ID a public slug
+// derived from its name, or takes it away when shared is false
+func (s *service) ShareCollection(ctx context.Context, id int64, shared bool) (*pkg.Collection, error) {
+	stored, err := s.ownedCollection(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !shared {
+		stored.Slug = ""
+		return s.saveCollection(ctx, stored)
+	}
+	if stored.Shared() {
+		return stored, nil
+	}
+
+	base := pkg.Slugify(stored.Name)
+	if base == "" {
+		base = "collection"
+	}
+	for i := 0; i < slugAttempts; i++ {
+		suffix, err := randomSuffix()
+		if err != nil {
+			return nil, fmt.Errorf("could not generate slug: %v", err)
+		}
+		stored.Slug = base + "-" + suffix
+		updated, err := s.saveCollection(ctx, stored)
+		if err != pkg.ErrSlugTaken {
+			return updated, err
+		}
+	}
+	return nil, pkg.ErrSlugTaken
+}
+
+// SharedCollection returns the collection shared under slug along with the
+// facts of it players may see, in order
+func (s *service) SharedCollection(ctx context.Context, slug string) (*pkg.Collection, []pkg.Fact, error) {
+	if s.collections == nil {
+		return nil, nil, ErrCollectionsDisabled
+	}
+
+	collection, err := s.collections.FindBySlug(ctx, slug)
+	if err != nil {
+		if err == pkg.ErrCollectionNotFound {
+			return nil, nil, err
+		}
+		return nil, nil, fmt.Errorf("could not find collection: %v", err)
+	}
+	facts, err := s.collectionFacts(ctx, *collection)
+	if err != nil {
+		return nil, nil, err
+	}
+	return collection, facts, nil
+}
+
+// ownedCollection returns the collection with the given ID if the
+// authenticated user owns it
+func (s *service) ownedCollection(ctx context.Context, id int64) (*pkg.Collection, error) {
+	if s.collections == nil {
+		return nil, ErrCollectionsDisabled
+	}
+	owner, err := authenticated(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	collection, err := s.collections.FindByID(ctx, id)
+	if err != nil {
+		if err == pkg.ErrCollectionNotFound {
+			return nil, err
+		}
+		return nil, fmt.Errorf("could not find collection: %v", err)
+	}
+	if collection.Owner != owner.Username {
+		return nil, ErrNotCollectionOwner
+	}
+	return collection, nil
+}
+
+func (s *service) saveCollection(ctx context.Context, collection *pkg.Collection) (*pkg.Collection, error) {
+	collection.UpdatedAt = s.clock.Now()
+	if err := s.collections.Update(ctx, collection); err != nil {
+		if err == pkg.ErrCollectionNotFound || err == pkg.ErrSlugTaken {
+			return nil, err
+		}
+		return nil, fmt.Errorf("could not update collection: %v", err)
+	}
+	return collection, nil
+}
+
+// validateCollection normalizes collection and checks that every fact it lists exists
+func (s *service) validateCollection(ctx context.Context, collection *pkg.Collection) error {
+	collection.Normalize()
+	if err := collection.Validate(); err != nil {
+		return err
+	}
+
+	verr := &pkg.ValidationError{Subject: "collection"}
+	for i, id := range collection.FactIDs {
+		_, err := s.repository.FindByID(ctx, id)
+		switch {
+		case err == pkg.ErrFactNotFound:
+			verr.Add(fmt.Sprintf("factIds[%d]", i), "must refer to an existing trivia")
+		case err != nil:
+			return fmt.Errorf("could not get fact: %v", err)
+		}
+	}
+	return verr.Err()
+}
+
+// collectionFacts returns the facts of collection players may see, in order.
+// Facts removed or unpublished since they were added are left out.
+func (s *service) collectionFacts(ctx context.Context, collection pkg.Collection) ([]pkg.Fact, error) {
+	live, err := s.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[int64]pkg.Fact, len(live))
+	for _, fact := range live {
+		byID[fact.ID] = fact
+	}
+
+	facts := make([]pkg.Fact, 0, len(collection.FactIDs))
+	for _, id := range collection.FactIDs {
+		if fact, ok := byID[id]; ok {
+			facts = append(facts, fact)
+		}
+	}
+	return facts, nil
+}
+
+func randomSuffix() (string, error) {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
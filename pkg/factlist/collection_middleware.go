@@ -0,0 +1,186 @@
+package factlist
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/markhaur/trivia/pkg"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// CollectionLoggingMiddleware logs the calls made to a CollectionService
+func CollectionLoggingMiddleware(logger log.Logger) CollectionMiddleware {
+	return func(s CollectionService) CollectionService { return &collectionLoggingMiddleware{logger, s} }
+}
+
+type collectionLoggingMiddleware struct {
+	logger log.Logger
+	CollectionService
+}
+
+func (s *collectionLoggingMiddleware) CreateCollection(ctx context.Context, collection pkg.Collection) (created *pkg.Collection, err error) {
+	defer func(begin time.Time) {
+		var id int64
+		if created != nil {
+			id = created.ID
+		}
+		level.Info(contextLogger(ctx, s.logger)).Log(
+			"method", "createCollection",
+			"id", id,
+			"facts", len(collection.FactIDs),
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+	return s.CollectionService.CreateCollection(ctx, collection)
+}
+
+func (s *collectionLoggingMiddleware) Collections(ctx context.Context) (list []pkg.Collection, err error) {
+	defer func(begin time.Time) {
+		level.Info(contextLogger(ctx, s.logger)).Log(
+			"method", "collections",
+			"count", len(list),
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+	return s.CollectionService.Collections(ctx)
+}
+
+func (s *collectionLoggingMiddleware) GetCollection(ctx context.Context, id int64) (_ *pkg.Collection, _ []pkg.Fact, err error) {
+	defer func(begin time.Time) {
+		level.Info(contextLogger(ctx, s.logger)).Log(
+			"method", "getCollection",
+			"id", id,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+	return s.CollectionService.GetCollection(ctx, id)
+}
+
+func (s *collectionLoggingMiddleware) UpdateCollection(ctx context.Context, collection pkg.Collection) (_ *pkg.Collection, err error) {
+	defer func(begin time.Time) {
+		level.Info(contextLogger(ctx, s.logger)).Log(
+			"method", "updateCollection",
+			"id", collection.ID,
+			"facts", len(collection.FactIDs),
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+	return s.CollectionService.UpdateCollection(ctx, collection)
+}
+
+func (s *collectionLoggingMiddleware) RemoveCollection(ctx context.Context, id int64) (err error) {
+	defer func(begin time.Time) {
+		level.Info(contextLogger(ctx, s.logger)).Log(
+			"method", "removeCollection",
+			"id", id,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+	return s.CollectionService.RemoveCollection(ctx, id)
+}
+
+func (s *collectionLoggingMiddleware) ReorderCollection(ctx context.Context, id int64, factIDs []int64) (_ *pkg.Collection, err error) {
+	defer func(begin time.Time) {
+		level.Info(contextLogger(ctx, s.logger)).Log(
+			"method", "reorderCollection",
+			"id", id,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+	return s.CollectionService.ReorderCollection(ctx, id, factIDs)
+}
+
+func (s *collectionLoggingMiddleware) ShareCollection(ctx context.Context, id int64, shared bool) (_ *pkg.Collection, err error) {
+	defer func(begin time.Time) {
+		level.Info(contextLogger(ctx, s.logger)).Log(
+			"method", "shareCollection",
+			"id", id,
+			"shared", shared,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+	return s.CollectionService.ShareCollection(ctx, id, shared)
+}
+
+func (s *collectionLoggingMiddleware) SharedCollection(ctx context.Context, slug string) (_ *pkg.Collection, _ []pkg.Fact, err error) {
+	defer func(begin time.Time) {
+		level.Info(contextLogger(ctx, s.logger)).Log(
+			"method", "sharedCollection",
+			"slug", slug,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+	return s.CollectionService.SharedCollection(ctx, slug)
+}
+
+// CollectionTracingMiddleware starts a span for every call made to a CollectionService
+func CollectionTracingMiddleware(tracer trace.Tracer) CollectionMiddleware {
+	return func(s CollectionService) CollectionService { return &collectionTracingMiddleware{tracer, s} }
+}
+
+type collectionTracingMiddleware struct {
+	tracer trace.Tracer
+	CollectionService
+}
+
+func (s *collectionTracingMiddleware) CreateCollection(ctx context.Context, collection pkg.Collection) (_ *pkg.Collection, err error) {
+	ctx, span := s.tracer.Start(ctx, "factlist.CreateCollection")
+	defer func() { endSpan(span, err) }()
+	return s.CollectionService.CreateCollection(ctx, collection)
+}
+
+func (s *collectionTracingMiddleware) Collections(ctx context.Context) (_ []pkg.Collection, err error) {
+	ctx, span := s.tracer.Start(ctx, "factlist.Collections")
+	defer func() { endSpan(span, err) }()
+	return s.CollectionService.Collections(ctx)
+}
+
+func (s *collectionTracingMiddleware) GetCollection(ctx context.Context, id int64) (_ *pkg.Collection, _ []pkg.Fact, err error) {
+	ctx, span := s.tracer.Start(ctx, "factlist.GetCollection", trace.WithAttributes(attribute.Int64("collection.id", id)))
+	defer func() { endSpan(span, err) }()
+	return s.CollectionService.GetCollection(ctx, id)
+}
+
+func (s *collectionTracingMiddleware) UpdateCollection(ctx context.Context, collection pkg.Collection) (_ *pkg.Collection, err error) {
+	ctx, span := s.tracer.Start(ctx, "factlist.UpdateCollection", trace.WithAttributes(attribute.Int64("collection.id", collection.ID)))
+	defer func() { endSpan(span, err) }()
+	return s.CollectionService.UpdateCollection(ctx, collection)
+}
+
+func (s *collectionTracingMiddleware) RemoveCollection(ctx context.Context, id int64) (err error) {
+	ctx, span := s.tracer.Start(ctx, "factlist.RemoveCollection", trace.WithAttributes(attribute.Int64("collection.id", id)))
+	defer func() { endSpan(span, err) }()
+	return s.CollectionService.RemoveCollection(ctx, id)
+}
+
+func (s *collectionTracingMiddleware) ReorderCollection(ctx context.Context, id int64, factIDs []int64) (_ *pkg.Collection, err error) {
+	ctx, span := s.tracer.Start(ctx, "factlist.ReorderCollection", trace.WithAttributes(attribute.Int64("collection.id", id)))
+	defer func() { endSpan(span, err) }()
+	return s.CollectionService.ReorderCollection(ctx, id, factIDs)
+}
+
+func (s *collectionTracingMiddleware) ShareCollection(ctx context.Context, id int64, shared bool) (_ *pkg.Collection, err error) {
+	ctx, span := s.tracer.Start(ctx, "factlist.ShareCollection", trace.WithAttributes(
+		attribute.Int64("collection.id", id),
+		attribute.Bool("collection.shared", shared),
+	))
+	defer func() { endSpan(span, err) }()
+	return s.CollectionService.ShareCollection(ctx, id, shared)
+}
+
+func (s *collectionTracingMiddleware) SharedCollection(ctx context.Context, slug string) (_ *pkg.Collection, _ []pkg.Fact, err error) {
+	ctx, span := s.tracer.Start(ctx, "factlist.SharedCollection", trace.WithAttributes(attribute.String("collection.slug", slug)))
+	defer func() { endSpan(span, err) }()
+	return s.CollectionService.SharedCollection(ctx, slug)
+}
@@ -0,0 +1,133 @@
+package factlist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/markhaur/trivia/pkg"
+)
+
+var (
+	ErrDuplicateDetectionDisabled = errors.New("duplicate detection is not enabled")
+	ErrInvalidMerge               = errors.New("a trivia can only be merged with other, distinct trivia")
+)
+
+func (s *service) detectsDuplicates() bool {
+	return s.duplicateMode == pkg.DuplicatesWarn || s.duplicateMode == pkg.DuplicatesReject
+}
+
+// rejectDuplicates fails with a *pkg.ValidationError if fact asks the same
+// question as a stored fact. Only the facts the user may see are named.
+func (s *service) rejectDuplicates(ctx context.Context, fact pkg.Fact) error {
+	existing, err := s.repository.FindAll(ctx)
+	if err != nil {
+		return fmt.Errorf("could not list facts: %v", err)
+	}
+
+	visible := s.visibleTo(ctx)
+	verr := &pkg.ValidationError{}
+	for _, duplicate := range s.detector.Similar(fact, existing) {
+		if visible(duplicate.Fact) {
+			verr.Add("question", fmt.Sprintf("is similar to trivia %d", duplicate.Fact.ID))
+		} else {
+			verr.Add("question", "is similar to another trivia")
+		}
+	}
+	return verr.Err()
+}
+
+// Duplicates returns the facts suspected of asking the same question as the
+// fact with the given ID, most similar first. Only the facts the user may
+// see are returned, so submitters are warned about published facts alone.
+func (s *service) Duplicates(ctx context.Context, id int64) ([]pkg.Duplicate, error) {
+	if !s.detectsDuplicates() {
+		return nil, ErrDuplicateDetectionDisabled
+	}
+
+	fact, err := s.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := s.visibleFacts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.detector.Similar(*fact, existing), nil
+}
+
+// DuplicateClusters groups the stored facts suspected of asking the same
+// question. Players only group the facts they may see.
+func (s *service) DuplicateClusters(ctx context.Context) ([]pkg.DuplicateCluster, error) {
+	if !s.detectsDuplicates() {
+		return nil, ErrDuplicateDetectionDisabled
+	}
+
+	list, err := s.visibleFacts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.detector.Clusters(list), nil
+}
+
+// Merge keeps the fact with the given ID and moves its duplicates to the
+// trash, recording the merge in the history of every fact involved. A
+// non-zero version is the version of the kept fact the caller expects.
+// Either every fact is merged or none is. Only reviewers may merge published facts.
+func (s *service) Merge(ctx context.Context, id int64, duplicates []int64, version int64) (*pkg.Fact, error) {
+	if len(duplicates) == 0 {
+		return nil, ErrInvalidMerge
+	}
+
+	kept, err := s.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.mayChange(ctx, *kept); err != nil {
+		return nil, err
+	}
+
+	versions := make(map[int64]int64, len(duplicates))
+	merged := make([]pkg.Fact, 0, len(duplicates))
+	for _, duplicate := range duplicates {
+		if _, seen := versions[duplicate]; duplicate == id || seen {
+			return nil, ErrInvalidMerge
+		}
+
+		fact, err := s.find(ctx, duplicate)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.mayChange(ctx, *fact); err != nil {
+			return nil, err
+		}
+		versions[duplicate] = fact.Version
+		merged = append(merged, *fact)
+	}
+
+	// the version of the kept fact is bumped so clients holding its old
+	// entity tag notice the merge
+	kept, err = s.repository.Merge(ctx, id, version, versions)
+	if err != nil {
+		if err == pkg.ErrFactNotFound || err == pkg.ErrVersionConflict {
+			return nil, err
+		}
+		return nil, fmt.Errorf("could not merge facts: %v", err)
+	}
+
+	ids := make([]string, 0, len(merged))
+	for _, fact := range merged {
+		into := pkg.Change{Field: "mergedInto", To: strconv.FormatInt(id, 10)}
+		if err := s.record(ctx, pkg.ActionMerge, fact, into); err != nil {
+			return nil, err
+		}
+		ids = append(ids, strconv.FormatInt(fact.ID, 10))
+	}
+
+	if err := s.record(ctx, pkg.ActionMerge, *kept, pkg.Change{Field: "merged", To: strings.Join(ids, ", ")}); err != nil {
+		return nil, err
+	}
+	return kept, nil
+}
@@ -1,43 +1,361 @@
 package factlist
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"hash/fnv"
+	"io"
+	"mime"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
 	"github.com/markhaur/trivia/pkg"
+	"github.com/markhaur/trivia/pkg/requestid"
+	"github.com/markhaur/trivia/pkg/users"
 	"github.com/matryer/way"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/text/language"
 )
 
-func NewServer(service Service, logger log.Logger) http.Handler {
+// NewServer returns an http.Handler serving the facts of service. The routes
+// of the other services are only served when given by opts.
+func NewServer(service Service, logger log.Logger, opts ...ServerOption) http.Handler {
 	s := server{service: service}
+	for _, opt := range opts {
+		opt(&s)
+	}
 
 	var handleSaveFact http.Handler
 	handleSaveFact = s.handleSaveFact()
 	handleSaveFact = httpLoggingMiddleware(logger, "handleSaveFact")(handleSaveFact)
+	handleSaveFact = httpTracingMiddleware("handleSaveFact")(handleSaveFact)
 
 	var handleListFact http.Handler
 	handleListFact = s.handleListFact()
 	handleListFact = httpLoggingMiddleware(logger, "handleListFact")(handleListFact)
+	handleListFact = httpTracingMiddleware("handleListFact")(handleListFact)
+
+	var handleGetFact http.Handler
+	handleGetFact = s.handleGetFact()
+	handleGetFact = httpLoggingMiddleware(logger, "handleGetFact")(handleGetFact)
+	handleGetFact = httpTracingMiddleware("handleGetFact")(handleGetFact)
 
 	var handleRemoveFact http.Handler
 	handleRemoveFact = s.handleRemoveFact()
 	handleRemoveFact = httpLoggingMiddleware(logger, "handleRemoveFact")(handleRemoveFact)
+	handleRemoveFact = httpTracingMiddleware("handleRemoveFact")(handleRemoveFact)
 
 	var handleUpdateFact http.Handler
 	handleUpdateFact = s.handleUpdateFact()
 	handleUpdateFact = httpLoggingMiddleware(logger, "handleUpdateFact")(handleUpdateFact)
+	handleUpdateFact = httpTracingMiddleware("handleUpdateFact")(handleUpdateFact)
+
+	var handlePatchFact http.Handler
+	handlePatchFact = s.handlePatchFact()
+	handlePatchFact = httpLoggingMiddleware(logger, "handlePatchFact")(handlePatchFact)
+	handlePatchFact = httpTracingMiddleware("handlePatchFact")(handlePatchFact)
+
+	var handleFactHistory http.Handler
+	handleFactHistory = s.handleFactHistory()
+	handleFactHistory = httpLoggingMiddleware(logger, "handleFactHistory")(handleFactHistory)
+	handleFactHistory = httpTracingMiddleware("handleFactHistory")(handleFactHistory)
+
+	var handleRevertFact http.Handler
+	handleRevertFact = s.handleRevertFact()
+	handleRevertFact = httpLoggingMiddleware(logger, "handleRevertFact")(handleRevertFact)
+	handleRevertFact = httpTracingMiddleware("handleRevertFact")(handleRevertFact)
+
+	var handleListTrash http.Handler
+	handleListTrash = s.handleListTrash()
+	handleListTrash = httpLoggingMiddleware(logger, "handleListTrash")(handleListTrash)
+	handleListTrash = httpTracingMiddleware("handleListTrash")(handleListTrash)
+
+	var handleRestoreFact http.Handler
+	handleRestoreFact = s.handleRestoreFact()
+	handleRestoreFact = httpLoggingMiddleware(logger, "handleRestoreFact")(handleRestoreFact)
+	handleRestoreFact = httpTracingMiddleware("handleRestoreFact")(handleRestoreFact)
+
+	var handleRandomFact http.Handler
+	handleRandomFact = s.handleRandomFact()
+	handleRandomFact = httpLoggingMiddleware(logger, "handleRandomFact")(handleRandomFact)
+	handleRandomFact = httpTracingMiddleware("handleRandomFact")(handleRandomFact)
+
+	var handleSubmitFact http.Handler
+	handleSubmitFact = s.handleSubmitFact()
+	handleSubmitFact = httpLoggingMiddleware(logger, "handleSubmitFact")(handleSubmitFact)
+	handleSubmitFact = httpTracingMiddleware("handleSubmitFact")(handleSubmitFact)
+
+	var handleReviewQueue http.Handler
+	handleReviewQueue = s.handleReviewQueue()
+	handleReviewQueue = httpLoggingMiddleware(logger, "handleReviewQueue")(handleReviewQueue)
+	handleReviewQueue = httpTracingMiddleware("handleReviewQueue")(handleReviewQueue)
+
+	var handleTransitionFact http.Handler
+	handleTransitionFact = s.handleTransitionFact()
+	handleTransitionFact = httpLoggingMiddleware(logger, "handleTransitionFact")(handleTransitionFact)
+	handleTransitionFact = httpTracingMiddleware("handleTransitionFact")(handleTransitionFact)
+
+	var handleSearchFact http.Handler
+	handleSearchFact = s.handleSearchFact()
+	handleSearchFact = httpLoggingMiddleware(logger, "handleSearchFact")(handleSearchFact)
+	handleSearchFact = httpTracingMiddleware("handleSearchFact")(handleSearchFact)
+
+	var handleFactDuplicates http.Handler
+	handleFactDuplicates = s.handleFactDuplicates()
+	handleFactDuplicates = httpLoggingMiddleware(logger, "handleFactDuplicates")(handleFactDuplicates)
+	handleFactDuplicates = httpTracingMiddleware("handleFactDuplicates")(handleFactDuplicates)
+
+	var handleDuplicateClusters http.Handler
+	handleDuplicateClusters = s.handleDuplicateClusters()
+	handleDuplicateClusters = httpLoggingMiddleware(logger, "handleDuplicateClusters")(handleDuplicateClusters)
+	handleDuplicateClusters = httpTracingMiddleware("handleDuplicateClusters")(handleDuplicateClusters)
+
+	var handleMergeFacts http.Handler
+	handleMergeFacts = s.handleMergeFacts()
+	handleMergeFacts = httpLoggingMiddleware(logger, "handleMergeFacts")(handleMergeFacts)
+	handleMergeFacts = httpTracingMiddleware("handleMergeFacts")(handleMergeFacts)
+
+	var handleSetTranslation http.Handler
+	handleSetTranslation = s.handleSetTranslation()
+	handleSetTranslation = httpLoggingMiddleware(logger, "handleSetTranslation")(handleSetTranslation)
+	handleSetTranslation = httpTracingMiddleware("handleSetTranslation")(handleSetTranslation)
+
+	var handleMissingTranslations http.Handler
+	handleMissingTranslations = s.handleMissingTranslations()
+	handleMissingTranslations = httpLoggingMiddleware(logger, "handleMissingTranslations")(handleMissingTranslations)
+	handleMissingTranslations = httpTracingMiddleware("handleMissingTranslations")(handleMissingTranslations)
+
+	var handleAttach http.Handler
+	handleAttach = s.handleAttach()
+	handleAttach = httpLoggingMiddleware(logger, "handleAttach")(handleAttach)
+	handleAttach = httpTracingMiddleware("handleAttach")(handleAttach)
+
+	var handleListAttachments http.Handler
+	handleListAttachments = s.handleListAttachments()
+	handleListAttachments = httpLoggingMiddleware(logger, "handleListAttachments")(handleListAttachments)
+	handleListAttachments = httpTracingMiddleware("handleListAttachments")(handleListAttachments)
+
+	var handleGetAttachment http.Handler
+	handleGetAttachment = s.handleGetAttachment()
+	handleGetAttachment = httpLoggingMiddleware(logger, "handleGetAttachment")(handleGetAttachment)
+	handleGetAttachment = httpTracingMiddleware("handleGetAttachment")(handleGetAttachment)
+
+	var handleDetach http.Handler
+	handleDetach = s.handleDetach()
+	handleDetach = httpLoggingMiddleware(logger, "handleDetach")(handleDetach)
+	handleDetach = httpTracingMiddleware("handleDetach")(handleDetach)
+
+	var handleBrokenSources http.Handler
+	handleBrokenSources = s.handleBrokenSources()
+	handleBrokenSources = httpLoggingMiddleware(logger, "handleBrokenSources")(handleBrokenSources)
+	handleBrokenSources = httpTracingMiddleware("handleBrokenSources")(handleBrokenSources)
+
+	var handleDueCards http.Handler
+	handleDueCards = s.handleDueCards()
+	handleDueCards = httpLoggingMiddleware(logger, "handleDueCards")(handleDueCards)
+	handleDueCards = httpTracingMiddleware("handleDueCards")(handleDueCards)
+
+	var handleReviewCard http.Handler
+	handleReviewCard = s.handleReviewCard()
+	handleReviewCard = httpLoggingMiddleware(logger, "handleReviewCard")(handleReviewCard)
+	handleReviewCard = httpTracingMiddleware("handleReviewCard")(handleReviewCard)
+
+	var handleStudyStats http.Handler
+	handleStudyStats = s.handleStudyStats()
+	handleStudyStats = httpLoggingMiddleware(logger, "handleStudyStats")(handleStudyStats)
+	handleStudyStats = httpTracingMiddleware("handleStudyStats")(handleStudyStats)
+
+	var handleAnswerFact http.Handler
+	handleAnswerFact = s.handleAnswerFact()
+	handleAnswerFact = httpLoggingMiddleware(logger, "handleAnswerFact")(handleAnswerFact)
+	handleAnswerFact = httpTracingMiddleware("handleAnswerFact")(handleAnswerFact)
+
+	var handleFactStats http.Handler
+	handleFactStats = s.handleFactStats()
+	handleFactStats = httpLoggingMiddleware(logger, "handleFactStats")(handleFactStats)
+	handleFactStats = httpTracingMiddleware("handleFactStats")(handleFactStats)
+
+	var handleHardestFacts http.Handler
+	handleHardestFacts = s.handleHardestFacts()
+	handleHardestFacts = httpLoggingMiddleware(logger, "handleHardestFacts")(handleHardestFacts)
+	handleHardestFacts = httpTracingMiddleware("handleHardestFacts")(handleHardestFacts)
+
+	var handleWrongAnswers http.Handler
+	handleWrongAnswers = s.handleWrongAnswers()
+	handleWrongAnswers = httpLoggingMiddleware(logger, "handleWrongAnswers")(handleWrongAnswers)
+	handleWrongAnswers = httpTracingMiddleware("handleWrongAnswers")(handleWrongAnswers)
+
+	var handleCreateCollection http.Handler
+	handleCreateCollection = s.handleCreateCollection()
+	handleCreateCollection = httpLoggingMiddleware(logger, "handleCreateCollection")(handleCreateCollection)
+	handleCreateCollection = httpTracingMiddleware("handleCreateCollection")(handleCreateCollection)
+
+	var handleListCollections http.Handler
+	handleListCollections = s.handleListCollections()
+	handleListCollections = httpLoggingMiddleware(logger, "handleListCollections")(handleListCollections)
+	handleListCollections = httpTracingMiddleware("handleListCollections")(handleListCollections)
+
+	var handleGetCollection http.Handler
+	handleGetCollection = s.handleGetCollection()
+	handleGetCollection = httpLoggingMiddleware(logger, "handleGetCollection")(handleGetCollection)
+	handleGetCollection = httpTracingMiddleware("handleGetCollection")(handleGetCollection)
+
+	var handleUpdateCollection http.Handler
+	handleUpdateCollection = s.handleUpdateCollection()
+	handleUpdateCollection = httpLoggingMiddleware(logger, "handleUpdateCollection")(handleUpdateCollection)
+	handleUpdateCollection = httpTracingMiddleware("handleUpdateCollection")(handleUpdateCollection)
+
+	var handleRemoveCollection http.Handler
+	handleRemoveCollection = s.handleRemoveCollection()
+	handleRemoveCollection = httpLoggingMiddleware(logger, "handleRemoveCollection")(handleRemoveCollection)
+	handleRemoveCollection = httpTracingMiddleware("handleRemoveCollection")(handleRemoveCollection)
+
+	var handleReorderCollection http.Handler
+	handleReorderCollection = s.handleReorderCollection()
+	handleReorderCollection = httpLoggingMiddleware(logger, "handleReorderCollection")(handleReorderCollection)
+	handleReorderCollection = httpTracingMiddleware("handleReorderCollection")(handleReorderCollection)
+
+	var handleShareCollection http.Handler
+	handleShareCollection = s.handleShareCollection()
+	handleShareCollection = httpLoggingMiddleware(logger, "handleShareCollection")(handleShareCollection)
+	handleShareCollection = httpTracingMiddleware("handleShareCollection")(handleShareCollection)
+
+	var handleUnshareCollection http.Handler
+	handleUnshareCollection = s.handleUnshareCollection()
+	handleUnshareCollection = httpLoggingMiddleware(logger, "handleUnshareCollection")(handleUnshareCollection)
+	handleUnshareCollection = httpTracingMiddleware("handleUnshareCollection")(handleUnshareCollection)
+
+	var handleCollectionSheet http.Handler
+	handleCollectionSheet = s.handleCollectionSheet()
+	handleCollectionSheet = httpLoggingMiddleware(logger, "handleCollectionSheet")(handleCollectionSheet)
+	handleCollectionSheet = httpTracingMiddleware("handleCollectionSheet")(handleCollectionSheet)
+
+	var handleSharedCollection http.Handler
+	handleSharedCollection = s.handleSharedCollection()
+	handleSharedCollection = httpLoggingMiddleware(logger, "handleSharedCollection")(handleSharedCollection)
+	handleSharedCollection = httpTracingMiddleware("handleSharedCollection")(handleSharedCollection)
+
+	var handleSharedCollectionSheet http.Handler
+	handleSharedCollectionSheet = s.handleSharedCollectionSheet()
+	handleSharedCollectionSheet = httpLoggingMiddleware(logger, "handleSharedCollectionSheet")(handleSharedCollectionSheet)
+	handleSharedCollectionSheet = httpTracingMiddleware("handleSharedCollectionSheet")(handleSharedCollectionSheet)
+
+	var handleQuizSheet http.Handler
+	handleQuizSheet = s.handleQuizSheet()
+	handleQuizSheet = httpLoggingMiddleware(logger, "handleQuizSheet")(handleQuizSheet)
+	handleQuizSheet = httpTracingMiddleware("handleQuizSheet")(handleQuizSheet)
+
+	var handleListSheetTemplates http.Handler
+	handleListSheetTemplates = s.handleListSheetTemplates()
+	handleListSheetTemplates = httpLoggingMiddleware(logger, "handleListSheetTemplates")(handleListSheetTemplates)
+	handleListSheetTemplates = httpTracingMiddleware("handleListSheetTemplates")(handleListSheetTemplates)
+
+	var handleGetSheetTemplate http.Handler
+	handleGetSheetTemplate = s.handleGetSheetTemplate()
+	handleGetSheetTemplate = httpLoggingMiddleware(logger, "handleGetSheetTemplate")(handleGetSheetTemplate)
+	handleGetSheetTemplate = httpTracingMiddleware("handleGetSheetTemplate")(handleGetSheetTemplate)
+
+	var handleSaveSheetTemplate http.Handler
+	handleSaveSheetTemplate = s.handleSaveSheetTemplate()
+	handleSaveSheetTemplate = httpLoggingMiddleware(logger, "handleSaveSheetTemplate")(handleSaveSheetTemplate)
+	handleSaveSheetTemplate = httpTracingMiddleware("handleSaveSheetTemplate")(handleSaveSheetTemplate)
+
+	var handleRemoveSheetTemplate http.Handler
+	handleRemoveSheetTemplate = s.handleRemoveSheetTemplate()
+	handleRemoveSheetTemplate = httpLoggingMiddleware(logger, "handleRemoveSheetTemplate")(handleRemoveSheetTemplate)
+	handleRemoveSheetTemplate = httpTracingMiddleware("handleRemoveSheetTemplate")(handleRemoveSheetTemplate)
+
+	var handleOpenAPI http.Handler
+	handleOpenAPI = s.handleOpenAPI()
+	handleOpenAPI = httpLoggingMiddleware(logger, "handleOpenAPI")(handleOpenAPI)
+	handleOpenAPI = httpTracingMiddleware("handleOpenAPI")(handleOpenAPI)
+
+	var handleDocs http.Handler
+	handleDocs = s.handleDocs()
+	handleDocs = httpLoggingMiddleware(logger, "handleDocs")(handleDocs)
+	handleDocs = httpTracingMiddleware("handleDocs")(handleDocs)
+
+	var handleDocsAsset http.Handler
+	handleDocsAsset = s.handleDocsAsset()
+	handleDocsAsset = httpLoggingMiddleware(logger, "handleDocsAsset")(handleDocsAsset)
+	handleDocsAsset = httpTracingMiddleware("handleDocsAsset")(handleDocsAsset)
 
 	router := way.NewRouter()
 
 	router.Handle("POST", "/factlist/v1/fact", handleSaveFact)
 	router.Handle("GET", "/factlist/v1/fact", handleListFact)
+	router.Handle("GET", "/factlist/v1/fact/random", handleRandomFact)
+	router.Handle("GET", "/factlist/v1/fact/search", handleSearchFact)
+	router.Handle("GET", "/factlist/v1/fact/:id", handleGetFact)
 	router.Handle("DELETE", "/factlist/v1/fact/:id", handleRemoveFact)
 	router.Handle("PUT", "factlist/v1/fact/:id", handleUpdateFact)
+	router.Handle("PATCH", "/factlist/v1/fact/:id", handlePatchFact)
+	router.Handle("GET", "/factlist/v1/fact/:id/history", handleFactHistory)
+	router.Handle("POST", "/factlist/v1/fact/:id/revert/:rev", handleRevertFact)
+	router.Handle("GET", "/factlist/v1/trash", handleListTrash)
+	router.Handle("POST", "/factlist/v1/trash/:id/restore", handleRestoreFact)
+	router.Handle("GET", "/factlist/v1/fact/:id/duplicates", handleFactDuplicates)
+	router.Handle("POST", "/factlist/v1/fact/:id/merge", handleMergeFacts)
+	router.Handle("GET", "/factlist/v1/duplicates", handleDuplicateClusters)
+	router.Handle("PUT", "/factlist/v1/fact/:id/translations/:lang", handleSetTranslation)
+	router.Handle("GET", "/factlist/v1/translations/:lang/missing", handleMissingTranslations)
+	router.Handle("GET", "/factlist/v1/sources/broken", handleBrokenSources)
+	if s.moderation != nil {
+		router.Handle("POST", "/factlist/v1/fact/:id/status", handleTransitionFact)
+		router.Handle("POST", "/factlist/v1/submission", handleSubmitFact)
+		router.Handle("GET", "/factlist/v1/review", handleReviewQueue)
+	}
+	if s.attachments != nil {
+		router.Handle("POST", "/factlist/v1/fact/:id/attachments", handleAttach)
+		router.Handle("GET", "/factlist/v1/fact/:id/attachments", handleListAttachments)
+		router.Handle("GET", "/factlist/v1/fact/:id/attachments/:attachment", handleGetAttachment)
+		router.Handle("DELETE", "/factlist/v1/fact/:id/attachments/:attachment", handleDetach)
+	}
+	if s.study != nil {
+		router.Handle("GET", "/factlist/v1/study/due", handleDueCards)
+		router.Handle("POST", "/factlist/v1/study/cards/:id/reviews", handleReviewCard)
+		router.Handle("GET", "/factlist/v1/study/stats", handleStudyStats)
+	}
+	if s.analytics != nil {
+		router.Handle("POST", "/factlist/v1/fact/:id/answers", handleAnswerFact)
+		router.Handle("GET", "/factlist/v1/fact/:id/stats", handleFactStats)
+		router.Handle("GET", "/factlist/v1/reports/hardest", handleHardestFacts)
+		router.Handle("GET", "/factlist/v1/reports/wrong-answers", handleWrongAnswers)
+	}
+	if s.collections != nil {
+		router.Handle("POST", "/factlist/v1/collections", handleCreateCollection)
+		router.Handle("GET", "/factlist/v1/collections", handleListCollections)
+		router.Handle("GET", "/factlist/v1/collections/:id", handleGetCollection)
+		router.Handle("PUT", "/factlist/v1/collections/:id", handleUpdateCollection)
+		router.Handle("DELETE", "/factlist/v1/collections/:id", handleRemoveCollection)
+		router.Handle("PUT", "/factlist/v1/collections/:id/order", handleReorderCollection)
+		router.Handle("PUT", "/factlist/v1/collections/:id/share", handleShareCollection)
+		router.Handle("DELETE", "/factlist/v1/collections/:id/share", handleUnshareCollection)
+		router.Handle("GET", "/factlist/v1/collections/:id/sheet", handleCollectionSheet)
+		router.Handle("GET", "/factlist/v1/shared/:slug", handleSharedCollection)
+		router.Handle("GET", "/factlist/v1/shared/:slug/sheet", handleSharedCollectionSheet)
+	}
+	if s.sheets != nil {
+		router.Handle("GET", "/factlist/v1/sheet", handleQuizSheet)
+		router.Handle("GET", "/factlist/v1/sheet/templates", handleListSheetTemplates)
+		router.Handle("GET", "/factlist/v1/sheet/templates/:name", handleGetSheetTemplate)
+		router.Handle("PUT", "/factlist/v1/sheet/templates/:name", handleSaveSheetTemplate)
+		router.Handle("DELETE", "/factlist/v1/sheet/templates/:name", handleRemoveSheetTemplate)
+	}
+	router.Handle("GET", "/factlist/v1/openapi.json", handleOpenAPI)
+	router.Handle("GET", "/factlist/v1/docs", handleDocs)
+	router.Handle("GET", "/factlist/v1/docs/:asset", handleDocsAsset)
 
 	router.NotFound = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { writeError(w, ErrResourceNotFound) })
 
@@ -45,14 +363,41 @@ func NewServer(service Service, logger log.Logger) http.Handler {
 }
 
 const (
-	contentTypeKey   = "Content-Type"
-	contentTypeValue = "application/json; charset=utf-8"
+	contentTypeKey     = "Content-Type"
+	contentTypeValue   = "application/json; charset=utf-8"
+	etagKey            = "ETag"
+	ifMatchKey         = "If-Match"
+	ifNoneMatchKey     = "If-None-Match"
+	acceptPatchKey     = "Accept-Patch"
+	varyKey            = "Vary"
+	acceptLanguageKey  = "Accept-Language"
+	contentLanguageKey = "Content-Language"
+	cacheControlKey    = "Cache-Control"
+	cspKey             = "Content-Security-Policy"
+	locationKey        = "Location"
+	attachmentField    = "file"
+	mergePatchType     = "application/merge-patch+json"
+	authenticateKey    = "WWW-Authenticate"
+	authenticateValue  = `Bearer realm="trivia"`
+	maxPatchSize       = 1 << 20
+	defaultLimit       = 20
+	maxLimit           = 100
 )
 
 var (
 	ErrNonNumericFactID = errors.New("fact id must be numeric")
+	ErrNonNumericRev    = errors.New("revision must be numeric")
+	ErrInvalidIfMatch   = errors.New("if-match must be a single entity tag or *")
+	ErrUnsupportedPatch = errors.New("unsupported patch document type")
+	ErrPatchTooLarge    = errors.New("patch document is too large")
 	ErrResourceNotFound = errors.New("resource not found")
 	ErrMethodNotAllowed = errors.New("method not allowed")
+	ErrMissingQuery     = errors.New("search query must not be blank")
+	ErrInvalidLimit     = errors.New("limit must be a number between 1 and 100")
+	ErrInvalidMin       = errors.New("min must be a non-negative number")
+	ErrNonNumericID     = errors.New("collection id must be numeric")
+
+	ErrTemplateFormatMismatch = errors.New("format must match the format of the template")
 )
 
 type ErrInvalidRequestBody struct{ err error }
@@ -60,19 +405,72 @@ type ErrInvalidRequestBody struct{ err error }
 func (e ErrInvalidRequestBody) Error() string { return fmt.Sprintf("invalid request body: %v", e.err) }
 
 type server struct {
-	service Service
+	service     Service
+	moderation  ModerationService
+	attachments AttachmentService
+	study       StudyService
+	analytics   AnalyticsService
+	collections CollectionService
+	sheets      SheetService
+}
+
+// ServerOption configures the http.Handler returned by NewServer
+type ServerOption func(*server)
+
+// WithModerationService serves the submission and review routes from moderation
+func WithModerationService(moderation ModerationService) ServerOption {
+	return func(s *server) { s.moderation = moderation }
+}
+
+// WithAttachmentService serves the attachment routes from attachments
+func WithAttachmentService(attachments AttachmentService) ServerOption {
+	return func(s *server) { s.attachments = attachments }
+}
+
+// WithStudyService serves the study routes from study
+func WithStudyService(study StudyService) ServerOption {
+	return func(s *server) { s.study = study }
+}
+
+// WithAnalyticsService serves the answer and report routes from analytics
+func WithAnalyticsService(analytics AnalyticsService) ServerOption {
+	return func(s *server) { s.analytics = analytics }
+}
+
+// WithCollectionService serves the collection routes from collections
+func WithCollectionService(collections CollectionService) ServerOption {
+	return func(s *server) { s.collections = collections }
+}
+
+// WithSheetService serves the quiz sheet and sheet template routes from
+// sheets, which also provides the templates collection sheets may use
+func WithSheetService(sheets SheetService) ServerOption {
+	return func(s *server) { s.sheets = sheets }
 }
 
 func (s *server) handleSaveFact() http.HandlerFunc {
 	type request struct {
-		Question string `json:"question"`
-		Answer   string `json:"answer"`
+		Question   string         `json:"question"`
+		Answer     string         `json:"answer"`
+		Language   string         `json:"language"`
+		Sources    []source       `json:"sources"`
+		Category   string         `json:"category"`
+		Difficulty pkg.Difficulty `json:"difficulty"`
+		PublishAt  *time.Time     `json:"publishAt"`
+		ExpireAt   *time.Time     `json:"expireAt"`
 	}
 	type response struct {
-		ID        int64     `json:"id"`
-		Question  string    `json:"question"`
-		Answer    string    `json:"answer"`
-		CreatedAt time.Time `json:"createdAt"`
+		ID         int64          `json:"id"`
+		Question   string         `json:"question"`
+		Answer     string         `json:"answer"`
+		CreatedAt  time.Time      `json:"createdAt"`
+		Sources    []source       `json:"sources,omitempty"`
+		Category   string         `json:"category,omitempty"`
+		Difficulty pkg.Difficulty `json:"difficulty,omitempty"`
+		PublishAt  *time.Time     `json:"publishAt,omitempty"`
+		ExpireAt   *time.Time     `json:"expireAt,omitempty"`
+		// PossibleDuplicates warns about stored facts asking the same question
+		PossibleDuplicates []int64 `json:"possibleDuplicates,omitempty"`
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -82,40 +480,167 @@ func (s *server) handleSaveFact() http.HandlerFunc {
 			return
 		}
 
-		fact, err := s.service.Save(r.Context(), pkg.Fact{Question: req.Question, Answer: req.Answer})
+		fact, err := s.service.Save(r.Context(), pkg.Fact{
+			Question:   req.Question,
+			Answer:     req.Answer,
+			Language:   req.Language,
+			Sources:    toSources(req.Sources),
+			Category:   req.Category,
+			Difficulty: req.Difficulty,
+			PublishAt:  timeValue(req.PublishAt),
+			ExpireAt:   timeValue(req.ExpireAt),
+		})
 		if err != nil {
 			writeError(w, err)
 			return
 		}
 		w.Header().Set(contentTypeKey, contentTypeValue)
-		json.NewEncoder(w).Encode(response{ID: fact.ID, Question: fact.Question, Answer: fact.Answer, CreatedAt: fact.CreatedAt})
+		w.Header().Set(etagKey, etag(fact.Version))
+		json.NewEncoder(w).Encode(response{
+			ID:                 fact.ID,
+			Question:           fact.Question,
+			Answer:             fact.Answer,
+			CreatedAt:          fact.CreatedAt,
+			Sources:            newSources(fact.Sources),
+			Category:           fact.Category,
+			Difficulty:         fact.Difficulty,
+			PublishAt:          timePtr(fact.PublishAt),
+			ExpireAt:           timePtr(fact.ExpireAt),
+			PossibleDuplicates: s.possibleDuplicates(r.Context(), fact.ID),
+		})
 	}
 }
 
 func (s *server) handleListFact() http.HandlerFunc {
 	type fact struct {
-		ID        int64     `json:"id"`
-		Question  string    `json:"question"`
-		Answer    string    `json:"answer"`
-		CreatedAt time.Time `json:"createdAt"`
+		ID         int64          `json:"id"`
+		Question   string         `json:"question"`
+		Answer     string         `json:"answer"`
+		CreatedAt  time.Time      `json:"createdAt"`
+		Sources    []source       `json:"sources,omitempty"`
+		Category   string         `json:"category,omitempty"`
+		Difficulty pkg.Difficulty `json:"difficulty,omitempty"`
+		PublishAt  *time.Time     `json:"publishAt,omitempty"`
+		ExpireAt   *time.Time     `json:"expireAt,omitempty"`
 	}
 	type response []fact
 	return func(w http.ResponseWriter, r *http.Request) {
-		list, err := s.service.List(r.Context())
+		chain, err := languageChain(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		var list []pkg.Fact
+		switch filter := r.URL.Query().Get("status"); filter {
+		case "":
+			list, err = s.service.List(r.Context())
+		case "all":
+			list, err = s.service.ListByStatus(r.Context())
+		default:
+			var statuses []pkg.Status
+			for _, status := range strings.Split(filter, ",") {
+				statuses = append(statuses, pkg.Status(strings.TrimSpace(status)))
+			}
+			list, err = s.service.ListByStatus(r.Context(), statuses...)
+		}
 		if err != nil {
 			writeError(w, err)
 			return
 		}
 
+		tag := listETag(list, chain)
+		w.Header().Set(etagKey, tag)
+		w.Header().Add(varyKey, acceptLanguageKey)
+		if noneMatch(r.Header.Get(ifNoneMatchKey), tag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
 		resp := make(response, 0, len(list))
+		languages := make(map[string]bool)
 		for _, v := range list {
-			resp = append(resp, fact{ID: v.ID, Question: v.Question, Answer: v.Answer, CreatedAt: v.CreatedAt})
+			t, lang := v.Localize(chain)
+			languages[lang] = true
+			resp = append(resp, fact{
+				ID:         v.ID,
+				Question:   t.Question,
+				Answer:     t.Answer,
+				CreatedAt:  v.CreatedAt,
+				Sources:    newSources(v.Sources),
+				Category:   v.Category,
+				Difficulty: v.Difficulty,
+				PublishAt:  timePtr(v.PublishAt),
+				ExpireAt:   timePtr(v.ExpireAt),
+			})
 		}
 		w.Header().Set(contentTypeKey, contentTypeValue)
+		if len(languages) == 1 {
+			for lang := range languages {
+				w.Header().Set(contentLanguageKey, lang)
+			}
+		}
 		json.NewEncoder(w).Encode(resp)
 	}
 }
 
+func (s *server) handleGetFact() http.HandlerFunc {
+	type response struct {
+		ID         int64          `json:"id"`
+		Question   string         `json:"question"`
+		Answer     string         `json:"answer"`
+		CreatedAt  time.Time      `json:"createdAt"`
+		Sources    []source       `json:"sources,omitempty"`
+		Category   string         `json:"category,omitempty"`
+		Difficulty pkg.Difficulty `json:"difficulty,omitempty"`
+		PublishAt  *time.Time     `json:"publishAt,omitempty"`
+		ExpireAt   *time.Time     `json:"expireAt,omitempty"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(way.Param(r.Context(), "id"), 10, 64)
+		if err != nil {
+			writeError(w, ErrNonNumericFactID)
+			return
+		}
+
+		chain, err := languageChain(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		fact, err := s.service.Get(r.Context(), id)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		tag := etag(fact.Version)
+		w.Header().Set(etagKey, tag)
+		w.Header().Add(varyKey, acceptLanguageKey)
+		if noneMatch(r.Header.Get(ifNoneMatchKey), tag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		t, lang := fact.Localize(chain)
+		w.Header().Set(contentTypeKey, contentTypeValue)
+		w.Header().Set(contentLanguageKey, lang)
+		json.NewEncoder(w).Encode(response{
+			ID:         fact.ID,
+			Question:   t.Question,
+			Answer:     t.Answer,
+			CreatedAt:  fact.CreatedAt,
+			Sources:    newSources(fact.Sources),
+			Category:   fact.Category,
+			Difficulty: fact.Difficulty,
+			PublishAt:  timePtr(fact.PublishAt),
+			ExpireAt:   timePtr(fact.ExpireAt),
+		})
+	}
+}
+
 func (s *server) handleRemoveFact() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		id, err := strconv.ParseInt(way.Param(r.Context(), "id"), 10, 64)
@@ -124,7 +649,13 @@ func (s *server) handleRemoveFact() http.HandlerFunc {
 			return
 		}
 
-		if err := s.service.Remove(r.Context(), id); err != nil {
+		version, err := ifMatch(r.Header.Get(ifMatchKey))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		if err := s.service.Remove(r.Context(), id, version); err != nil {
 			writeError(w, err)
 			return
 		}
@@ -134,15 +665,25 @@ func (s *server) handleRemoveFact() http.HandlerFunc {
 
 func (s *server) handleUpdateFact() http.HandlerFunc {
 	type request struct {
-		Question  string    `json:"Question"`
-		Answer    string    `json:"answer"`
-		CreatedAt time.Time `json:"createdAt"`
+		Question   string         `json:"question"`
+		Answer     string         `json:"answer"`
+		CreatedAt  time.Time      `json:"createdAt"`
+		Sources    []source       `json:"sources"`
+		Category   string         `json:"category"`
+		Difficulty pkg.Difficulty `json:"difficulty"`
+		PublishAt  *time.Time     `json:"publishAt"`
+		ExpireAt   *time.Time     `json:"expireAt"`
 	}
 	type response struct {
-		ID        int64     `json:"id"`
-		Question  string    `json:"question"`
-		Answer    string    `json:"answer"`
-		CreatedAt time.Time `json:"createdAt"`
+		ID         int64          `json:"id"`
+		Question   string         `json:"question"`
+		Answer     string         `json:"answer"`
+		CreatedAt  time.Time      `json:"createdAt"`
+		Sources    []source       `json:"sources,omitempty"`
+		Category   string         `json:"category,omitempty"`
+		Difficulty pkg.Difficulty `json:"difficulty,omitempty"`
+		PublishAt  *time.Time     `json:"publishAt,omitempty"`
+		ExpireAt   *time.Time     `json:"expireAt,omitempty"`
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -152,72 +693,1969 @@ func (s *server) handleUpdateFact() http.HandlerFunc {
 			return
 		}
 
+		version, err := ifMatch(r.Header.Get(ifMatchKey))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
 		var req request
 		if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
 			writeError(w, ErrInvalidRequestBody{err: err})
 			return
 		}
 
-		fact, isCreated, err := s.service.Update(r.Context(), pkg.Fact{ID: id, Question: req.Question, Answer: req.Answer, CreatedAt: req.CreatedAt})
+		replacement := pkg.Fact{
+			ID:         id,
+			Question:   req.Question,
+			Answer:     req.Answer,
+			CreatedAt:  req.CreatedAt,
+			Sources:    toSources(req.Sources),
+			Category:   req.Category,
+			Difficulty: req.Difficulty,
+			PublishAt:  timeValue(req.PublishAt),
+			ExpireAt:   timeValue(req.ExpireAt),
+			Version:    version,
+		}
+
+		var (
+			fact      *pkg.Fact
+			isCreated bool
+		)
+		if strings.TrimSpace(r.Header.Get(ifMatchKey)) == "*" {
+			// * only matches a current representation, so it mustn't create the fact
+			fact, err = s.service.Replace(r.Context(), replacement)
+			if err == pkg.ErrFactNotFound {
+				err = pkg.ErrVersionConflict
+			}
+		} else {
+			fact, isCreated, err = s.service.Update(r.Context(), replacement)
+		}
 		if err != nil {
 			writeError(w, err)
 			return
 		}
 
+		w.Header().Set(contentTypeKey, contentTypeValue)
+		w.Header().Set(etagKey, etag(fact.Version))
 		if isCreated {
 			w.WriteHeader(http.StatusCreated)
 		}
 
-		w.Header().Set(contentTypeKey, contentTypeValue)
-		json.NewEncoder(w).Encode(response{ID: fact.ID, Question: fact.Question, Answer: fact.Answer, CreatedAt: fact.CreatedAt})
+		json.NewEncoder(w).Encode(response{
+			ID:         fact.ID,
+			Question:   fact.Question,
+			Answer:     fact.Answer,
+			CreatedAt:  fact.CreatedAt,
+			Sources:    newSources(fact.Sources),
+			Category:   fact.Category,
+			Difficulty: fact.Difficulty,
+			PublishAt:  timePtr(fact.PublishAt),
+			ExpireAt:   timePtr(fact.ExpireAt),
+		})
 	}
 }
 
-func writeError(w http.ResponseWriter, err error) {
-	switch err {
-	case ErrResourceNotFound, pkg.ErrFactNotFound:
-		w.WriteHeader(http.StatusNotFound)
-	case pkg.ErrFactAlreadyExists:
-		w.WriteHeader(http.StatusConflict)
-	case ErrNonNumericFactID:
-		w.WriteHeader(http.StatusBadRequest)
-	case ErrMethodNotAllowed:
-		w.WriteHeader(http.StatusMethodNotAllowed)
-	default:
-		switch err.(type) {
-		case ErrInvalidRequestBody:
-			w.WriteHeader(http.StatusBadRequest)
-		default:
-			w.WriteHeader(http.StatusInternalServerError)
-		}
+func (s *server) handlePatchFact() http.HandlerFunc {
+	type response struct {
+		ID         int64          `json:"id"`
+		Question   string         `json:"question"`
+		Answer     string         `json:"answer"`
+		CreatedAt  time.Time      `json:"createdAt"`
+		Sources    []source       `json:"sources,omitempty"`
+		Category   string         `json:"category,omitempty"`
+		Difficulty pkg.Difficulty `json:"difficulty,omitempty"`
+		PublishAt  *time.Time     `json:"publishAt,omitempty"`
+		ExpireAt   *time.Time     `json:"expireAt,omitempty"`
 	}
 
-	json.NewEncoder(w).Encode(map[string]interface{}{"error": err.Error()})
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(way.Param(r.Context(), "id"), 10, 64)
+		if err != nil {
+			writeError(w, ErrNonNumericFactID)
+			return
+		}
+
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get(contentTypeKey))
+		if mediaType != mergePatchType && mediaType != "application/json" {
+			w.Header().Set(acceptPatchKey, mergePatchType)
+			writeError(w, ErrUnsupportedPatch)
+			return
+		}
+
+		version, err := ifMatch(r.Header.Get(ifMatchKey))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		patch, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPatchSize))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				err = ErrPatchTooLarge
+			} else {
+				err = ErrInvalidRequestBody{err}
+			}
+			writeError(w, err)
+			return
+		}
+		if !json.Valid(patch) {
+			writeError(w, ErrInvalidRequestBody{errors.New("malformed merge patch")})
+			return
+		}
+
+		fact, err := s.service.Patch(r.Context(), id, version, patch)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		w.Header().Set(contentTypeKey, contentTypeValue)
+		w.Header().Set(etagKey, etag(fact.Version))
+		json.NewEncoder(w).Encode(response{
+			ID:         fact.ID,
+			Question:   fact.Question,
+			Answer:     fact.Answer,
+			CreatedAt:  fact.CreatedAt,
+			Sources:    newSources(fact.Sources),
+			Category:   fact.Category,
+			Difficulty: fact.Difficulty,
+			PublishAt:  timePtr(fact.PublishAt),
+			ExpireAt:   timePtr(fact.ExpireAt),
+		})
+	}
 }
 
-type loggingResponseWriter struct {
-	http.ResponseWriter
-	statusCode int
+func (s *server) handleFactHistory() http.HandlerFunc {
+	type change struct {
+		Field string `json:"field"`
+		From  string `json:"from"`
+		To    string `json:"to"`
+	}
+	type revision struct {
+		Revision  int       `json:"revision"`
+		Action    string    `json:"action"`
+		Author    string    `json:"author"`
+		Timestamp time.Time `json:"timestamp"`
+		Changes   []change  `json:"changes"`
+		Question  string    `json:"question"`
+		Answer    string    `json:"answer"`
+	}
+	type response []revision
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(way.Param(r.Context(), "id"), 10, 64)
+		if err != nil {
+			writeError(w, ErrNonNumericFactID)
+			return
+		}
+
+		revisions, err := s.service.History(r.Context(), id)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		resp := make(response, 0, len(revisions))
+		for _, v := range revisions {
+			changes := make([]change, 0, len(v.Changes))
+			for _, c := range v.Changes {
+				changes = append(changes, change{Field: c.Field, From: c.From, To: c.To})
+			}
+			resp = append(resp, revision{
+				Revision:  v.Number,
+				Action:    v.Action,
+				Author:    v.Author,
+				Timestamp: v.Timestamp,
+				Changes:   changes,
+				Question:  v.Fact.Question,
+				Answer:    v.Fact.Answer,
+			})
+		}
+		w.Header().Set(contentTypeKey, contentTypeValue)
+		json.NewEncoder(w).Encode(resp)
+	}
 }
 
-func (lrw *loggingResponseWriter) WriteHeader(code int) {
-	lrw.statusCode = code
-	lrw.ResponseWriter.WriteHeader(code)
+func (s *server) handleRevertFact() http.HandlerFunc {
+	type response struct {
+		ID        int64     `json:"id"`
+		Question  string    `json:"question"`
+		Answer    string    `json:"answer"`
+		CreatedAt time.Time `json:"createdAt"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(way.Param(r.Context(), "id"), 10, 64)
+		if err != nil {
+			writeError(w, ErrNonNumericFactID)
+			return
+		}
+
+		rev, err := strconv.Atoi(way.Param(r.Context(), "rev"))
+		if err != nil {
+			writeError(w, ErrNonNumericRev)
+			return
+		}
+
+		version, err := ifMatch(r.Header.Get(ifMatchKey))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		fact, err := s.service.Revert(r.Context(), id, rev, version)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		w.Header().Set(contentTypeKey, contentTypeValue)
+		w.Header().Set(etagKey, etag(fact.Version))
+		json.NewEncoder(w).Encode(response{ID: fact.ID, Question: fact.Question, Answer: fact.Answer, CreatedAt: fact.CreatedAt})
+	}
 }
 
-func httpLoggingMiddleware(logger log.Logger, operation string) func(http.Handler) http.Handler {
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			begin := time.Now()
-			lrw := &loggingResponseWriter{w, http.StatusOK}
-			next.ServeHTTP(lrw, r)
-			logger.Log(
-				"operation", operation,
-				"method", r.Method,
-				"path", r.URL.Path,
-				"took", time.Since(begin),
+func (s *server) handleListTrash() http.HandlerFunc {
+	type fact struct {
+		ID        int64     `json:"id"`
+		Question  string    `json:"question"`
+		Answer    string    `json:"answer"`
+		CreatedAt time.Time `json:"createdAt"`
+		DeletedAt time.Time `json:"deletedAt"`
+	}
+	type response []fact
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		list, err := s.service.Trash(r.Context())
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		resp := make(response, 0, len(list))
+		for _, v := range list {
+			resp = append(resp, fact{ID: v.ID, Question: v.Question, Answer: v.Answer, CreatedAt: v.CreatedAt, DeletedAt: v.DeletedAt})
+		}
+		w.Header().Set(contentTypeKey, contentTypeValue)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+func (s *server) handleRestoreFact() http.HandlerFunc {
+	type response struct {
+		ID        int64     `json:"id"`
+		Question  string    `json:"question"`
+		Answer    string    `json:"answer"`
+		CreatedAt time.Time `json:"createdAt"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(way.Param(r.Context(), "id"), 10, 64)
+		if err != nil {
+			writeError(w, ErrNonNumericFactID)
+			return
+		}
+
+		version, err := ifMatch(r.Header.Get(ifMatchKey))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		fact, err := s.service.Restore(r.Context(), id, version)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		w.Header().Set(contentTypeKey, contentTypeValue)
+		w.Header().Set(etagKey, etag(fact.Version))
+		json.NewEncoder(w).Encode(response{ID: fact.ID, Question: fact.Question, Answer: fact.Answer, CreatedAt: fact.CreatedAt})
+	}
+}
+
+func (s *server) handleRandomFact() http.HandlerFunc {
+	type response struct {
+		ID        int64     `json:"id"`
+		Question  string    `json:"question"`
+		Answer    string    `json:"answer"`
+		CreatedAt time.Time `json:"createdAt"`
+		Sources   []source  `json:"sources,omitempty"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		chain, err := languageChain(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		fact, err := s.service.Random(r.Context())
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		t, lang := fact.Localize(chain)
+		w.Header().Set(contentTypeKey, contentTypeValue)
+		w.Header().Set(contentLanguageKey, lang)
+		w.Header().Set(etagKey, etag(fact.Version))
+		w.Header().Set(cacheControlKey, "no-store")
+		json.NewEncoder(w).Encode(response{ID: fact.ID, Question: t.Question, Answer: t.Answer, CreatedAt: fact.CreatedAt, Sources: newSources(fact.Sources)})
+	}
+}
+
+func (s *server) handleSubmitFact() http.HandlerFunc {
+	type request struct {
+		Question   string         `json:"question"`
+		Answer     string         `json:"answer"`
+		Sources    []source       `json:"sources"`
+		Category   string         `json:"category"`
+		Difficulty pkg.Difficulty `json:"difficulty"`
+		PublishAt  *time.Time     `json:"publishAt"`
+		ExpireAt   *time.Time     `json:"expireAt"`
+	}
+	type response struct {
+		ID                 int64          `json:"id"`
+		Question           string         `json:"question"`
+		Answer             string         `json:"answer"`
+		Status             pkg.Status     `json:"status"`
+		CreatedAt          time.Time      `json:"createdAt"`
+		Sources            []source       `json:"sources,omitempty"`
+		Category           string         `json:"category,omitempty"`
+		Difficulty         pkg.Difficulty `json:"difficulty,omitempty"`
+		PublishAt          *time.Time     `json:"publishAt,omitempty"`
+		ExpireAt           *time.Time     `json:"expireAt,omitempty"`
+		PossibleDuplicates []int64        `json:"possibleDuplicates,omitempty"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, ErrInvalidRequestBody{err})
+			return
+		}
+
+		fact, err := s.moderation.Submit(r.Context(), pkg.Fact{
+			Question:   req.Question,
+			Answer:     req.Answer,
+			Sources:    toSources(req.Sources),
+			Category:   req.Category,
+			Difficulty: req.Difficulty,
+			PublishAt:  timeValue(req.PublishAt),
+			ExpireAt:   timeValue(req.ExpireAt),
+		})
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		w.Header().Set(contentTypeKey, contentTypeValue)
+		w.Header().Set(etagKey, etag(fact.Version))
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(response{
+			ID:                 fact.ID,
+			Question:           fact.Question,
+			Answer:             fact.Answer,
+			Status:             fact.Status,
+			CreatedAt:          fact.CreatedAt,
+			Sources:            newSources(fact.Sources),
+			Category:           fact.Category,
+			Difficulty:         fact.Difficulty,
+			PublishAt:          timePtr(fact.PublishAt),
+			ExpireAt:           timePtr(fact.ExpireAt),
+			PossibleDuplicates: s.possibleDuplicates(r.Context(), fact.ID),
+		})
+	}
+}
+
+type reviewComment struct {
+	Author    string     `json:"author"`
+	Comment   string     `json:"comment"`
+	From      pkg.Status `json:"from"`
+	To        pkg.Status `json:"to"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+func reviewComments(comments []pkg.ReviewComment) []reviewComment {
+	resp := make([]reviewComment, 0, len(comments))
+	for _, c := range comments {
+		resp = append(resp, reviewComment{Author: c.Author, Comment: c.Body, From: c.From, To: c.To, CreatedAt: c.CreatedAt})
+	}
+	return resp
+}
+
+func (s *server) handleReviewQueue() http.HandlerFunc {
+	type fact struct {
+		ID        int64           `json:"id"`
+		Question  string          `json:"question"`
+		Answer    string          `json:"answer"`
+		Status    pkg.Status      `json:"status"`
+		CreatedAt time.Time       `json:"createdAt"`
+		Sources   []source        `json:"sources,omitempty"`
+		PublishAt *time.Time      `json:"publishAt,omitempty"`
+		ExpireAt  *time.Time      `json:"expireAt,omitempty"`
+		Comments  []reviewComment `json:"comments"`
+	}
+	type response []fact
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		list, err := s.moderation.ReviewQueue(r.Context())
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		resp := make(response, 0, len(list))
+		for _, v := range list {
+			resp = append(resp, fact{
+				ID:        v.ID,
+				Question:  v.Question,
+				Answer:    v.Answer,
+				Status:    v.Status,
+				CreatedAt: v.CreatedAt,
+				Sources:   newSources(v.Sources),
+				PublishAt: timePtr(v.PublishAt),
+				ExpireAt:  timePtr(v.ExpireAt),
+				Comments:  reviewComments(v.Comments),
+			})
+		}
+		w.Header().Set(contentTypeKey, contentTypeValue)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+func (s *server) handleTransitionFact() http.HandlerFunc {
+	type request struct {
+		Status  pkg.Status `json:"status"`
+		Comment string     `json:"comment"`
+	}
+	type response struct {
+		ID        int64           `json:"id"`
+		Question  string          `json:"question"`
+		Answer    string          `json:"answer"`
+		Status    pkg.Status      `json:"status"`
+		CreatedAt time.Time       `json:"createdAt"`
+		Comments  []reviewComment `json:"comments"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(way.Param(r.Context(), "id"), 10, 64)
+		if err != nil {
+			writeError(w, ErrNonNumericFactID)
+			return
+		}
+
+		version, err := ifMatch(r.Header.Get(ifMatchKey))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		var req request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, ErrInvalidRequestBody{err})
+			return
+		}
+
+		fact, err := s.moderation.Transition(r.Context(), id, req.Status, req.Comment, version)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		w.Header().Set(contentTypeKey, contentTypeValue)
+		w.Header().Set(etagKey, etag(fact.Version))
+		json.NewEncoder(w).Encode(response{
+			ID:        fact.ID,
+			Question:  fact.Question,
+			Answer:    fact.Answer,
+			Status:    fact.Status,
+			CreatedAt: fact.CreatedAt,
+			Comments:  reviewComments(fact.Comments),
+		})
+	}
+}
+
+func (s *server) handleSearchFact() http.HandlerFunc {
+	type result struct {
+		ID          int64     `json:"id"`
+		Question    string    `json:"question"`
+		Answer      string    `json:"answer"`
+		CreatedAt   time.Time `json:"createdAt"`
+		Score       float64   `json:"score"`
+		Highlighted struct {
+			Question string `json:"question"`
+			Answer   string `json:"answer"`
+		} `json:"highlighted"`
+	}
+	type response []result
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := strings.TrimSpace(r.URL.Query().Get("q"))
+		if query == "" {
+			writeError(w, ErrMissingQuery)
+			return
+		}
+
+		limit, err := queryLimit(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		results, err := s.service.Search(r.Context(), query, limit)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		resp := make(response, 0, len(results))
+		for _, v := range results {
+			res := result{ID: v.Fact.ID, Question: v.Fact.Question, Answer: v.Fact.Answer, CreatedAt: v.Fact.CreatedAt, Score: v.Score}
+			res.Highlighted.Question, res.Highlighted.Answer = v.Question, v.Answer
+			resp = append(resp, res)
+		}
+		w.Header().Set(contentTypeKey, contentTypeValue)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+// possibleDuplicates returns the IDs of the facts suspected of asking the same
+// question as the fact with the given ID. It is only a warning, so it returns
+// nothing if duplicates can't be looked up.
+func (s *server) possibleDuplicates(ctx context.Context, id int64) []int64 {
+	duplicates, err := s.service.Duplicates(ctx, id)
+	if err != nil {
+		return nil
+	}
+
+	ids := make([]int64, 0, len(duplicates))
+	for _, d := range duplicates {
+		ids = append(ids, d.Fact.ID)
+	}
+	return ids
+}
+
+func (s *server) handleFactDuplicates() http.HandlerFunc {
+	type duplicate struct {
+		ID         int64     `json:"id"`
+		Question   string    `json:"question"`
+		Answer     string    `json:"answer"`
+		CreatedAt  time.Time `json:"createdAt"`
+		Similarity float64   `json:"similarity"`
+	}
+	type response []duplicate
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(way.Param(r.Context(), "id"), 10, 64)
+		if err != nil {
+			writeError(w, ErrNonNumericFactID)
+			return
+		}
+
+		duplicates, err := s.service.Duplicates(r.Context(), id)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		resp := make(response, 0, len(duplicates))
+		for _, v := range duplicates {
+			resp = append(resp, duplicate{
+				ID:         v.Fact.ID,
+				Question:   v.Fact.Question,
+				Answer:     v.Fact.Answer,
+				CreatedAt:  v.Fact.CreatedAt,
+				Similarity: v.Similarity,
+			})
+		}
+		w.Header().Set(contentTypeKey, contentTypeValue)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+func (s *server) handleDuplicateClusters() http.HandlerFunc {
+	type fact struct {
+		ID        int64     `json:"id"`
+		Question  string    `json:"question"`
+		Answer    string    `json:"answer"`
+		CreatedAt time.Time `json:"createdAt"`
+	}
+	type cluster struct {
+		Similarity float64 `json:"similarity"`
+		Facts      []fact  `json:"facts"`
+	}
+	type response []cluster
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		clusters, err := s.service.DuplicateClusters(r.Context())
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		resp := make(response, 0, len(clusters))
+		for _, c := range clusters {
+			facts := make([]fact, 0, len(c.Facts))
+			for _, v := range c.Facts {
+				facts = append(facts, fact{ID: v.ID, Question: v.Question, Answer: v.Answer, CreatedAt: v.CreatedAt})
+			}
+			resp = append(resp, cluster{Similarity: c.Similarity, Facts: facts})
+		}
+		w.Header().Set(contentTypeKey, contentTypeValue)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+func (s *server) handleMergeFacts() http.HandlerFunc {
+	type request struct {
+		Duplicates []int64 `json:"duplicates"`
+	}
+	type response struct {
+		ID        int64     `json:"id"`
+		Question  string    `json:"question"`
+		Answer    string    `json:"answer"`
+		CreatedAt time.Time `json:"createdAt"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(way.Param(r.Context(), "id"), 10, 64)
+		if err != nil {
+			writeError(w, ErrNonNumericFactID)
+			return
+		}
+
+		version, err := ifMatch(r.Header.Get(ifMatchKey))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		var req request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, ErrInvalidRequestBody{err})
+			return
+		}
+
+		fact, err := s.service.Merge(r.Context(), id, req.Duplicates, version)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		w.Header().Set(contentTypeKey, contentTypeValue)
+		w.Header().Set(etagKey, etag(fact.Version))
+		json.NewEncoder(w).Encode(response{ID: fact.ID, Question: fact.Question, Answer: fact.Answer, CreatedAt: fact.CreatedAt})
+	}
+}
+
+func (s *server) handleSetTranslation() http.HandlerFunc {
+	type request struct {
+		Question string `json:"question"`
+		Answer   string `json:"answer"`
+	}
+	type response struct {
+		ID       int64  `json:"id"`
+		Language string `json:"language"`
+		Question string `json:"question"`
+		Answer   string `json:"answer"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(way.Param(r.Context(), "id"), 10, 64)
+		if err != nil {
+			writeError(w, ErrNonNumericFactID)
+			return
+		}
+
+		version, err := ifMatch(r.Header.Get(ifMatchKey))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		var req request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, ErrInvalidRequestBody{err})
+			return
+		}
+
+		lang := way.Param(r.Context(), "lang")
+		fact, created, err := s.service.SetTranslation(r.Context(), id, lang, pkg.Translation{Question: req.Question, Answer: req.Answer}, version)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		lang, _ = pkg.ParseLanguage(lang)
+		t := fact.Translations[lang]
+		w.Header().Set(contentTypeKey, contentTypeValue)
+		w.Header().Set(etagKey, etag(fact.Version))
+		if created {
+			w.WriteHeader(http.StatusCreated)
+		}
+		json.NewEncoder(w).Encode(response{ID: fact.ID, Language: lang, Question: t.Question, Answer: t.Answer})
+	}
+}
+
+func (s *server) handleMissingTranslations() http.HandlerFunc {
+	type fact struct {
+		ID        int64     `json:"id"`
+		Language  string    `json:"language"`
+		Question  string    `json:"question"`
+		Answer    string    `json:"answer"`
+		CreatedAt time.Time `json:"createdAt"`
+	}
+	type response []fact
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		list, err := s.service.MissingTranslations(r.Context(), way.Param(r.Context(), "lang"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		resp := make(response, 0, len(list))
+		for _, v := range list {
+			resp = append(resp, fact{ID: v.ID, Language: v.Language, Question: v.Question, Answer: v.Answer, CreatedAt: v.CreatedAt})
+		}
+		w.Header().Set(contentTypeKey, contentTypeValue)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+type attachment struct {
+	ID          string    `json:"id"`
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"contentType"`
+	Size        int64     `json:"size"`
+	Checksum    string    `json:"checksum"`
+	CreatedAt   time.Time `json:"createdAt"`
+	URL         string    `json:"url"`
+}
+
+func newAttachment(factID int64, a pkg.Attachment) attachment {
+	return attachment{
+		ID:          a.ID,
+		Filename:    a.Filename,
+		ContentType: a.ContentType,
+		Size:        a.Size,
+		Checksum:    a.Checksum,
+		CreatedAt:   a.CreatedAt,
+		URL:         fmt.Sprintf("/factlist/v1/fact/%d/attachments/%s", factID, a.ID),
+	}
+}
+
+func (s *server) handleAttach() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(way.Param(r.Context(), "id"), 10, 64)
+		if err != nil {
+			writeError(w, ErrNonNumericFactID)
+			return
+		}
+
+		version, err := ifMatch(r.Header.Get(ifMatchKey))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		// stream the file part to the service instead of buffering the whole form
+		reader, err := r.MultipartReader()
+		if err != nil {
+			writeError(w, ErrInvalidRequestBody{err})
+			return
+		}
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				writeError(w, ErrInvalidRequestBody{fmt.Errorf("missing %q part", attachmentField)})
+				return
+			}
+			if err != nil {
+				writeError(w, ErrInvalidRequestBody{err})
+				return
+			}
+			if part.FormName() != attachmentField {
+				continue
+			}
+
+			fact, a, err := s.attachments.Attach(r.Context(), id, part.FileName(), part, version)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+
+			resp := newAttachment(id, *a)
+			w.Header().Set(contentTypeKey, contentTypeValue)
+			w.Header().Set(etagKey, etag(fact.Version))
+			w.Header().Set(locationKey, resp.URL)
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(resp)
+			return
+		}
+	}
+}
+
+func (s *server) handleListAttachments() http.HandlerFunc {
+	type response []attachment
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(way.Param(r.Context(), "id"), 10, 64)
+		if err != nil {
+			writeError(w, ErrNonNumericFactID)
+			return
+		}
+
+		fact, err := s.service.Get(r.Context(), id)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		resp := make(response, 0, len(fact.Attachments))
+		for _, a := range fact.Attachments {
+			resp = append(resp, newAttachment(id, a))
+		}
+		w.Header().Set(contentTypeKey, contentTypeValue)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+func (s *server) handleGetAttachment() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(way.Param(r.Context(), "id"), 10, 64)
+		if err != nil {
+			writeError(w, ErrNonNumericFactID)
+			return
+		}
+
+		a, content, err := s.attachments.OpenAttachment(r.Context(), id, way.Param(r.Context(), "attachment"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		defer content.Close()
+
+		// the content of an attachment never changes, only its ID does
+		tag := strconv.Quote(a.Checksum)
+		w.Header().Set(etagKey, tag)
+		w.Header().Set(cacheControlKey, "public, max-age=31536000, immutable")
+		if noneMatch(r.Header.Get(ifNoneMatchKey), tag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Header().Set(contentTypeKey, a.ContentType)
+		w.Header().Set("Content-Length", strconv.FormatInt(a.Size, 10))
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		if a.Filename != "" {
+			w.Header().Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": a.Filename}))
+		}
+		io.Copy(w, content)
+	}
+}
+
+func (s *server) handleDetach() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(way.Param(r.Context(), "id"), 10, 64)
+		if err != nil {
+			writeError(w, ErrNonNumericFactID)
+			return
+		}
+
+		version, err := ifMatch(r.Header.Get(ifMatchKey))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		if err := s.attachments.Detach(r.Context(), id, way.Param(r.Context(), "attachment"), version); err != nil {
+			writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// source is the JSON representation of a pkg.Source. Problem and brokenSince
+// are set by the link checker and ignored in requests.
+type source struct {
+	URL         string     `json:"url"`
+	Title       string     `json:"title,omitempty"`
+	AccessedAt  *time.Time `json:"accessedAt,omitempty"`
+	Problem     string     `json:"problem,omitempty"`
+	BrokenSince *time.Time `json:"brokenSince,omitempty"`
+}
+
+func newSources(sources []pkg.Source) []source {
+	if len(sources) == 0 {
+		return nil
+	}
+	resp := make([]source, 0, len(sources))
+	for _, v := range sources {
+		src := source{URL: v.URL, Title: v.Title, Problem: v.Problem}
+		if !v.AccessedAt.IsZero() {
+			accessedAt := v.AccessedAt
+			src.AccessedAt = &accessedAt
+		}
+		if !v.BrokenSince.IsZero() {
+			brokenSince := v.BrokenSince
+			src.BrokenSince = &brokenSince
+		}
+		resp = append(resp, src)
+	}
+	return resp
+}
+
+func toSources(sources []source) []pkg.Source {
+	list := make([]pkg.Source, 0, len(sources))
+	for _, v := range sources {
+		src := pkg.Source{URL: v.URL, Title: v.Title}
+		if v.AccessedAt != nil {
+			src.AccessedAt = *v.AccessedAt
+		}
+		list = append(list, src)
+	}
+	return list
+}
+
+func (s *server) handleBrokenSources() http.HandlerFunc {
+	type fact struct {
+		ID       int64      `json:"id"`
+		Question string     `json:"question"`
+		Answer   string     `json:"answer"`
+		Status   pkg.Status `json:"status"`
+		Sources  []source   `json:"sources"`
+	}
+	type response []fact
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		list, err := s.service.BrokenSources(r.Context())
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		resp := make(response, 0, len(list))
+		for _, v := range list {
+			resp = append(resp, fact{ID: v.ID, Question: v.Question, Answer: v.Answer, Status: v.Status, Sources: newSources(v.Sources)})
+		}
+		w.Header().Set(contentTypeKey, contentTypeValue)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+// card is the JSON representation of a pkg.Card
+type card struct {
+	FactID         int64      `json:"factId"`
+	Repetitions    int        `json:"repetitions"`
+	Interval       int        `json:"interval"`
+	Ease           float64    `json:"ease"`
+	Due            *time.Time `json:"due,omitempty"`
+	Reviews        int        `json:"reviews"`
+	Lapses         int        `json:"lapses"`
+	LastReviewedAt *time.Time `json:"lastReviewedAt,omitempty"`
+}
+
+func newCard(c pkg.Card) card {
+	resp := card{
+		FactID:      c.FactID,
+		Repetitions: c.Repetitions,
+		Interval:    c.Interval,
+		Ease:        c.Ease,
+		Reviews:     c.Reviews,
+		Lapses:      c.Lapses,
+	}
+	if !c.Due.IsZero() {
+		due := c.Due
+		resp.Due = &due
+	}
+	if !c.LastReviewedAt.IsZero() {
+		lastReviewedAt := c.LastReviewedAt
+		resp.LastReviewedAt = &lastReviewedAt
+	}
+	return resp
+}
+
+func (s *server) handleDueCards() http.HandlerFunc {
+	type dueCard struct {
+		ID       int64  `json:"id"`
+		Question string `json:"question"`
+		Answer   string `json:"answer"`
+		New      bool   `json:"new"`
+		Card     card   `json:"card"`
+	}
+	type response []dueCard
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit, err := queryLimit(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		chain, err := languageChain(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		learner, err := authenticated(r.Context())
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		cards, err := s.study.DueCards(r.Context(), learner.Username, limit)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		resp := make(response, 0, len(cards))
+		for _, v := range cards {
+			t, _ := v.Fact.Localize(chain)
+			resp = append(resp, dueCard{ID: v.Fact.ID, Question: t.Question, Answer: t.Answer, New: v.New, Card: newCard(v.Card)})
+		}
+		w.Header().Set(contentTypeKey, contentTypeValue)
+		w.Header().Add(varyKey, acceptLanguageKey)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+func (s *server) handleReviewCard() http.HandlerFunc {
+	type request struct {
+		Grade *pkg.Grade `json:"grade"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		learner, err := authenticated(r.Context())
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		id, err := strconv.ParseInt(way.Param(r.Context(), "id"), 10, 64)
+		if err != nil {
+			writeError(w, ErrNonNumericFactID)
+			return
+		}
+
+		var req request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, ErrInvalidRequestBody{err})
+			return
+		}
+		if req.Grade == nil {
+			writeError(w, pkg.ErrInvalidGrade)
+			return
+		}
+
+		c, err := s.study.Grade(r.Context(), learner.Username, id, *req.Grade)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		w.Header().Set(contentTypeKey, contentTypeValue)
+		json.NewEncoder(w).Encode(newCard(*c))
+	}
+}
+
+func (s *server) handleStudyStats() http.HandlerFunc {
+	type response struct {
+		Cards     int     `json:"cards"`
+		New       int     `json:"new"`
+		Due       int     `json:"due"`
+		Mature    int     `json:"mature"`
+		Reviews   int     `json:"reviews"`
+		Lapses    int     `json:"lapses"`
+		Retention float64 `json:"retention"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		learner, err := authenticated(r.Context())
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		stats, err := s.study.StudyStats(r.Context(), learner.Username)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		w.Header().Set(contentTypeKey, contentTypeValue)
+		json.NewEncoder(w).Encode(response{
+			Cards:     stats.Cards,
+			New:       stats.New,
+			Due:       stats.Due,
+			Mature:    stats.Mature,
+			Reviews:   stats.Reviews,
+			Lapses:    stats.Lapses,
+			Retention: stats.Retention,
+		})
+	}
+}
+
+// answerStats is the JSON representation of the statistics of a fact's attempts
+type answerStats struct {
+	FactID          int64         `json:"factId"`
+	Attempts        int           `json:"attempts"`
+	Correct         int           `json:"correct"`
+	Accuracy        float64       `json:"accuracy"`
+	MedianLatencyMS int64         `json:"medianLatencyMs"`
+	WrongAnswers    []answerCount `json:"wrongAnswers"`
+}
+
+type answerCount struct {
+	Answer string `json:"answer"`
+	Count  int    `json:"count"`
+}
+
+func newAnswerStats(stats pkg.AnswerStats) answerStats {
+	wrong := make([]answerCount, 0, len(stats.WrongAnswers))
+	for _, v := range stats.WrongAnswers {
+		wrong = append(wrong, answerCount{Answer: v.Answer, Count: v.Count})
+	}
+	return answerStats{
+		FactID:          stats.FactID,
+		Attempts:        stats.Attempts,
+		Correct:         stats.Correct,
+		Accuracy:        stats.Accuracy(),
+		MedianLatencyMS: stats.MedianLatency.Milliseconds(),
+		WrongAnswers:    wrong,
+	}
+}
+
+func (s *server) handleAnswerFact() http.HandlerFunc {
+	type request struct {
+		Answer    string `json:"answer"`
+		LatencyMS int64  `json:"latencyMs"`
+	}
+	type response struct {
+		Correct bool   `json:"correct"`
+		Answer  string `json:"answer"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(way.Param(r.Context(), "id"), 10, 64)
+		if err != nil {
+			writeError(w, ErrNonNumericFactID)
+			return
+		}
+
+		chain, err := languageChain(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		var req request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, ErrInvalidRequestBody{err})
+			return
+		}
+
+		fact, attempt, err := s.analytics.RecordAnswer(r.Context(), id, req.Answer, time.Duration(req.LatencyMS)*time.Millisecond)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		t, _ := fact.Localize(chain)
+		w.Header().Set(contentTypeKey, contentTypeValue)
+		w.Header().Add(varyKey, acceptLanguageKey)
+		json.NewEncoder(w).Encode(response{Correct: attempt.Correct, Answer: t.Answer})
+	}
+}
+
+func (s *server) handleFactStats() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(way.Param(r.Context(), "id"), 10, 64)
+		if err != nil {
+			writeError(w, ErrNonNumericFactID)
+			return
+		}
+
+		stats, err := s.analytics.FactStats(r.Context(), id)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		w.Header().Set(contentTypeKey, contentTypeValue)
+		json.NewEncoder(w).Encode(newAnswerStats(*stats))
+	}
+}
+
+func (s *server) handleHardestFacts() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit, err := queryLimit(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		var min int
+		if v := r.URL.Query().Get("min"); v != "" {
+			if min, err = strconv.Atoi(v); err != nil || min < 0 {
+				writeError(w, ErrInvalidMin)
+				return
+			}
+		}
+
+		list, err := s.analytics.HardestFacts(r.Context(), min, limit)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeAnswerStats(w, list)
+	}
+}
+
+func (s *server) handleWrongAnswers() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit, err := queryLimit(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		list, err := s.analytics.WrongAnswers(r.Context(), limit)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeAnswerStats(w, list)
+	}
+}
+
+func writeAnswerStats(w http.ResponseWriter, list []pkg.AnswerStats) {
+	resp := make([]answerStats, 0, len(list))
+	for _, v := range list {
+		resp = append(resp, newAnswerStats(v))
+	}
+	w.Header().Set(contentTypeKey, contentTypeValue)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// collection is the JSON representation of a pkg.Collection
+type collection struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Owner       string    `json:"owner,omitempty"`
+	FactIDs     []int64   `json:"factIds"`
+	Slug        string    `json:"slug,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+func newCollection(c pkg.Collection) collection {
+	factIDs := c.FactIDs
+	if factIDs == nil {
+		factIDs = []int64{}
+	}
+	return collection{
+		ID:          c.ID,
+		Name:        c.Name,
+		Description: c.Description,
+		Owner:       c.Owner,
+		FactIDs:     factIDs,
+		Slug:        c.Slug,
+		CreatedAt:   c.CreatedAt,
+		UpdatedAt:   c.UpdatedAt,
+	}
+}
+
+// collectionRequest is the body of the requests creating or replacing a collection
+type collectionRequest struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	FactIDs     []int64 `json:"factIds"`
+}
+
+func (s *server) handleCreateCollection() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req collectionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, ErrInvalidRequestBody{err})
+			return
+		}
+
+		c, err := s.collections.CreateCollection(r.Context(), pkg.Collection{Name: req.Name, Description: req.Description, FactIDs: req.FactIDs})
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		w.Header().Set(contentTypeKey, contentTypeValue)
+		w.Header().Set(locationKey, fmt.Sprintf("/factlist/v1/collections/%d", c.ID))
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(newCollection(*c))
+	}
+}
+
+func (s *server) handleListCollections() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		list, err := s.collections.Collections(r.Context())
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		resp := make([]collection, 0, len(list))
+		for _, c := range list {
+			resp = append(resp, newCollection(c))
+		}
+		w.Header().Set(contentTypeKey, contentTypeValue)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+func (s *server) handleGetCollection() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(way.Param(r.Context(), "id"), 10, 64)
+		if err != nil {
+			writeError(w, ErrNonNumericID)
+			return
+		}
+
+		chain, err := languageChain(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		c, facts, err := s.collections.GetCollection(r.Context(), id)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeCollection(w, *c, facts, chain)
+	}
+}
+
+func (s *server) handleUpdateCollection() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(way.Param(r.Context(), "id"), 10, 64)
+		if err != nil {
+			writeError(w, ErrNonNumericID)
+			return
+		}
+
+		var req collectionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, ErrInvalidRequestBody{err})
+			return
+		}
+
+		c, err := s.collections.UpdateCollection(r.Context(), pkg.Collection{ID: id, Name: req.Name, Description: req.Description, FactIDs: req.FactIDs})
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		w.Header().Set(contentTypeKey, contentTypeValue)
+		json.NewEncoder(w).Encode(newCollection(*c))
+	}
+}
+
+func (s *server) handleRemoveCollection() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(way.Param(r.Context(), "id"), 10, 64)
+		if err != nil {
+			writeError(w, ErrNonNumericID)
+			return
+		}
+
+		if err := s.collections.RemoveCollection(r.Context(), id); err != nil {
+			writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (s *server) handleReorderCollection() http.HandlerFunc {
+	type request struct {
+		FactIDs []int64 `json:"factIds"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(way.Param(r.Context(), "id"), 10, 64)
+		if err != nil {
+			writeError(w, ErrNonNumericID)
+			return
+		}
+
+		var req request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, ErrInvalidRequestBody{err})
+			return
+		}
+
+		c, err := s.collections.ReorderCollection(r.Context(), id, req.FactIDs)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		w.Header().Set(contentTypeKey, contentTypeValue)
+		json.NewEncoder(w).Encode(newCollection(*c))
+	}
+}
+
+func (s *server) handleShareCollection() http.HandlerFunc {
+	return s.shareCollection(true)
+}
+
+func (s *server) handleUnshareCollection() http.HandlerFunc {
+	return s.shareCollection(false)
+}
+
+func (s *server) shareCollection(shared bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(way.Param(r.Context(), "id"), 10, 64)
+		if err != nil {
+			writeError(w, ErrNonNumericID)
+			return
+		}
+
+		c, err := s.collections.ShareCollection(r.Context(), id, shared)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		w.Header().Set(contentTypeKey, contentTypeValue)
+		json.NewEncoder(w).Encode(newCollection(*c))
+	}
+}
+
+func (s *server) handleCollectionSheet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(way.Param(r.Context(), "id"), 10, 64)
+		if err != nil {
+			writeError(w, ErrNonNumericID)
+			return
+		}
+
+		opts, err := sheetOptions(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		c, facts, err := s.collections.GetCollection(r.Context(), id)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		s.writeSheet(w, r, opts, pkg.NewQuizSheet(c.Name, c.Description, facts, opts.chain, opts.layout))
+	}
+}
+
+func (s *server) handleSharedCollection() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		chain, err := languageChain(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		c, facts, err := s.collections.SharedCollection(r.Context(), way.Param(r.Context(), "slug"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeCollection(w, *c, facts, chain)
+	}
+}
+
+func (s *server) handleSharedCollectionSheet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		opts, err := sheetOptions(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		c, facts, err := s.collections.SharedCollection(r.Context(), way.Param(r.Context(), "slug"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		s.writeSheet(w, r, opts, pkg.NewQuizSheet(c.Name, c.Description, facts, opts.chain, opts.layout))
+	}
+}
+
+// writeCollection writes c along with its facts, localized to the first language of chain they are available in
+func writeCollection(w http.ResponseWriter, c pkg.Collection, facts []pkg.Fact, chain []string) {
+	type fact struct {
+		ID       int64  `json:"id"`
+		Question string `json:"question"`
+		Answer   string `json:"answer"`
+	}
+	type response struct {
+		collection
+		Facts []fact `json:"facts"`
+	}
+
+	resp := response{collection: newCollection(c), Facts: make([]fact, 0, len(facts))}
+	for _, v := range facts {
+		t, _ := v.Localize(chain)
+		resp.Facts = append(resp.Facts, fact{ID: v.ID, Question: t.Question, Answer: t.Answer})
+	}
+	w.Header().Set(contentTypeKey, contentTypeValue)
+	w.Header().Add(varyKey, acceptLanguageKey)
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (s *server) handleQuizSheet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		opts, err := sheetOptions(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		q := r.URL.Query()
+		selection := pkg.FactSelection{Category: q.Get("category"), Difficulty: pkg.Difficulty(strings.ToLower(q.Get("difficulty")))}
+		if v := q.Get("collection"); v != "" {
+			if selection.CollectionID, err = strconv.ParseInt(v, 10, 64); err != nil {
+				writeError(w, ErrNonNumericID)
+				return
+			}
+		}
+		if v := q.Get("random"); v != "" {
+			if selection.Random, err = strconv.Atoi(v); err != nil || selection.Random < 1 {
+				writeError(w, ErrInvalidRandom)
+				return
+			}
+		}
+
+		facts, err := s.sheets.SelectFacts(r.Context(), selection)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		title := strings.TrimSpace(q.Get("title"))
+		if title == "" {
+			title = defaultSheetTitle
+		}
+		s.writeSheet(w, r, opts, pkg.NewQuizSheet(title, "", facts, opts.chain, opts.layout))
+	}
+}
+
+// sheetTemplate is the JSON representation of a custom quiz sheet template
+type sheetTemplate struct {
+	Name      string    `json:"name"`
+	Format    string    `json:"format"`
+	Source    string    `json:"source"`
+	Owner     string    `json:"owner,omitempty"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func newSheetTemplate(t pkg.SheetTemplate) sheetTemplate {
+	return sheetTemplate{Name: t.Name, Format: t.Format, Source: t.Source, Owner: t.Owner, UpdatedAt: t.UpdatedAt}
+}
+
+func (s *server) handleListSheetTemplates() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		list, err := s.sheets.SheetTemplates(r.Context())
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		resp := make([]sheetTemplate, 0, len(list))
+		for _, t := range list {
+			resp = append(resp, newSheetTemplate(t))
+		}
+		w.Header().Set(contentTypeKey, contentTypeValue)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+func (s *server) handleGetSheetTemplate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		t, err := s.sheets.SheetTemplate(r.Context(), way.Param(r.Context(), "name"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		w.Header().Set(contentTypeKey, contentTypeValue)
+		json.NewEncoder(w).Encode(newSheetTemplate(*t))
+	}
+}
+
+func (s *server) handleSaveSheetTemplate() http.HandlerFunc {
+	type request struct {
+		Format string `json:"format"`
+		Source string `json:"source"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, ErrInvalidRequestBody{err})
+			return
+		}
+
+		t, created, err := s.sheets.SaveSheetTemplate(r.Context(), pkg.SheetTemplate{
+			Name:   way.Param(r.Context(), "name"),
+			Format: req.Format,
+			Source: req.Source,
+		})
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		w.Header().Set(contentTypeKey, contentTypeValue)
+		if created {
+			w.Header().Set(locationKey, "/factlist/v1/sheet/templates/"+t.Name)
+			w.WriteHeader(http.StatusCreated)
+		}
+		json.NewEncoder(w).Encode(newSheetTemplate(*t))
+	}
+}
+
+func (s *server) handleRemoveSheetTemplate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := s.sheets.RemoveSheetTemplate(r.Context(), way.Param(r.Context(), "name")); err != nil {
+			writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// defaultSheetTitle is the title of quiz sheets requested without one
+const defaultSheetTitle = "Quiz"
+
+// sheetRequest tells how a quiz sheet is rendered and in which languages
+type sheetRequest struct {
+	format   string
+	part     string
+	template string
+	layout   pkg.SheetLayout
+	chain    []string
+}
+
+// sheetOptions parses the format, part, template, rounds and numbering query
+// parameters and the languages a quiz sheet is requested in. The format is
+// markdown unless given, or the one of the custom template asked for.
+func sheetOptions(r *http.Request) (sheetRequest, error) {
+	q := r.URL.Query()
+	opts := sheetRequest{
+		format:   q.Get("format"),
+		part:     q.Get("part"),
+		template: q.Get("template"),
+		layout:   pkg.SheetLayout{Numbering: pkg.Numbering(q.Get("numbering"))},
+	}
+	switch opts.format {
+	case "":
+		if opts.template == "" {
+			opts.format = FormatMarkdown
+		}
+	case FormatMarkdown, FormatHTML, FormatText:
+	default:
+		return sheetRequest{}, ErrUnsupportedFormat
+	}
+	switch opts.part {
+	case "", PartQuestions, PartAnswers:
+	default:
+		return sheetRequest{}, ErrUnsupportedPart
+	}
+	if v := q.Get("rounds"); v != "" {
+		rounds, err := strconv.Atoi(v)
+		if err != nil || rounds < 1 {
+			return sheetRequest{}, pkg.ErrInvalidRounds
+		}
+		opts.layout.Rounds = rounds
+	}
+	if err := opts.layout.Validate(); err != nil {
+		return sheetRequest{}, err
+	}
+
+	chain, err := languageChain(r)
+	if err != nil {
+		return sheetRequest{}, err
+	}
+	opts.chain = chain
+	return opts, nil
+}
+
+// writeSheet renders sheet with the custom template of opts if one is asked
+// for, or with the built-in template of its format otherwise
+func (s *server) writeSheet(w http.ResponseWriter, r *http.Request, opts sheetRequest, sheet pkg.QuizSheet) {
+	var (
+		buf    bytes.Buffer
+		format = opts.format
+		err    error
+	)
+	if opts.template != "" {
+		if s.sheets == nil {
+			writeError(w, ErrSheetTemplatesDisabled)
+			return
+		}
+		var t *pkg.SheetTemplate
+		if t, err = s.sheets.SheetTemplate(r.Context(), opts.template); err != nil {
+			writeError(w, err)
+			return
+		}
+		if format != "" && format != t.Format {
+			writeError(w, ErrTemplateFormatMismatch)
+			return
+		}
+		format = t.Format
+		err = RenderCustomSheet(r.Context(), &buf, *t, opts.part, sheet)
+	} else {
+		err = RenderSheet(&buf, format, opts.part, sheet)
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set(contentTypeKey, sheetContentTypes[format])
+	w.Header().Add(varyKey, acceptLanguageKey)
+	buf.WriteTo(w)
+}
+
+func (s *server) handleOpenAPI() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(contentTypeKey, contentTypeValue)
+		w.Write(openAPIDocument)
+	}
+}
+
+func (s *server) handleDocs() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(contentTypeKey, "text/html; charset=utf-8")
+		w.Header().Set(cspKey, docsPolicy)
+		io.WriteString(w, docsPage)
+	}
+}
+
+func (s *server) handleDocsAsset() http.HandlerFunc {
+	files := http.FileServer(http.FS(swaggerUI))
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		asset := way.Param(r.Context(), "asset")
+		switch {
+		case asset == docsScriptName:
+			w.Header().Set(contentTypeKey, "text/javascript; charset=utf-8")
+			io.WriteString(w, docsScript)
+		case swaggerUIAssets[asset]:
+			r = r.Clone(r.Context())
+			r.URL.Path = "/" + asset
+			files.ServeHTTP(w, r)
+		default:
+			writeError(w, ErrResourceNotFound)
+		}
+	}
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	w.Header().Set(contentTypeKey, contentTypeValue)
+	switch err {
+	case ErrResourceNotFound, pkg.ErrFactNotFound, pkg.ErrRevisionNotFound, pkg.ErrAttachmentNotFound, pkg.ErrCollectionNotFound,
+		pkg.ErrSheetTemplateNotFound:
+		w.WriteHeader(http.StatusNotFound)
+	case pkg.ErrFactAlreadyExists, pkg.ErrInvalidTransition, pkg.ErrSlugTaken:
+		w.WriteHeader(http.StatusConflict)
+	case pkg.ErrVersionConflict:
+		w.WriteHeader(http.StatusPreconditionFailed)
+	case ErrNonNumericFactID, ErrNonNumericRev, ErrInvalidIfMatch, pkg.ErrInvalidStatus, ErrMissingQuery, ErrInvalidLimit,
+		pkg.ErrInvalidLanguage, pkg.ErrInvalidGrade, pkg.ErrInvalidLearner, ErrInvalidLatency, ErrInvalidMin,
+		ErrNonNumericID, ErrUnsupportedFormat, ErrUnsupportedPart, ErrInvalidRandom, ErrTemplateFormatMismatch,
+		pkg.ErrInvalidDifficulty, pkg.ErrInvalidRounds, pkg.ErrInvalidNumbering:
+		w.WriteHeader(http.StatusBadRequest)
+	case users.ErrUnauthenticated:
+		w.Header().Set(authenticateKey, authenticateValue)
+		w.WriteHeader(http.StatusUnauthorized)
+	case ErrNotCollectionOwner, ErrNotSheetTemplateOwner, ErrNotReviewer, ErrFactPublished:
+		w.WriteHeader(http.StatusForbidden)
+	case ErrMethodNotAllowed:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	case ErrUnsupportedPatch, pkg.ErrUnsupportedMediaType:
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+	case pkg.ErrAttachmentTooLarge, ErrPatchTooLarge:
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+	case ErrInvalidMerge, pkg.ErrTranslationRedundant, ErrInvalidOrder:
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	case ErrHistoryDisabled, ErrSearchDisabled, ErrDuplicateDetectionDisabled, ErrAttachmentsDisabled, ErrStudyDisabled,
+		ErrAnalyticsDisabled, ErrCollectionsDisabled, ErrSheetTemplatesDisabled:
+		w.WriteHeader(http.StatusNotImplemented)
+	default:
+		switch err.(type) {
+		case ErrInvalidRequestBody:
+			w.WriteHeader(http.StatusBadRequest)
+		case ErrInvalidPatch, *pkg.ValidationError, ErrInvalidSheetTemplate:
+			w.WriteHeader(http.StatusUnprocessableEntity)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}
+
+	type fieldError struct {
+		Field   string `json:"field"`
+		Message string `json:"message"`
+	}
+
+	body := map[string]interface{}{"error": err.Error()}
+	if verr, ok := err.(*pkg.ValidationError); ok {
+		details := make([]fieldError, 0, len(verr.Problems))
+		for _, p := range verr.Problems {
+			details = append(details, fieldError{Field: p.Field, Message: p.Message})
+		}
+		body["details"] = details
+	}
+	if id := w.Header().Get(requestid.HeaderKey); id != "" {
+		body["requestId"] = id
+	}
+	json.NewEncoder(w).Encode(body)
+}
+
+// queryLimit parses the limit query parameter, defaulting it when missing
+func queryLimit(r *http.Request) (int, error) {
+	v := r.URL.Query().Get("limit")
+	if v == "" {
+		return defaultLimit, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 || n > maxLimit {
+		return 0, ErrInvalidLimit
+	}
+	return n, nil
+}
+
+// etag formats a fact version as a strong entity tag
+func etag(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
+}
+
+// listETag derives an entity tag from the IDs and versions of every fact in
+// list and the chain of languages they are localized to
+func listETag(list []pkg.Fact, chain []string) string {
+	h := fnv.New64a()
+	for _, f := range list {
+		fmt.Fprintf(h, "%d:%d;", f.ID, f.Version)
+	}
+	fmt.Fprintf(h, "lang:%s", strings.Join(chain, ","))
+	return strconv.Quote(strconv.FormatUint(h.Sum64(), 16))
+}
+
+// ifMatch parses an If-Match header into the version it expects.
+// An absent header or * yields 0, meaning any version is acceptable.
+// If-Match uses the strong comparison, so a weak tag never matches.
+func ifMatch(header string) (int64, error) {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+
+	weak := strings.HasPrefix(header, "W/")
+	tag, err := strconv.Unquote(strings.TrimPrefix(header, "W/"))
+	if err != nil {
+		return 0, ErrInvalidIfMatch
+	}
+	if weak {
+		return -1, nil
+	}
+	version, err := strconv.ParseInt(tag, 10, 64)
+	if err != nil || version <= 0 {
+		// a well-formed tag we never issued can't match the current version
+		return -1, nil
+	}
+	return version, nil
+}
+
+// noneMatch reports whether an If-None-Match header matches tag, using the
+// weak comparison required for GET requests
+func noneMatch(header, tag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// languageChain lists the languages the client prefers, most preferred first,
+// from the lang query parameter followed by the Accept-Language header, along
+// with the more general languages each of them falls back to
+func languageChain(r *http.Request) ([]string, error) {
+	var preferred []string
+	if lang := r.URL.Query().Get("lang"); lang != "" {
+		tag, err := pkg.ParseLanguage(lang)
+		if err != nil {
+			return nil, err
+		}
+		preferred = append(preferred, tag)
+	}
+
+	// a malformed header is ignored rather than failing the request
+	accepted, _, _ := language.ParseAcceptLanguage(r.Header.Get(acceptLanguageKey))
+	for _, tag := range accepted {
+		preferred = append(preferred, tag.String())
+	}
+	return pkg.FallbackChain(preferred...), nil
+}
+
+type loggingResponseWriter struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (lrw *loggingResponseWriter) WriteHeader(code int) {
+	lrw.statusCode = code
+	lrw.ResponseWriter.WriteHeader(code)
+}
+
+func httpLoggingMiddleware(logger log.Logger, operation string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			begin := time.Now()
+			lrw := &loggingResponseWriter{w, http.StatusOK}
+			next.ServeHTTP(lrw, r)
+			level.Info(contextLogger(r.Context(), logger)).Log(
+				"operation", operation,
+				"method", r.Method,
+				"path", r.URL.Path,
+				"took", time.Since(begin),
 				"status", lrw.statusCode,
 			)
 		})
 	}
 }
+
+const instrumentationName = "github.com/markhaur/trivia/pkg/factlist"
+
+// httpTracingMiddleware starts a server span for every request, continuing the
+// trace described by the incoming W3C traceparent header if present.
+func httpTracingMiddleware(operation string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+			ctx, span := otel.Tracer(instrumentationName).Start(ctx, operation,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					attribute.String("http.method", r.Method),
+					attribute.String("http.target", r.URL.Path),
+				),
+			)
+			defer span.End()
+			if id := requestid.FromContext(ctx); id != "" {
+				span.SetAttributes(attribute.String("http.request_id", id))
+			}
+
+			lrw := &loggingResponseWriter{w, http.StatusOK}
+			next.ServeHTTP(lrw, r.WithContext(ctx))
+
+			span.SetAttributes(attribute.Int("http.status_code", lrw.statusCode))
+			if lrw.statusCode >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(lrw.statusCode))
+			}
+		})
+	}
+}
@@ -1,20 +1,38 @@
 package factlist_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
 	"github.com/go-kit/log"
 	"github.com/markhaur/trivia/pkg"
 	"github.com/markhaur/trivia/pkg/factlist"
+	"github.com/markhaur/trivia/pkg/filesystem"
 	"github.com/markhaur/trivia/pkg/inmem"
+	"github.com/markhaur/trivia/pkg/requestid"
+	"github.com/markhaur/trivia/pkg/users"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func TestListTasks(t *testing.T) {
@@ -31,9 +49,9 @@ func TestListTasks(t *testing.T) {
 		{
 			Name: "Returns 200 and 3 facts if 3 facts exists",
 			FactsInRepo: []pkg.Fact{
-				{ID: 1, Question: "what is my github username?", Answer: "markhaur"},
-				{ID: 2, Question: "what's your favourite language?", Answer: "Go"},
-				{ID: 3, Question: "what's the name of current project?", Answer: "trivia"},
+				{ID: 1, Question: "what is my github username?", Answer: "markhaur", Status: pkg.StatusPublished},
+				{ID: 2, Question: "what's your favourite language?", Answer: "Go", Status: pkg.StatusPublished},
+				{ID: 3, Question: "what's the name of current project?", Answer: "trivia", Status: pkg.StatusPublished},
 			},
 			Expected: `[
 				{"id": 1, "question": "what is my github username?", "answer": "markhaur", "createdAt":"0001-01-01T00:00:00Z"},
@@ -44,7 +62,7 @@ func TestListTasks(t *testing.T) {
 		{
 			Name: "Returns 200 and 1 fact if 1 fact exists",
 			FactsInRepo: []pkg.Fact{
-				{ID: 1, Question: "what is my github username?", Answer: "markhaur"},
+				{ID: 1, Question: "what is my github username?", Answer: "markhaur", Status: pkg.StatusPublished},
 			},
 			Expected: `[
 				{"id": 1, "question": "what is my github username?", "answer": "markhaur", "createdAt":"0001-01-01T00:00:00Z"}
@@ -99,12 +117,12 @@ func TestUpdateTask(t *testing.T) {
 		},
 		{
 			Name:             "Returns 201 and creates fact for valid request if it doesn't exist",
-			ReqBody:          `{"question": "what is your username?", "answer": "markhaur"}`,
+			ReqBody:          `{"question": "what is your github username?", "answer": "markhaur"}`,
 			FactID:           "1337",
-			ExpectedQuestion: "what is your username?",
+			ExpectedQuestion: "what is your github username?",
 			ExpectedAnswer:   "markhaur",
 			ExpectedCode:     http.StatusCreated,
-			ExpectedRspBody:  `{"id": 1337, "question": "what is your username?", "answer": "markhaur", "createdAt":"0001-01-01T00:00:00Z"}`,
+			ExpectedRspBody:  `{"id": 1337, "question": "what is your github username?", "answer": "markhaur", "createdAt":"0001-01-01T00:00:00Z"}`,
 		},
 		{
 			Name:             "Returns 400 and error msg for non-numeric id",
@@ -140,7 +158,7 @@ func TestUpdateTask(t *testing.T) {
 			var (
 				require = require.New(t)
 				assert  = assert.New(t)
-				svc     = factlist.NewService(inmem.NewFactRepository())
+				svc     = factlist.NewService(inmem.NewFactRepository(), factlist.WithReviewers("reviewer"))
 				handler = factlist.NewServer(svc, log.NewNopLogger())
 			)
 
@@ -158,7 +176,7 @@ func TestUpdateTask(t *testing.T) {
 			assert.Equal(tc.ExpectedCode, rec.Result().StatusCode, "unexpected http status code")
 			assert.JSONEq(tc.ExpectedRspBody, rec.Body.String(), "unexpected http response body")
 
-			list, err := svc.List(context.TODO())
+			list, err := svc.ListByStatus(authenticatedAs(context.TODO(), "reviewer"))
 			require.NoError(err, "could not list facts")
 
 			if tc.ExpectedCode != 200 && tc.ExpectedCode != 201 {
@@ -176,3 +194,2523 @@ func TestUpdateTask(t *testing.T) {
 		})
 	}
 }
+
+func TestTraceContextPropagation(t *testing.T) {
+	var (
+		require  = require.New(t)
+		assert   = assert.New(t)
+		recorder = tracetest.NewSpanRecorder()
+		provider = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+		svc      = factlist.NewService(inmem.NewFactRepository())
+	)
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer otel.SetTracerProvider(trace.NewNoopTracerProvider())
+
+	svc = factlist.TracingMiddleware(provider.Tracer("test"))(svc)
+	handler := factlist.NewServer(svc, log.NewNopLogger())
+
+	serve(t, handler, "GET", "/factlist/v1/fact", "", "", map[string]string{"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"})
+
+	spans := recorder.Ended()
+	require.Len(spans, 2, "expected a span for the handler and one for the service")
+	for _, span := range spans {
+		assert.Equal("4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID().String(), "expected trace id to be propagated")
+	}
+	assert.Equal("factlist.List", spans[0].Name())
+	assert.Equal("handleListFact", spans[1].Name())
+	assert.Equal(spans[1].SpanContext().SpanID(), spans[0].Parent().SpanID(), "expected service span to be a child of handler span")
+}
+
+func TestRequestIDCorrelation(t *testing.T) {
+	var (
+		require = require.New(t)
+		assert  = assert.New(t)
+		buf     bytes.Buffer
+		logger  = log.NewLogfmtLogger(&buf)
+		svc     = factlist.LoggingMiddleware(logger)(factlist.NewService(inmem.NewFactRepository()))
+		handler = requestid.Middleware(factlist.NewServer(svc, logger))
+	)
+
+	rec := serve(t, handler, "DELETE", "/factlist/v1/fact/1337", "", "", map[string]string{requestid.HeaderKey: "req-1337"})
+
+	assert.Equal(http.StatusNotFound, rec.Result().StatusCode, "unexpected http status code")
+	assert.Equal("req-1337", rec.Header().Get(requestid.HeaderKey), "expected request id in response headers")
+	assert.JSONEq(`{"error": "trivia not found", "requestId": "req-1337"}`, rec.Body.String(), "unexpected http response body")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(lines, 2, "expected a log line from the service and one from the handler")
+	for _, line := range lines {
+		assert.Contains(line, "requestID=req-1337")
+	}
+}
+
+func TestConditionalRequests(t *testing.T) {
+	tt := []struct {
+		Name         string
+		Method       string
+		URL          string
+		Header       string
+		Value        string
+		ReqBody      string
+		ExpectedCode int
+		ExpectedETag string
+		ExpectedVary string
+		ExpectedBody string
+	}{
+		{
+			Name:         "Returns 200 and ETag for single fact",
+			Method:       "GET",
+			URL:          "/factlist/v1/fact/1",
+			ExpectedCode: http.StatusOK,
+			ExpectedETag: `"1"`,
+			ExpectedBody: `{"id": 1, "question": "what is your username?", "answer": "markhaur", "createdAt":"0001-01-01T00:00:00Z"}`,
+		},
+		{
+			Name:         "Returns 304 for single fact if ETag matches If-None-Match",
+			Method:       "GET",
+			URL:          "/factlist/v1/fact/1",
+			Header:       "If-None-Match",
+			Value:        `"1"`,
+			ExpectedCode: http.StatusNotModified,
+			ExpectedETag: `"1"`,
+		},
+		{
+			Name:         "Returns 304 for list if ETag matches If-None-Match",
+			Method:       "GET",
+			URL:          "/factlist/v1/fact",
+			Header:       "If-None-Match",
+			Value:        `"3f7bf4411db388c0"`,
+			ExpectedCode: http.StatusNotModified,
+			ExpectedETag: `"3f7bf4411db388c0"`,
+			ExpectedVary: "Accept-Language",
+		},
+		{
+			Name:         "Returns 200 for list in another language even if ETag matches If-None-Match",
+			Method:       "GET",
+			URL:          "/factlist/v1/fact?lang=de",
+			Header:       "If-None-Match",
+			Value:        `"3f7bf4411db388c0"`,
+			ExpectedCode: http.StatusOK,
+			ExpectedETag: `"1f4f8c2b05327f8b"`,
+			ExpectedVary: "Accept-Language",
+			ExpectedBody: `[{"id": 1, "question": "what is your username?", "answer": "markhaur", "createdAt":"0001-01-01T00:00:00Z"}]`,
+		},
+		{
+			Name:         "Returns 200 and new ETag for update if ETag matches If-Match",
+			Method:       "PUT",
+			URL:          "/factlist/v1/fact/1",
+			Header:       "If-Match",
+			Value:        `"1"`,
+			ReqBody:      `{"question": "what is your username?", "answer": "markhaur"}`,
+			ExpectedCode: http.StatusOK,
+			ExpectedETag: `"2"`,
+			ExpectedBody: `{"id": 1, "question": "what is your username?", "answer": "markhaur", "createdAt":"0001-01-01T00:00:00Z"}`,
+		},
+		{
+			Name:         "Returns 412 for update if ETag doesn't match If-Match",
+			Method:       "PUT",
+			URL:          "/factlist/v1/fact/1",
+			Header:       "If-Match",
+			Value:        `"2"`,
+			ReqBody:      `{"question": "what is your username?", "answer": "markhaur"}`,
+			ExpectedCode: http.StatusPreconditionFailed,
+			ExpectedBody: `{"error": "trivia has been modified"}`,
+		},
+		{
+			Name:         "Returns 412 for update of missing fact with If-Match",
+			Method:       "PUT",
+			URL:          "/factlist/v1/fact/1337",
+			Header:       "If-Match",
+			Value:        `"1"`,
+			ReqBody:      `{"question": "what is your github username?", "answer": "markhaur"}`,
+			ExpectedCode: http.StatusPreconditionFailed,
+			ExpectedBody: `{"error": "trivia has been modified"}`,
+		},
+		{
+			Name:         "Returns 412 for update of missing fact with If-Match *",
+			Method:       "PUT",
+			URL:          "/factlist/v1/fact/1337",
+			Header:       "If-Match",
+			Value:        `*`,
+			ReqBody:      `{"question": "what is your github username?", "answer": "markhaur"}`,
+			ExpectedCode: http.StatusPreconditionFailed,
+			ExpectedBody: `{"error": "trivia has been modified"}`,
+		},
+		{
+			Name:         "Returns 200 for update of existing fact with If-Match *",
+			Method:       "PUT",
+			URL:          "/factlist/v1/fact/1",
+			Header:       "If-Match",
+			Value:        `*`,
+			ReqBody:      `{"question": "what is your username?", "answer": "markhaur"}`,
+			ExpectedCode: http.StatusOK,
+			ExpectedETag: `"2"`,
+			ExpectedBody: `{"id": 1, "question": "what is your username?", "answer": "markhaur", "createdAt":"0001-01-01T00:00:00Z"}`,
+		},
+		{
+			Name:         "Returns 412 for update with a weak If-Match tag",
+			Method:       "PUT",
+			URL:          "/factlist/v1/fact/1",
+			Header:       "If-Match",
+			Value:        `W/"1"`,
+			ReqBody:      `{"question": "what is your username?", "answer": "markhaur"}`,
+			ExpectedCode: http.StatusPreconditionFailed,
+			ExpectedBody: `{"error": "trivia has been modified"}`,
+		},
+		{
+			Name:         "Returns 400 for malformed If-Match",
+			Method:       "DELETE",
+			URL:          "/factlist/v1/fact/1",
+			Header:       "If-Match",
+			Value:        `1`,
+			ExpectedCode: http.StatusBadRequest,
+			ExpectedBody: `{"error": "if-match must be a single entity tag or *"}`,
+		},
+		{
+			Name:         "Returns 412 for delete if ETag doesn't match If-Match",
+			Method:       "DELETE",
+			URL:          "/factlist/v1/fact/1",
+			Header:       "If-Match",
+			Value:        `"3"`,
+			ExpectedCode: http.StatusPreconditionFailed,
+			ExpectedBody: `{"error": "trivia has been modified"}`,
+		},
+		{
+			Name:         "Returns 204 for delete if ETag matches If-Match",
+			Method:       "DELETE",
+			URL:          "/factlist/v1/fact/1",
+			Header:       "If-Match",
+			Value:        `"1"`,
+			ExpectedCode: http.StatusNoContent,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			var (
+				require = require.New(t)
+				assert  = assert.New(t)
+				svc     = factlist.NewService(inmem.NewFactRepository(), factlist.WithReviewers("reviewer"))
+				handler = factlist.NewServer(svc, log.NewNopLogger())
+			)
+
+			_, err := svc.Save(context.TODO(), pkg.Fact{Question: "what is your username?", Answer: "markhaur", Status: pkg.StatusPublished})
+			require.NoError(err, "could not save fact")
+
+			rec := httptest.NewRecorder()
+			req, err := http.NewRequest(tc.Method, tc.URL, strings.NewReader(tc.ReqBody))
+			require.NoError(err, "could not create http request")
+			req = req.WithContext(authenticatedAs(req.Context(), "reviewer"))
+			if tc.Header != "" {
+				req.Header.Set(tc.Header, tc.Value)
+			}
+
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(tc.ExpectedCode, rec.Result().StatusCode, "unexpected http status code")
+			assert.Equal(tc.ExpectedETag, rec.Header().Get("ETag"), "unexpected ETag")
+			if tc.ExpectedVary != "" {
+				assert.Equal(tc.ExpectedVary, rec.Header().Get("Vary"), "unexpected Vary")
+			}
+			if tc.ExpectedBody == "" {
+				assert.Empty(rec.Body.String(), "expected empty http response body")
+				return
+			}
+			assert.JSONEq(tc.ExpectedBody, rec.Body.String(), "unexpected http response body")
+		})
+	}
+}
+
+func TestPatchFact(t *testing.T) {
+	tt := []struct {
+		Name            string
+		ContentType     string
+		IfMatch         string
+		ReqBody         string
+		FactID          string
+		ExpectedCode    int
+		ExpectedRspBody string
+	}{
+		{
+			Name:            "Returns 200 and updates only the patched member",
+			ContentType:     "application/merge-patch+json",
+			ReqBody:         `{"answer": "markhaur"}`,
+			FactID:          "1",
+			ExpectedCode:    http.StatusOK,
+			ExpectedRspBody: `{"id": 1, "question": "what is your username?", "answer": "markhaur", "createdAt":"2022-11-20T10:00:00Z"}`,
+		},
+		{
+			Name:            "Returns 200 if ETag matches If-Match",
+			ContentType:     "application/merge-patch+json",
+			IfMatch:         `"1"`,
+			ReqBody:         `{"question": "what's your username?"}`,
+			FactID:          "1",
+			ExpectedCode:    http.StatusOK,
+			ExpectedRspBody: `{"id": 1, "question": "what's your username?", "answer": "I don't know", "createdAt":"2022-11-20T10:00:00Z"}`,
+		},
+		{
+			Name:            "Returns 412 if ETag doesn't match If-Match",
+			ContentType:     "application/merge-patch+json",
+			IfMatch:         `"7"`,
+			ReqBody:         `{"answer": "markhaur"}`,
+			FactID:          "1",
+			ExpectedCode:    http.StatusPreconditionFailed,
+			ExpectedRspBody: `{"error": "trivia has been modified"}`,
+		},
+		{
+			Name:            "Returns 404 for missing fact",
+			ContentType:     "application/merge-patch+json",
+			ReqBody:         `{"answer": "markhaur"}`,
+			FactID:          "1337",
+			ExpectedCode:    http.StatusNotFound,
+			ExpectedRspBody: `{"error": "trivia not found"}`,
+		},
+		{
+			Name:            "Returns 415 for JSON Patch documents",
+			ContentType:     "application/json-patch+json",
+			ReqBody:         `[{"op": "replace", "path": "/answer", "value": "markhaur"}]`,
+			FactID:          "1",
+			ExpectedCode:    http.StatusUnsupportedMediaType,
+			ExpectedRspBody: `{"error": "unsupported patch document type"}`,
+		},
+		{
+			Name:            "Returns 400 for malformed patch",
+			ContentType:     "application/merge-patch+json",
+			ReqBody:         `{"answer": `,
+			FactID:          "1",
+			ExpectedCode:    http.StatusBadRequest,
+			ExpectedRspBody: `{"error": "invalid request body: malformed merge patch"}`,
+		},
+		{
+			Name:            "Returns 413 for oversized patch",
+			ContentType:     "application/merge-patch+json",
+			ReqBody:         `{"answer": "` + strings.Repeat("a", 1<<20) + `"}`,
+			FactID:          "1",
+			ExpectedCode:    http.StatusRequestEntityTooLarge,
+			ExpectedRspBody: `{"error": "patch document is too large"}`,
+		},
+		{
+			Name:            "Returns 422 for removing a required member",
+			ContentType:     "application/merge-patch+json",
+			ReqBody:         `{"question": null}`,
+			FactID:          "1",
+			ExpectedCode:    http.StatusUnprocessableEntity,
+			ExpectedRspBody: `{"error": "invalid patch: question can't be removed"}`,
+		},
+		{
+			Name:            "Returns 422 if patched fact is invalid",
+			ContentType:     "application/merge-patch+json",
+			ReqBody:         `{"answer": "  "}`,
+			FactID:          "1",
+			ExpectedCode:    http.StatusUnprocessableEntity,
+			ExpectedRspBody: `{"error": "invalid trivia: answer must not be blank", "details": [{"field": "answer", "message": "must not be blank"}]}`,
+		},
+		{
+			Name:            "Returns 422 for unknown members",
+			ContentType:     "application/merge-patch+json",
+			ReqBody:         `{"id": 2}`,
+			FactID:          "1",
+			ExpectedCode:    http.StatusUnprocessableEntity,
+			ExpectedRspBody: `{"error": "invalid patch: json: unknown field \"id\""}`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			var (
+				require = require.New(t)
+				assert  = assert.New(t)
+				svc     = factlist.NewService(inmem.NewFactRepository())
+				handler = factlist.NewServer(svc, log.NewNopLogger())
+			)
+
+			createdAt := time.Date(2022, 11, 20, 10, 0, 0, 0, time.UTC)
+			_, err := svc.Save(context.TODO(), pkg.Fact{Question: "what is your username?", Answer: "I don't know", CreatedAt: createdAt})
+			require.NoError(err, "could not save fact")
+
+			rec := httptest.NewRecorder()
+			url := fmt.Sprintf("/factlist/v1/fact/%s", tc.FactID)
+			req, err := http.NewRequest("PATCH", url, strings.NewReader(tc.ReqBody))
+			require.NoError(err, "could not create http request")
+			req.Header.Set("Content-Type", tc.ContentType)
+			if tc.IfMatch != "" {
+				req.Header.Set("If-Match", tc.IfMatch)
+			}
+
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(tc.ExpectedCode, rec.Result().StatusCode, "unexpected http status code")
+			assert.JSONEq(tc.ExpectedRspBody, rec.Body.String(), "unexpected http response body")
+		})
+	}
+}
+
+func TestSaveFactValidation(t *testing.T) {
+	tt := []struct {
+		Name            string
+		ReqBody         string
+		ExpectedCode    int
+		ExpectedRspBody string
+	}{
+		{
+			Name:            "Returns 200 and trims surrounding whitespace",
+			ReqBody:         `{"question": "  what's your favourite language?  ", "answer": " Go "}`,
+			ExpectedCode:    http.StatusOK,
+			ExpectedRspBody: `{"id": 2, "question": "what's your favourite language?", "answer": "Go", "createdAt":"0001-01-01T00:00:00Z"}`,
+		},
+		{
+			Name:         "Returns 422 and details for blank fields",
+			ReqBody:      `{"question": "   ", "answer": ""}`,
+			ExpectedCode: http.StatusUnprocessableEntity,
+			ExpectedRspBody: `{
+				"error": "invalid trivia: question must not be blank; answer must not be blank",
+				"details": [
+					{"field": "question", "message": "must not be blank"},
+					{"field": "answer", "message": "must not be blank"}
+				]
+			}`,
+		},
+		{
+			Name:         "Returns 422 and details for overly long answer",
+			ReqBody:      `{"question": "what's the name of current project?", "answer": "trivia trivia trivia"}`,
+			ExpectedCode: http.StatusUnprocessableEntity,
+			ExpectedRspBody: `{
+				"error": "invalid trivia: answer must be at most 10 characters",
+				"details": [{"field": "answer", "message": "must be at most 10 characters"}]
+			}`,
+		},
+		{
+			Name:         "Returns 422 and details for duplicate question",
+			ReqBody:      `{"question": "What is your GitHub username", "answer": "markhaur"}`,
+			ExpectedCode: http.StatusUnprocessableEntity,
+			ExpectedRspBody: `{
+				"error": "invalid trivia: question duplicates trivia 1",
+				"details": [{"field": "question", "message": "duplicates trivia 1"}]
+			}`,
+		},
+		{
+			Name:         "Returns 422 and details for blocked language",
+			ReqBody:      `{"question": "who wrote Darn It?", "answer": "nobody"}`,
+			ExpectedCode: http.StatusUnprocessableEntity,
+			ExpectedRspBody: `{
+				"error": "invalid trivia: question contains blocked language",
+				"details": [{"field": "question", "message": "contains blocked language"}]
+			}`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			var (
+				require = require.New(t)
+				assert  = assert.New(t)
+				policy  = pkg.FactPolicy{MaxQuestionLength: 100, MaxAnswerLength: 10, Blocklist: []string{"darn it"}}
+				svc     = factlist.NewService(inmem.NewFactRepository(), factlist.WithPolicy(policy))
+				handler = factlist.NewServer(svc, log.NewNopLogger())
+			)
+
+			_, err := svc.Save(context.TODO(), pkg.Fact{Question: "what is your github username?", Answer: "markhaur"})
+			require.NoError(err, "could not save fact")
+
+			rec := httptest.NewRecorder()
+			req, err := http.NewRequest("POST", "/factlist/v1/fact", strings.NewReader(tc.ReqBody))
+			require.NoError(err, "could not create http request")
+
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(tc.ExpectedCode, rec.Result().StatusCode, "unexpected http status code")
+			assert.JSONEq(tc.ExpectedRspBody, rec.Body.String(), "unexpected http response body")
+		})
+	}
+}
+
+func TestFactHistory(t *testing.T) {
+	var (
+		require = require.New(t)
+		assert  = assert.New(t)
+		svc     = factlist.NewService(inmem.NewFactRepository(), factlist.WithHistory(inmem.NewHistoryRepository()), factlist.WithReviewers("carol"))
+		handler = factlist.NewServer(svc, log.NewNopLogger())
+	)
+
+	rec := serve(t, handler, "POST", "/factlist/v1/fact", "alice", `{"question": "what is your github username?", "answer": "I don't know"}`, nil)
+	require.Equal(http.StatusOK, rec.Code, "could not save fact")
+	rec = serve(t, handler, "PUT", "/factlist/v1/fact/1", "bob", `{"question": "what is your github username?", "answer": "markhaur"}`, nil)
+	require.Equal(http.StatusOK, rec.Code, "could not update fact")
+	rec = serve(t, handler, "DELETE", "/factlist/v1/fact/1", "", "", nil)
+	require.Equal(http.StatusNoContent, rec.Code, "could not remove fact")
+
+	rec = serve(t, handler, "GET", "/factlist/v1/fact/1/history", "carol", "", nil)
+	assert.Equal(http.StatusOK, rec.Code, "unexpected http status code")
+
+	var history []map[string]interface{}
+	require.NoError(json.Unmarshal(rec.Body.Bytes(), &history), "could not decode history")
+	require.Len(history, 3, "expected a revision per change")
+	for i, expected := range []struct{ action, author, changes string }{
+		{"save", "alice", `[{"field": "question", "from": "", "to": "what is your github username?"}, {"field": "answer", "from": "", "to": "I don't know"}, {"field": "language", "from": "", "to": "en"}, {"field": "status", "from": "", "to": "pending_review"}]`},
+		{"update", "bob", `[{"field": "answer", "from": "I don't know", "to": "markhaur"}]`},
+		{"remove", "anonymous", `[{"field": "question", "from": "what is your github username?", "to": ""}, {"field": "answer", "from": "markhaur", "to": ""}, {"field": "language", "from": "en", "to": ""}, {"field": "status", "from": "pending_review", "to": ""}]`},
+	} {
+		changes, err := json.Marshal(history[i]["changes"])
+		require.NoError(err)
+		assert.Equal(float64(i+1), history[i]["revision"])
+		assert.Equal(expected.action, history[i]["action"])
+		assert.Equal(expected.author, history[i]["author"])
+		assert.JSONEq(expected.changes, string(changes))
+	}
+
+	tt := []struct {
+		Name           string
+		Method         string
+		URL            string
+		Author         string
+		ExpectedStatus int
+		ExpectedBody   string
+	}{
+		{
+			Name:           "Returns 404 for an unknown revision",
+			Method:         "POST",
+			URL:            "/factlist/v1/fact/1/revert/9",
+			Author:         "carol",
+			ExpectedStatus: http.StatusNotFound,
+			ExpectedBody:   `{"error": "revision not found"}`,
+		},
+		{
+			Name:           "Returns 404 for the history of an unknown fact",
+			Method:         "GET",
+			URL:            "/factlist/v1/fact/1337/history",
+			Author:         "carol",
+			ExpectedStatus: http.StatusNotFound,
+			ExpectedBody:   `{"error": "trivia not found"}`,
+		},
+		{
+			Name:           "Returns 404 for the history of a fact hidden from the user",
+			Method:         "GET",
+			URL:            "/factlist/v1/fact/1/history",
+			Author:         "alice",
+			ExpectedStatus: http.StatusNotFound,
+			ExpectedBody:   `{"error": "trivia not found"}`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			rec := serve(t, handler, tc.Method, tc.URL, tc.Author, "", nil)
+			assert.Equal(tc.ExpectedStatus, rec.Code, "unexpected http status code")
+			assert.JSONEq(tc.ExpectedBody, rec.Body.String(), "unexpected http response body")
+		})
+	}
+
+	rec = serve(t, handler, "POST", "/factlist/v1/fact/1/revert/1", "carol", "", nil)
+	assert.Equal(http.StatusOK, rec.Code, "unexpected http status code")
+	assert.JSONEq(`{"id": 1, "question": "what is your github username?", "answer": "I don't know", "createdAt":"0001-01-01T00:00:00Z"}`, rec.Body.String())
+
+	history = nil
+	rec = serve(t, handler, "GET", "/factlist/v1/fact/1/history", "carol", "", nil)
+	require.NoError(json.Unmarshal(rec.Body.Bytes(), &history), "could not decode history")
+	require.Len(history, 4, "expected revert to be recorded")
+	assert.Equal("revert", history[3]["action"])
+	assert.Equal("carol", history[3]["author"])
+}
+
+func TestFactHistoryDisabled(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		handler = factlist.NewServer(factlist.NewService(inmem.NewFactRepository()), log.NewNopLogger())
+	)
+
+	rec := serve(t, handler, "GET", "/factlist/v1/fact/1/history", "", "", nil)
+
+	assert.Equal(http.StatusNotImplemented, rec.Code, "unexpected http status code")
+	assert.JSONEq(`{"error": "fact history is not enabled"}`, rec.Body.String())
+}
+
+func TestTrash(t *testing.T) {
+	var (
+		require = require.New(t)
+		assert  = assert.New(t)
+		svc     = factlist.NewService(inmem.NewFactRepository(), factlist.WithReviewers("reviewer"))
+		handler = factlist.NewServer(svc, log.NewNopLogger())
+	)
+
+	_, err := svc.Save(context.TODO(), pkg.Fact{Question: "what is your github username?", Answer: "markhaur", Status: pkg.StatusPublished})
+	require.NoError(err, "could not save fact")
+
+	rec := serve(t, handler, "DELETE", "/factlist/v1/fact/1", "reviewer", "", nil)
+	require.Equal(http.StatusNoContent, rec.Code, "could not remove fact")
+
+	rec = serve(t, handler, "GET", "/factlist/v1/trash", "reviewer", "", nil)
+	assert.Equal(http.StatusOK, rec.Code, "unexpected http status code")
+	var trash []map[string]interface{}
+	require.NoError(json.Unmarshal(rec.Body.Bytes(), &trash), "could not decode trash")
+	require.Len(trash, 1, "expected removed fact in trash")
+	assert.Equal(float64(1), trash[0]["id"])
+	assert.NotEqual("0001-01-01T00:00:00Z", trash[0]["deletedAt"], "expected deletion time")
+
+	fact := `{"id": 1, "question": "what is your github username?", "answer": "markhaur", "createdAt":"0001-01-01T00:00:00Z"}`
+	tt := []struct {
+		Name           string
+		Method         string
+		URL            string
+		User           string
+		ExpectedStatus int
+		ExpectedBody   string
+	}{
+		{
+			Name:           "Hides removed fact from get",
+			Method:         "GET",
+			URL:            "/factlist/v1/fact/1",
+			ExpectedStatus: http.StatusNotFound,
+			ExpectedBody:   `{"error": "trivia not found"}`,
+		},
+		{
+			Name:           "Hides removed fact from list",
+			Method:         "GET",
+			URL:            "/factlist/v1/fact",
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody:   `[]`,
+		},
+		{
+			Name:           "Returns 401 for an anonymous restore of a published fact",
+			Method:         "POST",
+			URL:            "/factlist/v1/trash/1/restore",
+			ExpectedStatus: http.StatusUnauthorized,
+			ExpectedBody:   `{"error": "missing, invalid or expired session token"}`,
+		},
+		{
+			Name:           "Returns 403 for a restore of a published fact by a non-reviewer",
+			Method:         "POST",
+			URL:            "/factlist/v1/trash/1/restore",
+			User:           "player",
+			ExpectedStatus: http.StatusForbidden,
+			ExpectedBody:   `{"error": "only reviewers may change published trivia"}`,
+		},
+		{
+			Name:           "Restores removed fact",
+			Method:         "POST",
+			URL:            "/factlist/v1/trash/1/restore",
+			User:           "reviewer",
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody:   fact,
+		},
+		{
+			Name:           "Returns 404 for a fact that left the trash",
+			Method:         "POST",
+			URL:            "/factlist/v1/trash/1/restore",
+			User:           "reviewer",
+			ExpectedStatus: http.StatusNotFound,
+			ExpectedBody:   `{"error": "trivia not found"}`,
+		},
+		{
+			Name:           "Shows restored fact",
+			Method:         "GET",
+			URL:            "/factlist/v1/fact/1",
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody:   fact,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			rec := serve(t, handler, tc.Method, tc.URL, tc.User, "", nil)
+			assert.Equal(tc.ExpectedStatus, rec.Code, "unexpected http status code")
+			assert.JSONEq(tc.ExpectedBody, rec.Body.String(), "unexpected http response body")
+		})
+	}
+}
+
+func TestPublishedFactsNeedReviewer(t *testing.T) {
+	var (
+		require = require.New(t)
+		svc     = factlist.NewService(inmem.NewFactRepository(),
+			factlist.WithHistory(inmem.NewHistoryRepository()),
+			factlist.WithDuplicateDetection(pkg.DuplicateDetector{Threshold: 0.6}, pkg.DuplicatesWarn),
+			factlist.WithReviewers("reviewer"),
+		)
+		handler = factlist.NewServer(svc, log.NewNopLogger())
+	)
+
+	_, err := svc.Save(context.TODO(), pkg.Fact{Question: "what is the capital of France?", Answer: "Paris", Status: pkg.StatusPublished})
+	require.NoError(err, "could not save fact")
+	_, err = svc.Save(context.TODO(), pkg.Fact{Question: "what's France's capital city?", Answer: "Paris"})
+	require.NoError(err, "could not save fact")
+
+	update := `{"question": "what is the capital of France?", "answer": "Paris, France"}`
+	tt := []struct {
+		Name           string
+		Method         string
+		URL            string
+		User           string
+		Body           string
+		Headers        map[string]string
+		ExpectedStatus int
+	}{
+		{
+			Name:           "Returns 401 for an anonymous update of a published fact",
+			Method:         "PUT",
+			URL:            "/factlist/v1/fact/1",
+			Body:           update,
+			ExpectedStatus: http.StatusUnauthorized,
+		},
+		{
+			Name:           "Returns 403 for an update of a published fact by a non-reviewer",
+			Method:         "PUT",
+			URL:            "/factlist/v1/fact/1",
+			User:           "player",
+			Body:           update,
+			ExpectedStatus: http.StatusForbidden,
+		},
+		{
+			Name:           "Returns 403 for a patch of a published fact by a non-reviewer",
+			Method:         "PATCH",
+			URL:            "/factlist/v1/fact/1",
+			User:           "player",
+			Body:           `{"answer": "Paris, France"}`,
+			Headers:        map[string]string{"Content-Type": "application/merge-patch+json"},
+			ExpectedStatus: http.StatusForbidden,
+		},
+		{
+			Name:           "Returns 403 for a translation of a published fact by a non-reviewer",
+			Method:         "PUT",
+			URL:            "/factlist/v1/fact/1/translations/fr",
+			User:           "player",
+			Body:           `{"question": "quelle est la capitale de la France ?", "answer": "Paris"}`,
+			ExpectedStatus: http.StatusForbidden,
+		},
+		{
+			Name:           "Returns 403 for a revert of a published fact by a non-reviewer",
+			Method:         "POST",
+			URL:            "/factlist/v1/fact/1/revert/1",
+			User:           "player",
+			ExpectedStatus: http.StatusForbidden,
+		},
+		{
+			Name:           "Returns 403 for a merge into a published fact by a non-reviewer",
+			Method:         "POST",
+			URL:            "/factlist/v1/fact/1/merge",
+			User:           "player",
+			Body:           `{"duplicates": [2]}`,
+			ExpectedStatus: http.StatusForbidden,
+		},
+		{
+			Name:           "Returns 403 for a removal of a published fact by a non-reviewer",
+			Method:         "DELETE",
+			URL:            "/factlist/v1/fact/1",
+			User:           "player",
+			ExpectedStatus: http.StatusForbidden,
+		},
+		{
+			Name:           "Returns 200 for an update of a fact waiting for review by a non-reviewer",
+			Method:         "PUT",
+			URL:            "/factlist/v1/fact/2",
+			User:           "player",
+			Body:           `{"question": "what's France's capital city?", "answer": "Paris, France"}`,
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:           "Returns 200 for an update of a published fact by a reviewer",
+			Method:         "PUT",
+			URL:            "/factlist/v1/fact/1",
+			User:           "reviewer",
+			Body:           update,
+			ExpectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			rec := serve(t, handler, tc.Method, tc.URL, tc.User, tc.Body, tc.Headers)
+			assert.Equal(t, tc.ExpectedStatus, rec.Code, "unexpected http status code")
+		})
+	}
+}
+
+func TestModerationWorkflow(t *testing.T) {
+	var (
+		require = require.New(t)
+		assert  = assert.New(t)
+		repo    = inmem.NewFactRepository()
+		opts    = []factlist.Option{factlist.WithHistory(inmem.NewHistoryRepository()), factlist.WithReviewers("reviewer")}
+		svc     = factlist.NewService(repo, opts...)
+		handler = factlist.NewServer(svc, log.NewNopLogger(), factlist.WithModerationService(factlist.NewModerationService(repo, opts...)))
+	)
+
+	rec := serve(t, handler, "GET", "/factlist/v1/fact/random", "", "", nil)
+	assert.Equal(http.StatusNotFound, rec.Code, "expected no random fact before anything is published")
+
+	rec = serve(t, handler, "POST", "/factlist/v1/submission", "", `{"question": "what is your github username?", "answer": "markhaur"}`, nil)
+	require.Equal(http.StatusAccepted, rec.Code, "could not submit fact")
+	assert.JSONEq(`{"id": 1, "question": "what is your github username?", "answer": "markhaur", "status": "pending_review", "createdAt":"0001-01-01T00:00:00Z"}`, rec.Body.String())
+
+	rec = serve(t, handler, "GET", "/factlist/v1/fact", "", "", nil)
+	assert.JSONEq(`[]`, rec.Body.String(), "expected submitted fact to be hidden from the list")
+	rec = serve(t, handler, "GET", "/factlist/v1/fact?status=pending_review", "", "", nil)
+	assert.Equal(http.StatusUnauthorized, rec.Code, "expected anonymous callers to be denied facts waiting for review")
+	rec = serve(t, handler, "GET", "/factlist/v1/fact?status=all", "player", "", nil)
+	assert.Equal(http.StatusForbidden, rec.Code, "expected non-reviewers to be denied facts waiting for review")
+	rec = serve(t, handler, "GET", "/factlist/v1/fact?status=pending_review", "reviewer", "", nil)
+	assert.JSONEq(`[{"id": 1, "question": "what is your github username?", "answer": "markhaur", "createdAt":"0001-01-01T00:00:00Z"}]`, rec.Body.String())
+	rec = serve(t, handler, "GET", "/factlist/v1/fact?status=unknown", "", "", nil)
+	assert.Equal(http.StatusBadRequest, rec.Code, "expected unknown status filter to be rejected")
+
+	rec = serve(t, handler, "GET", "/factlist/v1/review", "", "", nil)
+	assert.Equal(http.StatusUnauthorized, rec.Code, "expected anonymous callers to be denied the review queue")
+	rec = serve(t, handler, "GET", "/factlist/v1/review", "player", "", nil)
+	assert.Equal(http.StatusForbidden, rec.Code, "expected non-reviewers to be denied the review queue")
+	rec = serve(t, handler, "GET", "/factlist/v1/review", "reviewer", "", nil)
+	assert.Equal(http.StatusOK, rec.Code, "unexpected http status code")
+	assert.JSONEq(`[{"id": 1, "question": "what is your github username?", "answer": "markhaur", "status": "pending_review", "createdAt":"0001-01-01T00:00:00Z", "comments": []}]`, rec.Body.String())
+
+	tt := []struct {
+		Name           string
+		User           string
+		Body           string
+		Headers        map[string]string
+		ExpectedStatus int
+	}{
+		{
+			Name:           "Returns 401 for anonymous callers",
+			Body:           `{"status": "published"}`,
+			ExpectedStatus: http.StatusUnauthorized,
+		},
+		{
+			Name:           "Returns 403 for users who aren't reviewers",
+			User:           "player",
+			Body:           `{"status": "published"}`,
+			ExpectedStatus: http.StatusForbidden,
+		},
+		{
+			Name:           "Returns 400 for an unknown status",
+			User:           "reviewer",
+			Body:           `{"status": "archived"}`,
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:           "Returns 409 for a transition the workflow doesn't allow",
+			User:           "reviewer",
+			Body:           `{"status": "pending_review"}`,
+			ExpectedStatus: http.StatusConflict,
+		},
+		{
+			Name:           "Returns 412 if the fact has been modified",
+			User:           "reviewer",
+			Body:           `{"status": "published"}`,
+			Headers:        map[string]string{"If-Match": `"7"`},
+			ExpectedStatus: http.StatusPreconditionFailed,
+		},
+		{
+			Name:           "Returns 200 and publishes the fact",
+			User:           "reviewer",
+			Body:           `{"status": "published", "comment": "looks good"}`,
+			Headers:        map[string]string{"If-Match": `"1"`},
+			ExpectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			rec := serve(t, handler, "POST", "/factlist/v1/fact/1/status", tc.User, tc.Body, tc.Headers)
+			assert.Equal(tc.ExpectedStatus, rec.Code, "unexpected http status code")
+		})
+	}
+
+	fact, err := svc.Get(context.TODO(), 1)
+	require.NoError(err, "could not get fact")
+	assert.Equal(pkg.StatusPublished, fact.Status)
+	require.Len(fact.Comments, 1, "expected review comment")
+	assert.Equal(pkg.ReviewComment{
+		Author:    "reviewer",
+		Body:      "looks good",
+		From:      pkg.StatusPendingReview,
+		To:        pkg.StatusPublished,
+		CreatedAt: fact.Comments[0].CreatedAt,
+	}, fact.Comments[0])
+
+	rec = serve(t, handler, "GET", "/factlist/v1/review", "reviewer", "", nil)
+	assert.JSONEq(`[]`, rec.Body.String(), "expected review queue to be empty")
+	rec = serve(t, handler, "GET", "/factlist/v1/fact", "", "", nil)
+	assert.JSONEq(`[{"id": 1, "question": "what is your github username?", "answer": "markhaur", "createdAt":"0001-01-01T00:00:00Z"}]`, rec.Body.String())
+	rec = serve(t, handler, "GET", "/factlist/v1/fact/random", "", "", nil)
+	assert.Equal(http.StatusOK, rec.Code, "expected published fact to be served at random")
+
+	revisions, err := svc.History(context.TODO(), 1)
+	require.NoError(err, "could not list revisions")
+	require.Len(revisions, 2)
+	assert.Equal(pkg.ActionReview, revisions[1].Action)
+	assert.Equal([]pkg.Change{{Field: "status", From: "pending_review", To: "published"}}, revisions[1].Changes)
+}
+
+func TestSearchFacts(t *testing.T) {
+	var (
+		require    = require.New(t)
+		assert     = assert.New(t)
+		repo       = inmem.NewFactRepository()
+		index      = inmem.NewSearchIndex()
+		svc        = factlist.IndexingMiddleware(index, pkg.SystemClock, log.NewNopLogger())(factlist.NewService(repo, factlist.WithReviewers("reviewer")))
+		moderation = factlist.ModerationIndexingMiddleware(index, log.NewNopLogger())(factlist.NewModerationService(repo, factlist.WithReviewers("reviewer")))
+		handler    = factlist.NewServer(svc, log.NewNopLogger())
+	)
+
+	for _, fact := range []pkg.Fact{
+		{Question: "which programming language was created at Google?", Answer: "Go", Status: pkg.StatusPublished},
+		{Question: "what is the most spoken language?", Answer: "English, a language spoken by many", Status: pkg.StatusPublished},
+		{Question: "what's the name of current project?", Answer: "trivia", Status: pkg.StatusPublished},
+	} {
+		_, err := svc.Save(context.TODO(), fact)
+		require.NoError(err, "could not save fact")
+	}
+	_, err := moderation.Submit(context.TODO(), pkg.Fact{Question: "which language has the most words?", Answer: "English"})
+	require.NoError(err, "could not submit fact")
+
+	search := func(url string) []map[string]interface{} {
+		rec := serve(t, handler, "GET", url, "", "", nil)
+		require.Equal(http.StatusOK, rec.Code, "unexpected http status code")
+		var results []map[string]interface{}
+		require.NoError(json.Unmarshal(rec.Body.Bytes(), &results), "could not decode results")
+		return results
+	}
+	ids := func(results []map[string]interface{}) []float64 {
+		ids := make([]float64, 0, len(results))
+		for _, r := range results {
+			ids = append(ids, r["id"].(float64))
+		}
+		return ids
+	}
+
+	results := search("/factlist/v1/fact/search?q=languages")
+	assert.Equal([]float64{2, 1}, ids(results), "expected facts ranked by relevance, unpublished ones hidden")
+	assert.Equal(map[string]interface{}{
+		"question": "what is the most spoken <mark>language</mark>?",
+		"answer":   "English, a <mark>language</mark> spoken by many",
+	}, results[0]["highlighted"])
+
+	assert.Equal([]float64{2}, ids(search("/factlist/v1/fact/search?q=languages&limit=1")))
+	assert.Equal([]float64{3}, ids(search("/factlist/v1/fact/search?q=Trivia")))
+	assert.Empty(search("/factlist/v1/fact/search?q=the"), "expected stop words to match nothing")
+
+	_, _, err = svc.Update(authenticatedAs(context.TODO(), "reviewer"), pkg.Fact{ID: 3, Question: "what's the name of current project?", Answer: "quiz"})
+	require.NoError(err, "could not update fact")
+	assert.Empty(search("/factlist/v1/fact/search?q=trivia"), "expected index to follow updates")
+	assert.Equal([]float64{3}, ids(search("/factlist/v1/fact/search?q=quiz")))
+
+	require.NoError(svc.Remove(authenticatedAs(context.TODO(), "reviewer"), 2, 0), "could not remove fact")
+	assert.Equal([]float64{1}, ids(search("/factlist/v1/fact/search?q=language")), "expected removed fact to be dropped")
+
+	_, err = moderation.Transition(authenticatedAs(context.TODO(), "reviewer"), 4, pkg.StatusPublished, "", 0)
+	require.NoError(err, "could not publish fact")
+	assert.Equal([]float64{4}, ids(search("/factlist/v1/fact/search?q=words")), "expected published fact to be indexed")
+
+	_, err = svc.Save(context.TODO(), pkg.Fact{Question: "which language do most languages borrow words from?", Answer: "Latin", Status: pkg.StatusPublished, PublishAt: time.Now().Add(time.Hour)})
+	require.NoError(err, "could not save fact")
+	assert.Equal([]float64{1}, ids(search("/factlist/v1/fact/search?q=languages&limit=1")), "expected a fact that isn't live yet not to take the place of a live one")
+
+	tt := []struct {
+		Name           string
+		Handler        http.Handler
+		URL            string
+		ExpectedStatus int
+		ExpectedBody   string
+	}{
+		{
+			Name:           "Returns 400 for a missing query",
+			Handler:        handler,
+			URL:            "/factlist/v1/fact/search",
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedBody:   `{"error": "search query must not be blank"}`,
+		},
+		{
+			Name:           "Returns 400 for a zero limit",
+			Handler:        handler,
+			URL:            "/factlist/v1/fact/search?q=go&limit=0",
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedBody:   `{"error": "limit must be a number between 1 and 100"}`,
+		},
+		{
+			Name:           "Returns 400 for a non-numeric limit",
+			Handler:        handler,
+			URL:            "/factlist/v1/fact/search?q=go&limit=ten",
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedBody:   `{"error": "limit must be a number between 1 and 100"}`,
+		},
+		{
+			Name:           "Returns 400 for a limit above the maximum",
+			Handler:        handler,
+			URL:            "/factlist/v1/fact/search?q=go&limit=1000",
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedBody:   `{"error": "limit must be a number between 1 and 100"}`,
+		},
+		{
+			Name:           "Returns 501 without a search index",
+			Handler:        factlist.NewServer(factlist.NewService(inmem.NewFactRepository()), log.NewNopLogger()),
+			URL:            "/factlist/v1/fact/search?q=go",
+			ExpectedStatus: http.StatusNotImplemented,
+			ExpectedBody:   `{"error": "fact search is not enabled"}`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			rec := serve(t, tc.Handler, "GET", tc.URL, "", "", nil)
+			assert.Equal(tc.ExpectedStatus, rec.Code, "unexpected http status code")
+			assert.JSONEq(tc.ExpectedBody, rec.Body.String(), "unexpected http response body")
+		})
+	}
+}
+
+func TestMergeDuplicates(t *testing.T) {
+	var (
+		require = require.New(t)
+		assert  = assert.New(t)
+		svc     = factlist.NewService(inmem.NewFactRepository(),
+			factlist.WithHistory(inmem.NewHistoryRepository()),
+			factlist.WithDuplicateDetection(pkg.DuplicateDetector{Threshold: 0.6}, pkg.DuplicatesWarn),
+			factlist.WithReviewers("editor"),
+		)
+		handler = factlist.NewServer(svc, log.NewNopLogger())
+	)
+
+	for _, question := range []string{
+		"What is the capital of France?",
+		"Who wrote Hamlet?",
+		"What's France's capital city?",
+		"Hamlet: who wrote it?",
+	} {
+		rec := serve(t, handler, "POST", "/factlist/v1/fact", "editor", fmt.Sprintf(`{"question": %q, "answer": "answer"}`, question), nil)
+		require.Equal(http.StatusOK, rec.Code, "could not save fact")
+	}
+	rec := serve(t, handler, "POST", "/factlist/v1/fact", "editor", `{"question": "Capital of France?", "answer": "Paris"}`, nil)
+	require.Equal(http.StatusOK, rec.Code, "could not save fact")
+	var saved map[string]interface{}
+	require.NoError(json.Unmarshal(rec.Body.Bytes(), &saved), "could not decode fact")
+	assert.Equal([]interface{}{float64(1), float64(3)}, saved["possibleDuplicates"], "expected a warning about duplicates")
+
+	rec = serve(t, handler, "GET", "/factlist/v1/duplicates", "editor", "", nil)
+	assert.Equal(http.StatusOK, rec.Code, "unexpected http status code")
+	var clusters []struct {
+		Similarity float64
+		Facts      []struct{ ID int64 }
+	}
+	require.NoError(json.Unmarshal(rec.Body.Bytes(), &clusters), "could not decode clusters")
+	require.Len(clusters, 2, "expected a cluster per question")
+	var ids [][]int64
+	for _, c := range clusters {
+		assert.GreaterOrEqual(c.Similarity, 0.6)
+		var cluster []int64
+		for _, f := range c.Facts {
+			cluster = append(cluster, f.ID)
+		}
+		ids = append(ids, cluster)
+	}
+	assert.ElementsMatch([][]int64{{1, 3, 5}, {2, 4}}, ids)
+
+	rec = serve(t, handler, "GET", "/factlist/v1/duplicates", "player", "", nil)
+	assert.JSONEq(`[]`, rec.Body.String(), "expected facts pending review to be hidden from players")
+	rec = serve(t, handler, "GET", "/factlist/v1/fact/5/duplicates", "player", "", nil)
+	assert.JSONEq(`[]`, rec.Body.String(), "expected facts pending review to be hidden from players")
+
+	tt := []struct {
+		Name           string
+		URL            string
+		Body           string
+		Headers        map[string]string
+		ExpectedStatus int
+	}{
+		{
+			Name:           "Returns 422 when merging a fact into itself",
+			URL:            "/factlist/v1/fact/1/merge",
+			Body:           `{"duplicates": [1, 3]}`,
+			ExpectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			Name:           "Returns 404 when merging a missing fact",
+			URL:            "/factlist/v1/fact/1/merge",
+			Body:           `{"duplicates": [42]}`,
+			ExpectedStatus: http.StatusNotFound,
+		},
+		{
+			Name:           "Returns 412 if the kept fact has been modified",
+			URL:            "/factlist/v1/fact/1/merge",
+			Body:           `{"duplicates": [3, 5]}`,
+			Headers:        map[string]string{"If-Match": `"2"`},
+			ExpectedStatus: http.StatusPreconditionFailed,
+		},
+		{
+			Name:           "Returns 200 and keeps the fact",
+			URL:            "/factlist/v1/fact/1/merge",
+			Body:           `{"duplicates": [3, 5]}`,
+			Headers:        map[string]string{"If-Match": `"1"`},
+			ExpectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			rec := serve(t, handler, "POST", tc.URL, "editor", tc.Body, tc.Headers)
+			assert.Equal(tc.ExpectedStatus, rec.Code, "unexpected http status code")
+		})
+	}
+
+	rec = serve(t, handler, "GET", "/factlist/v1/fact/1/duplicates", "editor", "", nil)
+	assert.JSONEq(`[]`, rec.Body.String(), "expected merged duplicates to be gone")
+	rec = serve(t, handler, "GET", "/factlist/v1/trash", "editor", "", nil)
+	var trash []map[string]interface{}
+	require.NoError(json.Unmarshal(rec.Body.Bytes(), &trash), "could not decode trash")
+	assert.Len(trash, 2, "expected merged duplicates in the trash")
+
+	revisions, err := svc.History(authenticatedAs(context.TODO(), "editor"), 3)
+	require.NoError(err, "could not list revisions")
+	merge := revisions[len(revisions)-1]
+	assert.Equal(pkg.ActionMerge, merge.Action)
+	assert.Equal("editor", merge.Author)
+	assert.Equal([]pkg.Change{{Field: "mergedInto", To: "1"}}, merge.Changes)
+
+	revisions, err = svc.History(authenticatedAs(context.TODO(), "editor"), 1)
+	require.NoError(err, "could not list revisions")
+	assert.Equal([]pkg.Change{{Field: "merged", To: "3, 5"}}, revisions[len(revisions)-1].Changes)
+}
+
+func TestTranslations(t *testing.T) {
+	var (
+		require = require.New(t)
+		assert  = assert.New(t)
+		svc     = factlist.NewService(inmem.NewFactRepository(), factlist.WithHistory(inmem.NewHistoryRepository()), factlist.WithReviewers("reviewer"))
+		handler = factlist.NewServer(svc, log.NewNopLogger())
+	)
+
+	_, err := svc.Save(context.TODO(), pkg.Fact{Question: "what is the capital of Germany?", Answer: "Berlin", Status: pkg.StatusPublished})
+	require.NoError(err, "could not save fact")
+	_, err = svc.Save(context.TODO(), pkg.Fact{Question: "Wie heißt die Hauptstadt von Österreich?", Answer: "Wien", Language: "DE", Status: pkg.StatusPublished})
+	require.NoError(err, "could not save fact")
+
+	rec := serve(t, handler, "GET", "/factlist/v1/translations/de/missing", "", "", nil)
+	assert.JSONEq(`[{"id": 1, "language": "en", "question": "what is the capital of Germany?", "answer": "Berlin", "createdAt":"0001-01-01T00:00:00Z"}]`, rec.Body.String())
+
+	tt := []struct {
+		Name           string
+		URL            string
+		Body           string
+		Headers        map[string]string
+		ExpectedStatus int
+		ExpectedBody   string
+	}{
+		{
+			Name:           "Returns 400 for an invalid language tag",
+			URL:            "/factlist/v1/fact/1/translations/english",
+			Body:           `{"question": "Was ist die Hauptstadt von Deutschland?", "answer": "Berlin"}`,
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedBody:   `{"error": "invalid language tag"}`,
+		},
+		{
+			Name:           "Returns 422 when translating to the language of the fact",
+			URL:            "/factlist/v1/fact/1/translations/en",
+			Body:           `{"question": "what's the capital of Germany?", "answer": "Berlin"}`,
+			ExpectedStatus: http.StatusUnprocessableEntity,
+			ExpectedBody:   `{"error": "trivia is already written in that language"}`,
+		},
+		{
+			Name:           "Returns 422 for a blank translation",
+			URL:            "/factlist/v1/fact/1/translations/de",
+			Body:           `{"question": " ", "answer": "Berlin"}`,
+			ExpectedStatus: http.StatusUnprocessableEntity,
+			ExpectedBody:   `{"error": "invalid trivia: question must not be blank", "details": [{"field": "question", "message": "must not be blank"}]}`,
+		},
+		{
+			Name:           "Returns 201 when adding a translation",
+			URL:            "/factlist/v1/fact/1/translations/de",
+			Body:           `{"question": "Was ist die Hauptstadt von Deutschland? ", "answer": "Berlin"}`,
+			Headers:        map[string]string{"If-Match": `"1"`},
+			ExpectedStatus: http.StatusCreated,
+			ExpectedBody:   `{"id": 1, "language": "de", "question": "Was ist die Hauptstadt von Deutschland?", "answer": "Berlin"}`,
+		},
+		{
+			Name:           "Returns 201 when adding another translation",
+			URL:            "/factlist/v1/fact/1/translations/ur",
+			Body:           `{"question": "جرمنی کا دارالحکومت کیا ہے؟", "answer": "برلن"}`,
+			ExpectedStatus: http.StatusCreated,
+			ExpectedBody:   `{"id": 1, "language": "ur", "question": "جرمنی کا دارالحکومت کیا ہے؟", "answer": "برلن"}`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			rec := serve(t, handler, "PUT", tc.URL, "reviewer", tc.Body, tc.Headers)
+			assert.Equal(tc.ExpectedStatus, rec.Code, "unexpected http status code")
+			assert.JSONEq(tc.ExpectedBody, rec.Body.String(), "unexpected http response body")
+		})
+	}
+
+	rec = serve(t, handler, "PUT", "/factlist/v1/fact/1/translations/de", "reviewer", `{"question": "Wie heißt die Hauptstadt von Deutschland?", "answer": "Berlin"}`, map[string]string{"If-Match": `"1"`})
+	assert.Equal(http.StatusPreconditionFailed, rec.Code, "expected stale version to be rejected")
+	rec = serve(t, handler, "PUT", "/factlist/v1/fact/1/translations/de", "reviewer", `{"question": "Wie heißt die Hauptstadt von Deutschland?", "answer": "Berlin"}`, nil)
+	assert.Equal(http.StatusOK, rec.Code, "expected translation to be replaced")
+
+	negotiation := []struct {
+		Name             string
+		URL              string
+		AcceptLanguage   string
+		ExpectedLanguage string
+		ExpectedQuestion string
+	}{
+		{
+			Name:             "Serves the original without preferences",
+			URL:              "/factlist/v1/fact/1",
+			ExpectedLanguage: "en",
+			ExpectedQuestion: "what is the capital of Germany?",
+		},
+		{
+			Name:             "Serves the most preferred available language",
+			URL:              "/factlist/v1/fact/1",
+			AcceptLanguage:   "fr;q=0.9, ur;q=0.8, de;q=0.5",
+			ExpectedLanguage: "ur",
+			ExpectedQuestion: "جرمنی کا دارالحکومت کیا ہے؟",
+		},
+		{
+			Name:             "Falls back from a regional variant",
+			URL:              "/factlist/v1/fact/1",
+			AcceptLanguage:   "de-AT",
+			ExpectedLanguage: "de",
+			ExpectedQuestion: "Wie heißt die Hauptstadt von Deutschland?",
+		},
+		{
+			Name:             "Prefers the lang query parameter",
+			URL:              "/factlist/v1/fact/1?lang=de",
+			AcceptLanguage:   "ur",
+			ExpectedLanguage: "de",
+			ExpectedQuestion: "Wie heißt die Hauptstadt von Deutschland?",
+		},
+		{
+			Name:             "Falls back to the original if no language is available",
+			URL:              "/factlist/v1/fact/2",
+			AcceptLanguage:   "en-GB, ur",
+			ExpectedLanguage: "de",
+			ExpectedQuestion: "Wie heißt die Hauptstadt von Österreich?",
+		},
+	}
+
+	for _, tc := range negotiation {
+		t.Run(tc.Name, func(t *testing.T) {
+			rec := serve(t, handler, "GET", tc.URL, "", "", map[string]string{"Accept-Language": tc.AcceptLanguage})
+			require.Equal(http.StatusOK, rec.Code, "unexpected http status code")
+			assert.Equal(tc.ExpectedLanguage, rec.Header().Get("Content-Language"))
+			assert.Equal("Accept-Language", rec.Header().Get("Vary"))
+			var fact map[string]interface{}
+			require.NoError(json.Unmarshal(rec.Body.Bytes(), &fact), "could not decode fact")
+			assert.Equal(tc.ExpectedQuestion, fact["question"])
+		})
+	}
+
+	rec = serve(t, handler, "GET", "/factlist/v1/fact?lang=de", "", "", nil)
+	assert.JSONEq(`[
+		{"id": 1, "question": "Wie heißt die Hauptstadt von Deutschland?", "answer": "Berlin", "createdAt":"0001-01-01T00:00:00Z"},
+		{"id": 2, "question": "Wie heißt die Hauptstadt von Österreich?", "answer": "Wien", "createdAt":"0001-01-01T00:00:00Z"}
+	]`, rec.Body.String())
+	assert.Equal("de", rec.Header().Get("Content-Language"))
+	rec = serve(t, handler, "GET", "/factlist/v1/fact?lang=--", "", "", nil)
+	assert.Equal(http.StatusBadRequest, rec.Code, "expected invalid lang parameter to be rejected")
+
+	rec = serve(t, handler, "GET", "/factlist/v1/translations/de/missing", "", "", nil)
+	assert.JSONEq(`[]`, rec.Body.String())
+	rec = serve(t, handler, "GET", "/factlist/v1/translations/ur/missing", "", "", nil)
+	assert.Contains(rec.Body.String(), `"id":2`)
+
+	revisions, err := svc.History(context.TODO(), 1)
+	require.NoError(err, "could not list revisions")
+	assert.Equal(pkg.ActionTranslate, revisions[len(revisions)-1].Action)
+	assert.Equal([]pkg.Change{{
+		Field: "translations.de.question",
+		From:  "Was ist die Hauptstadt von Deutschland?",
+		To:    "Wie heißt die Hauptstadt von Deutschland?",
+	}}, revisions[len(revisions)-1].Changes)
+}
+
+func TestAttachments(t *testing.T) {
+	var (
+		require = require.New(t)
+		assert  = assert.New(t)
+		dir     = t.TempDir()
+	)
+
+	blobs, err := filesystem.NewBlobStore(dir)
+	require.NoError(err, "could not create blob store")
+	var (
+		repo    = inmem.NewFactRepository()
+		opts    = []factlist.Option{factlist.WithAttachments(blobs, pkg.AttachmentPolicy{MaxSize: 64, ContentTypes: []string{"image/png", "audio/mpeg"}}), factlist.WithReviewers("reviewer")}
+		svc     = factlist.NewService(repo, opts...)
+		handler = factlist.NewServer(svc, log.NewNopLogger(), factlist.WithAttachmentService(factlist.NewAttachmentService(repo, opts...)))
+	)
+
+	upload := func(url, user, field, filename string, content []byte, headers map[string]string) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		require.NoError(mw.WriteField("caption", "ignored"))
+		fw, err := mw.CreateFormFile(field, filename)
+		require.NoError(err, "could not create form file")
+		_, err = fw.Write(content)
+		require.NoError(err, "could not write form file")
+		require.NoError(mw.Close())
+
+		if headers == nil {
+			headers = map[string]string{}
+		}
+		headers["Content-Type"] = mw.FormDataContentType()
+		return serve(t, handler, "POST", url, user, body.String(), headers)
+	}
+	blobCount := func() int {
+		var n int
+		filepath.Walk(dir, func(_ string, info os.FileInfo, err error) error {
+			if err == nil && !info.IsDir() {
+				n++
+			}
+			return nil
+		})
+		return n
+	}
+
+	_, err = svc.Save(context.TODO(), pkg.Fact{Question: "which animal is in this picture?", Answer: "a cat", Status: pkg.StatusPublished})
+	require.NoError(err, "could not save fact")
+
+	png := append([]byte("\x89PNG\r\n\x1a\n"), []byte("not really an image")...)
+
+	tt := []struct {
+		Name           string
+		URL            string
+		User           string
+		Field          string
+		Content        []byte
+		Headers        map[string]string
+		ExpectedStatus int
+	}{
+		{
+			Name:           "Returns 401 for an anonymous user",
+			URL:            "/factlist/v1/fact/1/attachments",
+			Field:          "file",
+			Content:        png,
+			ExpectedStatus: http.StatusUnauthorized,
+		},
+		{
+			Name:           "Returns 403 for a player attaching to a published fact",
+			URL:            "/factlist/v1/fact/1/attachments",
+			User:           "player",
+			Field:          "file",
+			Content:        png,
+			ExpectedStatus: http.StatusForbidden,
+		},
+		{
+			Name:           "Returns 404 for a missing fact",
+			URL:            "/factlist/v1/fact/42/attachments",
+			User:           "reviewer",
+			Field:          "file",
+			Content:        png,
+			ExpectedStatus: http.StatusNotFound,
+		},
+		{
+			Name:           "Returns 400 without a file part",
+			URL:            "/factlist/v1/fact/1/attachments",
+			User:           "reviewer",
+			Field:          "upload",
+			Content:        png,
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:           "Returns 415 for content that isn't an image or audio",
+			URL:            "/factlist/v1/fact/1/attachments",
+			User:           "reviewer",
+			Field:          "file",
+			Content:        []byte("<html><script>alert(1)</script></html>"),
+			ExpectedStatus: http.StatusUnsupportedMediaType,
+		},
+		{
+			Name:           "Returns 413 for content larger than allowed",
+			URL:            "/factlist/v1/fact/1/attachments",
+			User:           "reviewer",
+			Field:          "file",
+			Content:        append(png, bytes.Repeat([]byte{0}, 64)...),
+			ExpectedStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			Name:           "Returns 412 if the fact has been modified",
+			URL:            "/factlist/v1/fact/1/attachments",
+			User:           "reviewer",
+			Field:          "file",
+			Content:        png,
+			Headers:        map[string]string{"If-Match": `"2"`},
+			ExpectedStatus: http.StatusPreconditionFailed,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			rec := upload(tc.URL, tc.User, tc.Field, "cat.png", tc.Content, tc.Headers)
+			assert.Equal(tc.ExpectedStatus, rec.Code, "unexpected http status code")
+		})
+	}
+	assert.Zero(blobCount(), "expected rejected uploads to leave no blob behind")
+
+	rec := upload("/factlist/v1/fact/1/attachments", "reviewer", "file", "../../cat.png", png, map[string]string{"If-Match": `"1"`})
+	require.Equal(http.StatusCreated, rec.Code, "could not upload attachment")
+	assert.Equal(`"2"`, rec.Header().Get("ETag"))
+	var attachment map[string]interface{}
+	require.NoError(json.Unmarshal(rec.Body.Bytes(), &attachment), "could not decode attachment")
+	assert.Equal("cat.png", attachment["filename"])
+	assert.Equal("image/png", attachment["contentType"])
+	assert.Equal(float64(len(png)), attachment["size"])
+	url := attachment["url"].(string)
+	assert.Equal(url, rec.Header().Get("Location"))
+	assert.Equal(1, blobCount())
+
+	rec = serve(t, handler, "GET", "/factlist/v1/fact/1/attachments", "", "", nil)
+	assert.Contains(rec.Body.String(), url, "expected attachment to be listed")
+
+	rec = serve(t, handler, "GET", url, "", "", nil)
+	assert.Equal(http.StatusOK, rec.Code, "unexpected http status code")
+	assert.Equal(png, rec.Body.Bytes())
+	assert.Equal("image/png", rec.Header().Get("Content-Type"))
+	assert.Equal("public, max-age=31536000, immutable", rec.Header().Get("Cache-Control"))
+	assert.Equal("nosniff", rec.Header().Get("X-Content-Type-Options"))
+	assert.Equal(`inline; filename=cat.png`, rec.Header().Get("Content-Disposition"))
+
+	rec = serve(t, handler, "GET", url, "", "", map[string]string{"If-None-Match": rec.Header().Get("ETag")})
+	assert.Equal(http.StatusNotModified, rec.Code, "expected cached attachment to be fresh")
+	assert.Empty(rec.Body.Bytes())
+
+	rec = serve(t, handler, "GET", "/factlist/v1/fact/1/attachments/0123456789abcdef", "", "", nil)
+	assert.Equal(http.StatusNotFound, rec.Code, "unexpected http status code")
+
+	rec = serve(t, handler, "DELETE", url, "", "", nil)
+	assert.Equal(http.StatusUnauthorized, rec.Code, "expected anonymous users not to detach attachments")
+	rec = serve(t, handler, "DELETE", url, "player", "", nil)
+	assert.Equal(http.StatusForbidden, rec.Code, "expected players not to detach attachments from published facts")
+	assert.Equal(1, blobCount(), "expected a refused detach to keep the attachment")
+
+	rec = serve(t, handler, "DELETE", url, "reviewer", "", nil)
+	assert.Equal(http.StatusNoContent, rec.Code, "could not detach attachment")
+	assert.Zero(blobCount(), "expected detached attachment to be deleted")
+	rec = serve(t, handler, "GET", url, "", "", nil)
+	assert.Equal(http.StatusNotFound, rec.Code, "expected detached attachment to be gone")
+
+	rec = upload("/factlist/v1/fact/1/attachments", "reviewer", "file", "cat.png", png, nil)
+	require.Equal(http.StatusCreated, rec.Code, "could not upload attachment")
+	require.NoError(svc.Remove(authenticatedAs(context.TODO(), "reviewer"), 1, 0), "could not remove fact")
+	assert.Equal(1, blobCount(), "expected attachments to be kept while the fact is in the trash")
+	_, err = svc.Purge(context.TODO(), time.Now().Add(time.Minute))
+	require.NoError(err, "could not purge facts")
+	assert.Zero(blobCount(), "expected attachments to be deleted with the fact")
+}
+
+func TestSources(t *testing.T) {
+	var (
+		require = require.New(t)
+		assert  = assert.New(t)
+		svc     = factlist.NewService(inmem.NewFactRepository(), factlist.WithReviewers("reviewer"))
+		handler = factlist.NewServer(svc, log.NewNopLogger())
+	)
+
+	tt := []struct {
+		Name           string
+		Method         string
+		URL            string
+		User           string
+		Body           string
+		ExpectedStatus int
+		ExpectedBody   string
+	}{
+		{
+			Name:           "Returns 422 for a source that isn't an http URL",
+			Method:         "POST",
+			URL:            "/factlist/v1/fact",
+			Body:           `{"question": "what is the capital of Germany?", "answer": "Berlin", "sources": [{"url": "ftp://example.com/berlin"}]}`,
+			ExpectedStatus: http.StatusUnprocessableEntity,
+			ExpectedBody: `{
+				"error": "invalid trivia: sources[0].url must be an absolute http or https URL",
+				"details": [{"field": "sources[0].url", "message": "must be an absolute http or https URL"}]
+			}`,
+		},
+		{
+			Name:           "Returns 422 for a source listed twice",
+			Method:         "POST",
+			URL:            "/factlist/v1/fact",
+			Body:           `{"question": "what is the capital of Germany?", "answer": "Berlin", "sources": [{"url": "https://example.com/berlin"}, {"url": " https://example.com/berlin "}]}`,
+			ExpectedStatus: http.StatusUnprocessableEntity,
+			ExpectedBody: `{
+				"error": "invalid trivia: sources[1].url is listed more than once",
+				"details": [{"field": "sources[1].url", "message": "is listed more than once"}]
+			}`,
+		},
+		{
+			Name:           "Returns 200 and the sources of a saved fact",
+			Method:         "POST",
+			URL:            "/factlist/v1/fact",
+			Body:           `{"question": "what is the capital of Germany?", "answer": "Berlin", "sources": [{"url": "https://example.com/berlin", "title": " Berlin ", "accessedAt": "2022-11-20T00:00:00Z", "problem": "ignored"}]}`,
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody:   `{"id": 1, "question": "what is the capital of Germany?", "answer": "Berlin", "createdAt": "0001-01-01T00:00:00Z", "sources": [{"url": "https://example.com/berlin", "title": "Berlin", "accessedAt": "2022-11-20T00:00:00Z"}]}`,
+		},
+		{
+			Name:           "Returns 200 and the replaced sources of an updated fact",
+			Method:         "PUT",
+			URL:            "/factlist/v1/fact/1",
+			Body:           `{"Question": "what is the capital of Germany?", "answer": "Berlin", "sources": [{"url": "https://example.org/germany"}]}`,
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody:   `{"id": 1, "question": "what is the capital of Germany?", "answer": "Berlin", "createdAt": "0001-01-01T00:00:00Z", "sources": [{"url": "https://example.org/germany"}]}`,
+		},
+		{
+			Name:           "Returns 200 and the sources of listed facts",
+			Method:         "GET",
+			URL:            "/factlist/v1/fact?status=all",
+			User:           "reviewer",
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody:   `[{"id": 1, "question": "what is the capital of Germany?", "answer": "Berlin", "createdAt": "0001-01-01T00:00:00Z", "sources": [{"url": "https://example.org/germany"}]}]`,
+		},
+		{
+			Name:           "Returns 200 and no facts while every source works",
+			Method:         "GET",
+			URL:            "/factlist/v1/sources/broken",
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody:   `[]`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			rec := serve(t, handler, tc.Method, tc.URL, tc.User, tc.Body, nil)
+			assert.Equal(tc.ExpectedStatus, rec.Code, "unexpected http status code")
+			assert.JSONEq(tc.ExpectedBody, rec.Body.String(), "unexpected http response body")
+		})
+	}
+
+	_, err := svc.RecordSourceChecks(context.TODO(), 1, []pkg.SourceCheck{{URL: "https://example.org/germany", Problem: "server responded with 404 Not Found"}})
+	require.NoError(err, "could not record source checks")
+
+	rec := serve(t, handler, "GET", "/factlist/v1/sources/broken", "", "", nil)
+	assert.JSONEq(`[]`, rec.Body.String(), "expected a fact pending review to be hidden from players")
+
+	rec = serve(t, handler, "GET", "/factlist/v1/sources/broken", "reviewer", "", nil)
+	assert.Equal(http.StatusOK, rec.Code, "unexpected http status code")
+	var broken []struct {
+		ID      int64 `json:"id"`
+		Sources []struct {
+			URL         string     `json:"url"`
+			Problem     string     `json:"problem"`
+			BrokenSince *time.Time `json:"brokenSince"`
+		} `json:"sources"`
+	}
+	require.NoError(json.NewDecoder(rec.Body).Decode(&broken), "could not decode broken sources")
+	require.Len(broken, 1, "expected the fact with a broken source to be flagged")
+	assert.Equal(int64(1), broken[0].ID, "unexpected fact flagged")
+	require.Len(broken[0].Sources, 1, "unexpected number of sources")
+	assert.Equal("server responded with 404 Not Found", broken[0].Sources[0].Problem, "unexpected problem")
+	assert.NotNil(broken[0].Sources[0].BrokenSince, "expected the time the source broke")
+}
+
+func TestStudyMode(t *testing.T) {
+	var (
+		require = require.New(t)
+		assert  = assert.New(t)
+		repo    = inmem.NewFactRepository()
+		svc     = factlist.NewService(repo)
+		handler = factlist.NewServer(svc, log.NewNopLogger(), factlist.WithStudyService(factlist.NewStudyService(repo, factlist.WithStudy(inmem.NewCardRepository()))))
+	)
+
+	for _, fact := range []pkg.Fact{
+		{Question: "what is the capital of Germany?", Answer: "Berlin", Status: pkg.StatusPublished},
+		{Question: "what is the capital of Austria?", Answer: "Vienna", Status: pkg.StatusPublished},
+		{Question: "what is the capital of Switzerland?", Answer: "Bern", Status: pkg.StatusDraft},
+	} {
+		_, err := svc.Save(context.TODO(), fact)
+		require.NoError(err, "could not save fact")
+	}
+
+	tt := []struct {
+		Name           string
+		Method         string
+		Learner        string
+		URL            string
+		Body           string
+		ExpectedStatus int
+		ExpectedBody   string
+	}{
+		{
+			Name:           "Returns 401 for an anonymous learner",
+			Method:         "GET",
+			URL:            "/factlist/v1/study/due",
+			ExpectedStatus: http.StatusUnauthorized,
+			ExpectedBody:   `{"error": "missing, invalid or expired session token"}`,
+		},
+		{
+			Name:           "Returns 200 and every published fact as a new card",
+			Method:         "GET",
+			Learner:        "alice",
+			URL:            "/factlist/v1/study/due",
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody: `[
+				{"id": 1, "question": "what is the capital of Germany?", "answer": "Berlin", "new": true, "card": {"factId": 1, "repetitions": 0, "interval": 0, "ease": 2.5, "reviews": 0, "lapses": 0}},
+				{"id": 2, "question": "what is the capital of Austria?", "answer": "Vienna", "new": true, "card": {"factId": 2, "repetitions": 0, "interval": 0, "ease": 2.5, "reviews": 0, "lapses": 0}}
+			]`,
+		},
+		{
+			Name:           "Returns 400 for a grade out of range",
+			Method:         "POST",
+			Learner:        "alice",
+			URL:            "/factlist/v1/study/cards/1/reviews",
+			Body:           `{"grade": 6}`,
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedBody:   `{"error": "grade must be a number between 0 and 5"}`,
+		},
+		{
+			Name:           "Returns 400 for a missing grade",
+			Method:         "POST",
+			Learner:        "alice",
+			URL:            "/factlist/v1/study/cards/1/reviews",
+			Body:           `{}`,
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedBody:   `{"error": "grade must be a number between 0 and 5"}`,
+		},
+		{
+			Name:           "Returns 404 when grading an unpublished fact",
+			Method:         "POST",
+			Learner:        "alice",
+			URL:            "/factlist/v1/study/cards/3/reviews",
+			Body:           `{"grade": 5}`,
+			ExpectedStatus: http.StatusNotFound,
+			ExpectedBody:   `{"error": "trivia not found"}`,
+		},
+		{
+			Name:           "Returns 200 and only the fact not yet studied",
+			Method:         "GET",
+			Learner:        "bob",
+			URL:            "/factlist/v1/study/due?limit=1",
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody:   `[{"id": 1, "question": "what is the capital of Germany?", "answer": "Berlin", "new": true, "card": {"factId": 1, "repetitions": 0, "interval": 0, "ease": 2.5, "reviews": 0, "lapses": 0}}]`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			rec := serve(t, handler, tc.Method, tc.URL, tc.Learner, tc.Body, nil)
+			assert.Equal(tc.ExpectedStatus, rec.Code, "unexpected http status code")
+			assert.JSONEq(tc.ExpectedBody, rec.Body.String(), "unexpected http response body")
+		})
+	}
+
+	type card struct {
+		FactID      int64     `json:"factId"`
+		Repetitions int       `json:"repetitions"`
+		Interval    int       `json:"interval"`
+		Ease        float64   `json:"ease"`
+		Due         time.Time `json:"due"`
+		Lapses      int       `json:"lapses"`
+	}
+	review := func(id int64, grade pkg.Grade) card {
+		rec := serve(t, handler, "POST", fmt.Sprintf("/factlist/v1/study/cards/%d/reviews", id), "alice", fmt.Sprintf(`{"grade": %d}`, grade), nil)
+		require.Equal(http.StatusOK, rec.Code, "unexpected http status code")
+		var c card
+		require.NoError(json.NewDecoder(rec.Body).Decode(&c), "could not decode card")
+		return c
+	}
+
+	// SM-2 schedules recalled cards after 1, then 6, then 6 × ease days
+	for i, expected := range []card{
+		{FactID: 1, Repetitions: 1, Interval: 1, Ease: 2.6},
+		{FactID: 1, Repetitions: 2, Interval: 6, Ease: 2.7},
+		{FactID: 1, Repetitions: 3, Interval: 16, Ease: 2.8},
+	} {
+		c := review(1, pkg.GradePerfect)
+		assert.Equal(expected.Repetitions, c.Repetitions, "unexpected repetitions after review %d", i+1)
+		assert.Equal(expected.Interval, c.Interval, "unexpected interval after review %d", i+1)
+		assert.InDelta(expected.Ease, c.Ease, 1e-9, "unexpected ease after review %d", i+1)
+		assert.WithinDuration(time.Now().AddDate(0, 0, c.Interval), c.Due, time.Minute, "unexpected due date after review %d", i+1)
+	}
+
+	c := review(2, pkg.GradeIncorrect)
+	assert.Equal(0, c.Repetitions, "expected a lapse to reset repetitions")
+	assert.Equal(1, c.Interval, "expected a lapse to be reviewed the next day")
+	assert.Equal(1, c.Lapses, "expected the lapse to be counted")
+	assert.InDelta(1.96, c.Ease, 1e-9, "unexpected ease after a lapse")
+
+	rec := serve(t, handler, "GET", "/factlist/v1/study/due", "alice", "", nil)
+	assert.JSONEq(`[]`, rec.Body.String(), "expected no card to be due after reviewing every fact")
+
+	rec = serve(t, handler, "GET", "/factlist/v1/study/stats", "alice", "", nil)
+	assert.Equal(http.StatusOK, rec.Code, "unexpected http status code")
+	assert.JSONEq(`{"cards": 2, "new": 0, "due": 0, "mature": 0, "reviews": 4, "lapses": 1, "retention": 0.75}`, rec.Body.String())
+
+	disabled := factlist.NewServer(svc, log.NewNopLogger(), factlist.WithStudyService(factlist.NewStudyService(repo)))
+	rec = serve(t, disabled, "GET", "/factlist/v1/study/stats", "alice", "", nil)
+	assert.Equal(http.StatusNotImplemented, rec.Code, "expected study mode to be disabled")
+}
+
+func TestAnswerAnalytics(t *testing.T) {
+	var (
+		require = require.New(t)
+		assert  = assert.New(t)
+		repo    = inmem.NewFactRepository()
+		svc     = factlist.NewService(repo)
+		handler = factlist.NewServer(svc, log.NewNopLogger(), factlist.WithAnalyticsService(factlist.NewAnalyticsService(repo, factlist.WithAnalytics(inmem.NewAnalyticsSink(100)))))
+	)
+
+	for _, fact := range []pkg.Fact{
+		{Question: "what is the capital of Australia?", Answer: "Canberra", Status: pkg.StatusPublished},
+		{Question: "what is the capital of Germany?", Answer: "Berlin", Status: pkg.StatusPublished},
+		{Question: "what is the capital of Switzerland?", Answer: "Bern", Status: pkg.StatusDraft},
+		{Question: "what is the capital of Austria?", Answer: "Vienna", Status: pkg.StatusPublished},
+	} {
+		_, err := svc.Save(context.TODO(), fact)
+		require.NoError(err, "could not save fact")
+	}
+
+	tt := []struct {
+		Name           string
+		Method         string
+		URL            string
+		Body           string
+		ExpectedStatus int
+		ExpectedBody   string
+	}{
+		{
+			Name:           "Returns 200 and accepts an answer regardless of case and punctuation",
+			Method:         "POST",
+			URL:            "/factlist/v1/fact/1/answers",
+			Body:           `{"answer": "canberra!", "latencyMs": 4000}`,
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody:   `{"correct": true, "answer": "Canberra"}`,
+		},
+		{
+			Name:           "Returns 200 and rejects a wrong answer",
+			Method:         "POST",
+			URL:            "/factlist/v1/fact/1/answers",
+			Body:           `{"answer": "Sydney", "latencyMs": 2000}`,
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody:   `{"correct": false, "answer": "Canberra"}`,
+		},
+		{
+			Name:           "Returns 200 and rejects the same wrong answer spelled differently",
+			Method:         "POST",
+			URL:            "/factlist/v1/fact/1/answers",
+			Body:           `{"answer": " sydney ", "latencyMs": 1000}`,
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody:   `{"correct": false, "answer": "Canberra"}`,
+		},
+		{
+			Name:           "Returns 200 and records an answer to another fact",
+			Method:         "POST",
+			URL:            "/factlist/v1/fact/2/answers",
+			Body:           `{"answer": "Berlin", "latencyMs": 1500}`,
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody:   `{"correct": true, "answer": "Berlin"}`,
+		},
+		{
+			Name:           "Returns 400 for a negative latency",
+			Method:         "POST",
+			URL:            "/factlist/v1/fact/1/answers",
+			Body:           `{"answer": "Canberra", "latencyMs": -1}`,
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedBody:   `{"error": "latency must not be negative"}`,
+		},
+		{
+			Name:           "Returns 404 when answering an unpublished fact",
+			Method:         "POST",
+			URL:            "/factlist/v1/fact/3/answers",
+			Body:           `{"answer": "Bern"}`,
+			ExpectedStatus: http.StatusNotFound,
+			ExpectedBody:   `{"error": "trivia not found"}`,
+		},
+		{
+			Name:           "Returns 200 and the statistics of a fact",
+			Method:         "GET",
+			URL:            "/factlist/v1/fact/1/stats",
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody:   `{"factId": 1, "attempts": 3, "correct": 1, "accuracy": 0.3333333333333333, "medianLatencyMs": 2000, "wrongAnswers": [{"answer": "sydney", "count": 2}]}`,
+		},
+		{
+			Name:           "Returns 200 and empty statistics for a fact never attempted",
+			Method:         "GET",
+			URL:            "/factlist/v1/fact/4/stats",
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody:   `{"factId": 4, "attempts": 0, "correct": 0, "accuracy": 0, "medianLatencyMs": 0, "wrongAnswers": []}`,
+		},
+		{
+			Name:           "Returns 404 for the statistics of an unpublished fact",
+			Method:         "GET",
+			URL:            "/factlist/v1/fact/3/stats",
+			ExpectedStatus: http.StatusNotFound,
+			ExpectedBody:   `{"error": "trivia not found"}`,
+		},
+		{
+			Name:           "Returns 404 for the statistics of a missing fact",
+			Method:         "GET",
+			URL:            "/factlist/v1/fact/99/stats",
+			ExpectedStatus: http.StatusNotFound,
+			ExpectedBody:   `{"error": "trivia not found"}`,
+		},
+		{
+			Name:           "Returns 200 and the hardest facts first",
+			Method:         "GET",
+			URL:            "/factlist/v1/reports/hardest",
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody: `[
+				{"factId": 1, "attempts": 3, "correct": 1, "accuracy": 0.3333333333333333, "medianLatencyMs": 2000, "wrongAnswers": [{"answer": "sydney", "count": 2}]},
+				{"factId": 2, "attempts": 1, "correct": 1, "accuracy": 1, "medianLatencyMs": 1500, "wrongAnswers": []}
+			]`,
+		},
+		{
+			Name:           "Returns 200 and only the facts attempted often enough",
+			Method:         "GET",
+			URL:            "/factlist/v1/reports/hardest?min=2",
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody:   `[{"factId": 1, "attempts": 3, "correct": 1, "accuracy": 0.3333333333333333, "medianLatencyMs": 2000, "wrongAnswers": [{"answer": "sydney", "count": 2}]}]`,
+		},
+		{
+			Name:           "Returns 400 for an invalid minimum of attempts",
+			Method:         "GET",
+			URL:            "/factlist/v1/reports/hardest?min=-1",
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedBody:   `{"error": "min must be a non-negative number"}`,
+		},
+		{
+			Name:           "Returns 200 and the facts answered wrongly",
+			Method:         "GET",
+			URL:            "/factlist/v1/reports/wrong-answers",
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody:   `[{"factId": 1, "attempts": 3, "correct": 1, "accuracy": 0.3333333333333333, "medianLatencyMs": 2000, "wrongAnswers": [{"answer": "sydney", "count": 2}]}]`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			rec := serve(t, handler, tc.Method, tc.URL, "", tc.Body, nil)
+			assert.Equal(tc.ExpectedStatus, rec.Code, "unexpected http status code")
+			assert.JSONEq(tc.ExpectedBody, rec.Body.String(), "unexpected http response body")
+		})
+	}
+
+	disabled := factlist.NewServer(svc, log.NewNopLogger(), factlist.WithAnalyticsService(factlist.NewAnalyticsService(repo)))
+	rec := serve(t, disabled, "GET", "/factlist/v1/reports/hardest", "", "", nil)
+	assert.Equal(http.StatusNotImplemented, rec.Code, "expected answer analytics to be disabled")
+}
+
+func TestSchedule(t *testing.T) {
+	var (
+		require = require.New(t)
+		assert  = assert.New(t)
+		svc     = factlist.NewService(inmem.NewFactRepository(), factlist.WithReviewers("editor"))
+		handler = factlist.NewServer(svc, log.NewNopLogger())
+	)
+
+	rec := serve(t, handler, "POST", "/factlist/v1/fact", "editor", `{
+		"question": "what is the capital of Germany?",
+		"answer": "Berlin",
+		"publishAt": "2020-10-31T00:00:00Z",
+		"expireAt": "2099-11-01T00:00:00Z"
+	}`, nil)
+	require.Equal(http.StatusOK, rec.Code, "unexpected http status code")
+
+	type schedule struct {
+		PublishAt *time.Time `json:"publishAt"`
+		ExpireAt  *time.Time `json:"expireAt"`
+	}
+	decode := func(rec *httptest.ResponseRecorder) schedule {
+		var s schedule
+		require.NoError(json.NewDecoder(rec.Body).Decode(&s), "could not decode fact")
+		return s
+	}
+
+	s := decode(serve(t, handler, "GET", "/factlist/v1/fact/1", "editor", "", nil))
+	require.NotNil(s.PublishAt, "expected the publication time")
+	require.NotNil(s.ExpireAt, "expected the expiry time")
+	assert.Equal(time.Date(2020, time.October, 31, 0, 0, 0, 0, time.UTC), s.PublishAt.UTC())
+	assert.Equal(time.Date(2099, time.November, 1, 0, 0, 0, 0, time.UTC), s.ExpireAt.UTC())
+
+	tt := []struct {
+		Name            string
+		Body            string
+		ExpectedStatus  int
+		ExpectPublishAt bool
+		ExpectExpireAt  bool
+	}{
+		{
+			Name:            "Removes the expiry time and keeps the publication time",
+			Body:            `{"expireAt": null}`,
+			ExpectedStatus:  http.StatusOK,
+			ExpectPublishAt: true,
+		},
+		{
+			Name:           "Returns 422 for a fact expiring before it goes live",
+			Body:           `{"expireAt": "2019-01-01T00:00:00Z"}`,
+			ExpectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			rec := serve(t, handler, "PATCH", "/factlist/v1/fact/1", "editor", tc.Body, map[string]string{"Content-Type": "application/merge-patch+json"})
+			require.Equal(tc.ExpectedStatus, rec.Code, "unexpected http status code")
+			if rec.Code != http.StatusOK {
+				return
+			}
+			s := decode(rec)
+			assert.Equal(tc.ExpectPublishAt, s.PublishAt != nil, "unexpected publication time")
+			assert.Equal(tc.ExpectExpireAt, s.ExpireAt != nil, "unexpected expiry time")
+		})
+	}
+}
+
+func TestCollections(t *testing.T) {
+	var (
+		require = require.New(t)
+		assert  = assert.New(t)
+		repo    = inmem.NewFactRepository()
+		svc     = factlist.NewService(repo)
+		handler = factlist.NewServer(svc, log.NewNopLogger(), factlist.WithCollectionService(factlist.NewCollectionService(repo, factlist.WithCollections(inmem.NewCollectionRepository()))))
+	)
+
+	for _, fact := range []pkg.Fact{
+		{Question: "what is the capital of Germany?", Answer: "Berlin", Status: pkg.StatusPublished},
+		{Question: "what is the capital of Austria?", Answer: "Vienna", Status: pkg.StatusPublished},
+		{Question: "what is the capital of Switzerland?", Answer: "Bern", Status: pkg.StatusPublished},
+	} {
+		_, err := svc.Save(context.TODO(), fact)
+		require.NoError(err, "could not save fact")
+	}
+
+	tt := []struct {
+		Name           string
+		Method         string
+		URL            string
+		Author         string
+		Body           string
+		ExpectedStatus int
+		ExpectedBody   string
+	}{
+		{
+			Name:           "Returns 422 for a collection without a name",
+			Method:         "POST",
+			URL:            "/factlist/v1/collections",
+			Author:         "host",
+			Body:           `{"name": " ", "factIds": [1]}`,
+			ExpectedStatus: http.StatusUnprocessableEntity,
+			ExpectedBody:   `{"error": "invalid collection: name must not be blank", "details": [{"field": "name", "message": "must not be blank"}]}`,
+		},
+		{
+			Name:           "Returns 422 for a collection listing a missing fact",
+			Method:         "POST",
+			URL:            "/factlist/v1/collections",
+			Author:         "host",
+			Body:           `{"name": "Capitals", "factIds": [1, 99]}`,
+			ExpectedStatus: http.StatusUnprocessableEntity,
+			ExpectedBody:   `{"error": "invalid collection: factIds[1] must refer to an existing trivia", "details": [{"field": "factIds[1]", "message": "must refer to an existing trivia"}]}`,
+		},
+		{
+			Name:           "Returns 401 when creating a collection anonymously",
+			Method:         "POST",
+			URL:            "/factlist/v1/collections",
+			Body:           `{"name": "Capitals", "factIds": [1]}`,
+			ExpectedStatus: http.StatusUnauthorized,
+			ExpectedBody:   `{"error": "missing, invalid or expired session token"}`,
+		},
+		{
+			Name:           "Returns 401 when reading a collection anonymously",
+			Method:         "GET",
+			URL:            "/factlist/v1/collections/1",
+			ExpectedStatus: http.StatusUnauthorized,
+			ExpectedBody:   `{"error": "missing, invalid or expired session token"}`,
+		},
+		{
+			Name:           "Returns 403 when reading the collection of another author",
+			Method:         "GET",
+			URL:            "/factlist/v1/collections/1",
+			Author:         "guest",
+			ExpectedStatus: http.StatusForbidden,
+			ExpectedBody:   `{"error": "collection belongs to another author"}`,
+		},
+		{
+			Name:           "Returns 422 for an order leaving out a fact",
+			Method:         "PUT",
+			URL:            "/factlist/v1/collections/1/order",
+			Author:         "host",
+			Body:           `{"factIds": [3, 1]}`,
+			ExpectedStatus: http.StatusUnprocessableEntity,
+			ExpectedBody:   `{"error": "order must list every trivia of the collection exactly once"}`,
+		},
+		{
+			Name:           "Returns 404 for a collection that isn't shared",
+			Method:         "GET",
+			URL:            "/factlist/v1/shared/european-capitals",
+			ExpectedStatus: http.StatusNotFound,
+			ExpectedBody:   `{"error": "collection not found"}`,
+		},
+		{
+			Name:           "Returns 400 for an unsupported sheet format",
+			Method:         "GET",
+			URL:            "/factlist/v1/collections/1/sheet?format=pdf",
+			Author:         "host",
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedBody:   `{"error": "format must be markdown, html or text"}`,
+		},
+	}
+
+	rec := serve(t, handler, "POST", "/factlist/v1/collections", "host", `{"name": " European capitals ", "description": "Round one", "factIds": [1, 2, 3]}`, nil)
+	require.Equal(http.StatusCreated, rec.Code, "unexpected http status code")
+	assert.Equal("/factlist/v1/collections/1", rec.Header().Get("Location"), "unexpected location")
+	var created struct {
+		Name    string  `json:"name"`
+		Owner   string  `json:"owner"`
+		FactIDs []int64 `json:"factIds"`
+	}
+	require.NoError(json.NewDecoder(rec.Body).Decode(&created), "could not decode collection")
+	assert.Equal("European capitals", created.Name, "expected the name to be trimmed")
+	assert.Equal("host", created.Owner, "expected the author to own the collection")
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			rec := serve(t, handler, tc.Method, tc.URL, tc.Author, tc.Body, nil)
+			assert.Equal(tc.ExpectedStatus, rec.Code, "unexpected http status code")
+			assert.JSONEq(tc.ExpectedBody, rec.Body.String(), "unexpected http response body")
+		})
+	}
+
+	rec = serve(t, handler, "PUT", "/factlist/v1/collections/1/order", "host", `{"factIds": [3, 1, 2]}`, nil)
+	require.Equal(http.StatusOK, rec.Code, "unexpected http status code")
+
+	type detail struct {
+		Slug  string `json:"slug"`
+		Facts []struct {
+			ID int64 `json:"id"`
+		} `json:"facts"`
+	}
+	decode := func(rec *httptest.ResponseRecorder) detail {
+		var d detail
+		require.NoError(json.NewDecoder(rec.Body).Decode(&d), "could not decode collection")
+		return d
+	}
+	d := decode(serve(t, handler, "GET", "/factlist/v1/collections/1", "host", "", nil))
+	require.Len(d.Facts, 3, "unexpected number of facts")
+	assert.Equal([]int64{3, 1, 2}, []int64{d.Facts[0].ID, d.Facts[1].ID, d.Facts[2].ID}, "expected the facts in the new order")
+
+	rec = serve(t, handler, "PUT", "/factlist/v1/collections/1/share", "host", "", nil)
+	require.Equal(http.StatusOK, rec.Code, "unexpected http status code")
+	slug := decode(rec).Slug
+	assert.Regexp(`^european-capitals-[0-9a-f]{8}$`, slug, "unexpected slug")
+
+	rec = serve(t, handler, "GET", "/factlist/v1/shared/"+slug, "", "", nil)
+	require.Equal(http.StatusOK, rec.Code, "expected anyone to see a shared collection")
+	assert.Len(decode(rec).Facts, 3, "unexpected number of shared facts")
+
+	rec = serve(t, handler, "GET", "/factlist/v1/shared/"+slug+"/sheet", "", "", nil)
+	require.Equal(http.StatusOK, rec.Code, "unexpected http status code")
+	assert.Equal("text/markdown; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Equal(`# European capitals
+
+Round one
+
+## Questions
+
+1. what is the capital of Switzerland?
+2. what is the capital of Germany?
+3. what is the capital of Austria?
+
+<div style="page-break-before: always"></div>
+
+## Answers
+
+1. Bern
+2. Berlin
+3. Vienna
+`, rec.Body.String())
+
+	rec = serve(t, handler, "GET", "/factlist/v1/collections/1/sheet?format=html", "host", "", nil)
+	require.Equal(http.StatusOK, rec.Code, "unexpected http status code")
+	assert.Equal("text/html; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Contains(rec.Body.String(), `<section class="answers">`, "expected the answers on a page of their own")
+	assert.Contains(rec.Body.String(), `<li value="1">Bern</li>`)
+
+	rec = serve(t, handler, "DELETE", "/factlist/v1/collections/1/share", "host", "", nil)
+	require.Equal(http.StatusOK, rec.Code, "unexpected http status code")
+	assert.Equal(http.StatusNotFound, serve(t, handler, "GET", "/factlist/v1/shared/"+slug, "", "", nil).Code, "expected the slug to stop working")
+
+	rec = serve(t, handler, "DELETE", "/factlist/v1/collections/1", "guest", "", nil)
+	assert.Equal(http.StatusForbidden, rec.Code, "expected only the owner to remove the collection")
+	rec = serve(t, handler, "DELETE", "/factlist/v1/collections/1", "host", "", nil)
+	assert.Equal(http.StatusNoContent, rec.Code, "unexpected http status code")
+	assert.JSONEq(`[]`, serve(t, handler, "GET", "/factlist/v1/collections", "host", "", nil).Body.String(), "expected no collection left")
+}
+
+func TestQuizSheet(t *testing.T) {
+	var (
+		require = require.New(t)
+		assert  = assert.New(t)
+		repo    = inmem.NewFactRepository()
+		svc     = factlist.NewService(repo)
+		handler = factlist.NewServer(svc, log.NewNopLogger(), factlist.WithSheetService(factlist.NewSheetService(repo, factlist.WithSheetTemplates(inmem.NewSheetTemplateRepository()))))
+	)
+
+	for _, fact := range []pkg.Fact{
+		{Question: "what is the capital of Germany?", Answer: "Berlin", Category: "Geography", Difficulty: pkg.DifficultyEasy, Status: pkg.StatusPublished},
+		{Question: "what is the capital of Australia?", Answer: "Canberra", Category: "geography", Difficulty: pkg.DifficultyHard, Status: pkg.StatusPublished},
+		{Question: "who painted the Mona Lisa?", Answer: "Leonardo da Vinci", Category: "art", Difficulty: pkg.DifficultyEasy, Status: pkg.StatusPublished},
+		{Question: "what is the capital of Austria?", Answer: "Vienna", Category: "geography", Difficulty: pkg.DifficultyEasy, Status: pkg.StatusPublished},
+	} {
+		_, err := svc.Save(context.TODO(), fact)
+		require.NoError(err, "could not save fact")
+	}
+
+	tt := []struct {
+		Name           string
+		Method         string
+		URL            string
+		Author         string
+		Body           string
+		ExpectedStatus int
+		ExpectedType   string
+		ExpectedBody   string
+	}{
+		{
+			Name:           "Renders the questions of a category and difficulty in rounds",
+			Method:         "GET",
+			URL:            "/factlist/v1/sheet?category=Geography&difficulty=easy&rounds=2&numbering=per-round&part=questions&title=Capitals",
+			ExpectedStatus: http.StatusOK,
+			ExpectedType:   "text/markdown; charset=utf-8",
+			ExpectedBody: `# Capitals
+
+## Questions
+
+### Round 1
+
+1. what is the capital of Germany?
+
+### Round 2
+
+1. what is the capital of Austria?
+`,
+		},
+		{
+			Name:           "Renders the answer key in plain text",
+			Method:         "GET",
+			URL:            "/factlist/v1/sheet?difficulty=easy&format=text&part=answers",
+			ExpectedStatus: http.StatusOK,
+			ExpectedType:   "text/plain; charset=utf-8",
+			ExpectedBody: `Quiz
+
+ANSWERS
+
+1. Berlin
+2. Leonardo da Vinci
+3. Vienna
+`,
+		},
+		{
+			Name:           "Numbers rounds continuously unless asked otherwise",
+			Method:         "GET",
+			URL:            "/factlist/v1/sheet?category=geography&rounds=2&format=text&part=answers",
+			ExpectedStatus: http.StatusOK,
+			ExpectedType:   "text/plain; charset=utf-8",
+			ExpectedBody: `Quiz
+
+ANSWERS
+
+Round 1
+
+1. Berlin
+2. Canberra
+
+Round 2
+
+3. Vienna
+`,
+		},
+		{
+			Name:           "Returns 400 for an unknown difficulty",
+			Method:         "GET",
+			URL:            "/factlist/v1/sheet?difficulty=brutal",
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedBody:   `{"error": "difficulty must be easy, medium or hard"}`,
+		},
+		{
+			Name:           "Returns 400 for too many rounds",
+			Method:         "GET",
+			URL:            "/factlist/v1/sheet?rounds=21",
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedBody:   `{"error": "rounds must be a number between 1 and 20"}`,
+		},
+		{
+			Name:           "Returns 400 for an unknown numbering",
+			Method:         "GET",
+			URL:            "/factlist/v1/sheet?numbering=roman",
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedBody:   `{"error": "numbering must be continuous or per-round"}`,
+		},
+		{
+			Name:           "Returns 400 for an unknown part",
+			Method:         "GET",
+			URL:            "/factlist/v1/sheet?part=hints",
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedBody:   `{"error": "part must be questions or answers"}`,
+		},
+		{
+			Name:           "Returns 400 for a random count that isn't a positive number",
+			Method:         "GET",
+			URL:            "/factlist/v1/sheet?random=0",
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedBody:   `{"error": "random must be a number between 1 and 200"}`,
+		},
+		{
+			Name:           "Returns 404 for a missing template",
+			Method:         "GET",
+			URL:            "/factlist/v1/sheet?template=pub-night",
+			ExpectedStatus: http.StatusNotFound,
+			ExpectedBody:   `{"error": "sheet template not found"}`,
+		},
+		{
+			Name:           "Returns 422 for a template that doesn't parse",
+			Method:         "PUT",
+			URL:            "/factlist/v1/sheet/templates/broken",
+			Author:         "host",
+			Body:           `{"format": "text", "source": "{{define \"questions\"}}{{.Title}"}`,
+			ExpectedStatus: http.StatusUnprocessableEntity,
+			ExpectedBody:   `{"error": "invalid sheet template: template: broken:1: bad character U+007D '}'"}`,
+		},
+		{
+			Name:           "Returns 422 for a template without an answer key",
+			Method:         "PUT",
+			URL:            "/factlist/v1/sheet/templates/broken",
+			Author:         "host",
+			Body:           `{"format": "text", "source": "{{define \"questions\"}}{{.Title}}{{end}}"}`,
+			ExpectedStatus: http.StatusUnprocessableEntity,
+			ExpectedBody:   `{"error": "invalid sheet template: template must define \"questions\" and \"answers\""}`,
+		},
+		{
+			Name:           "Returns 422 for a template that doesn't execute",
+			Method:         "PUT",
+			URL:            "/factlist/v1/sheet/templates/broken",
+			Author:         "host",
+			Body:           `{"format": "text", "source": "{{define \"questions\"}}{{.Heading}}{{end}}{{define \"answers\"}}{{end}}"}`,
+			ExpectedStatus: http.StatusUnprocessableEntity,
+			ExpectedBody:   `{"error": "invalid sheet template: template: broken:1:24: executing \"questions\" at <.Heading>: can't evaluate field Heading in type pkg.QuizSheet"}`,
+		},
+		{
+			Name:           "Returns 422 for a template that loops for too long",
+			Method:         "PUT",
+			URL:            "/factlist/v1/sheet/templates/broken",
+			Author:         "host",
+			Body:           `{"format": "text", "source": "{{define \"questions\"}}{{range 1000000000000}}{{range 1000}}{{end}}.{{end}}{{end}}{{define \"answers\"}}{{end}}"}`,
+			ExpectedStatus: http.StatusUnprocessableEntity,
+			ExpectedBody:   `{"error": "invalid sheet template: sheet must render within 1s"}`,
+		},
+		{
+			Name:           "Returns 422 for a template that writes too much",
+			Method:         "PUT",
+			URL:            "/factlist/v1/sheet/templates/broken",
+			Author:         "host",
+			Body:           `{"format": "text", "source": "{{define \"questions\"}}{{range 1000000000000}}.{{end}}{{end}}{{define \"answers\"}}{{end}}"}`,
+			ExpectedStatus: http.StatusUnprocessableEntity,
+			ExpectedBody:   `{"error": "invalid sheet template: sheet must be at most 2097152 bytes"}`,
+		},
+		{
+			Name:           "Returns 401 when saving a template anonymously",
+			Method:         "PUT",
+			URL:            "/factlist/v1/sheet/templates/pub-night",
+			Body:           `{"format": "text", "source": "{{define \"questions\"}}{{end}}{{define \"answers\"}}{{end}}"}`,
+			ExpectedStatus: http.StatusUnauthorized,
+			ExpectedBody:   `{"error": "missing, invalid or expired session token"}`,
+		},
+		{
+			Name:           "Returns 400 for a template of an unsupported format",
+			Method:         "PUT",
+			URL:            "/factlist/v1/sheet/templates/broken",
+			Author:         "host",
+			Body:           `{"format": "pdf", "source": ""}`,
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedBody:   `{"error": "format must be markdown, html or text"}`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			rec := serve(t, handler, tc.Method, tc.URL, tc.Author, tc.Body, nil)
+			assert.Equal(tc.ExpectedStatus, rec.Code, "unexpected http status code")
+			if tc.ExpectedType == "" {
+				assert.JSONEq(tc.ExpectedBody, rec.Body.String(), "unexpected http response body")
+				return
+			}
+			assert.Equal(tc.ExpectedType, rec.Header().Get("Content-Type"), "unexpected content type")
+			assert.Equal(tc.ExpectedBody, rec.Body.String(), "unexpected http response body")
+		})
+	}
+
+	rec := serve(t, handler, "GET", "/factlist/v1/sheet?random=2&format=text&part=questions", "", "", nil)
+	require.Equal(http.StatusOK, rec.Code, "unexpected http status code")
+	assert.Equal(2, strings.Count(rec.Body.String(), "?\n"), "expected two questions picked at random")
+
+	const source = `{{define "questions"}}{{range .Rounds}}{{range .Questions}}Q{{.Number}} [{{.Category}}/{{.Difficulty}}] {{.Question}}
+{{end}}{{end}}{{end}}{{define "answers"}}{{range .Rounds}}{{range .Questions}}A{{.Number}} {{.Answer}}
+{{end}}{{end}}{{end}}`
+	body, err := json.Marshal(map[string]string{"format": "text", "source": source})
+	require.NoError(err, "could not encode template")
+	rec = serve(t, handler, "PUT", "/factlist/v1/sheet/templates/pub-night", "host", string(body), nil)
+	require.Equal(http.StatusCreated, rec.Code, "unexpected http status code")
+	assert.Equal("/factlist/v1/sheet/templates/pub-night", rec.Header().Get("Location"), "unexpected location")
+	rec = serve(t, handler, "PUT", "/factlist/v1/sheet/templates/pub-night", "host", string(body), nil)
+	assert.Equal(http.StatusOK, rec.Code, "expected the owner to replace the template")
+	rec = serve(t, handler, "PUT", "/factlist/v1/sheet/templates/pub-night", "guest", string(body), nil)
+	assert.Equal(http.StatusForbidden, rec.Code, "expected only the owner to replace the template")
+
+	rec = serve(t, handler, "GET", "/factlist/v1/sheet?category=art&template=pub-night", "", "", nil)
+	require.Equal(http.StatusOK, rec.Code, "unexpected http status code")
+	assert.Equal("text/plain; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Equal("Q1 [art/easy] who painted the Mona Lisa?\nA1 Leonardo da Vinci\n", rec.Body.String())
+
+	rec = serve(t, handler, "GET", "/factlist/v1/sheet?template=pub-night&format=html", "", "", nil)
+	assert.Equal(http.StatusBadRequest, rec.Code, "expected the format to match the template")
+
+	rec = serve(t, handler, "GET", "/factlist/v1/sheet/templates", "", "", nil)
+	require.Equal(http.StatusOK, rec.Code, "unexpected http status code")
+	var list []struct {
+		Name  string `json:"name"`
+		Owner string `json:"owner"`
+	}
+	require.NoError(json.NewDecoder(rec.Body).Decode(&list), "could not decode templates")
+	require.Len(list, 1, "unexpected number of templates")
+	assert.Equal("pub-night", list[0].Name)
+	assert.Equal("host", list[0].Owner)
+
+	assert.Equal(http.StatusUnauthorized, serve(t, handler, "DELETE", "/factlist/v1/sheet/templates/pub-night", "", "", nil).Code, "expected anonymous users not to remove the template")
+	assert.Equal(http.StatusForbidden, serve(t, handler, "DELETE", "/factlist/v1/sheet/templates/pub-night", "guest", "", nil).Code, "expected only the owner to remove the template")
+	assert.Equal(http.StatusNoContent, serve(t, handler, "DELETE", "/factlist/v1/sheet/templates/pub-night", "host", "", nil).Code, "unexpected http status code")
+	assert.Equal(http.StatusNotFound, serve(t, handler, "GET", "/factlist/v1/sheet/templates/pub-night", "", "", nil).Code, "expected the template to be gone")
+}
+
+func TestOpenAPI(t *testing.T) {
+	var (
+		require = require.New(t)
+		assert  = assert.New(t)
+		ctx     = context.Background()
+	)
+
+	blobs, err := filesystem.NewBlobStore(t.TempDir())
+	require.NoError(err, "could not create blob store")
+	var (
+		repo  = inmem.NewFactRepository()
+		index = inmem.NewSearchIndex()
+		opts  = []factlist.Option{
+			factlist.WithHistory(inmem.NewHistoryRepository()),
+			factlist.WithDuplicateDetection(pkg.DuplicateDetector{Threshold: 0.6}, pkg.DuplicatesWarn),
+			factlist.WithAttachments(blobs, pkg.AttachmentPolicy{MaxSize: 64, ContentTypes: []string{"image/png"}}),
+			factlist.WithStudy(inmem.NewCardRepository()),
+			factlist.WithAnalytics(inmem.NewAnalyticsSink(100)),
+			factlist.WithCollections(inmem.NewCollectionRepository()),
+			factlist.WithSheetTemplates(inmem.NewSheetTemplateRepository()),
+			factlist.WithReviewers("host"),
+		}
+		svc     = factlist.IndexingMiddleware(index, pkg.SystemClock, log.NewNopLogger())(factlist.NewService(repo, opts...))
+		handler = factlist.NewServer(svc, log.NewNopLogger(),
+			factlist.WithModerationService(factlist.ModerationIndexingMiddleware(index, log.NewNopLogger())(factlist.NewModerationService(repo, opts...))),
+			factlist.WithAttachmentService(factlist.NewAttachmentService(repo, opts...)),
+			factlist.WithStudyService(factlist.NewStudyService(repo, opts...)),
+			factlist.WithAnalyticsService(factlist.NewAnalyticsService(repo, opts...)),
+			factlist.WithCollectionService(factlist.NewCollectionService(repo, opts...)),
+			factlist.WithSheetService(factlist.NewSheetService(repo, opts...)),
+		)
+	)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/factlist/v1/openapi.json", nil))
+	require.Equal(http.StatusOK, rec.Code, "unexpected http status code")
+	doc, err := openapi3.NewLoader().LoadFromData(rec.Body.Bytes())
+	require.NoError(err, "could not load OpenAPI document")
+	require.NoError(doc.Validate(ctx), "invalid OpenAPI document")
+	router, err := gorillamux.NewRouter(doc)
+	require.NoError(err, "could not route OpenAPI document")
+
+	// bodies of these types are only checked to be strings
+	for _, contentType := range []string{"text/markdown", "text/html", "image/png", "text/javascript", "text/css"} {
+		openapi3filter.RegisterBodyDecoder(contentType, openapi3filter.FileBodyDecoder)
+	}
+
+	// newRequest creates a request of the reviewer host, with a JSON body unless empty
+	newRequest := func(method, url, body string) *http.Request {
+		req := httptest.NewRequest(method, url, strings.NewReader(body))
+		req = req.WithContext(authenticatedAs(req.Context(), "host"))
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		return req
+	}
+
+	// serve checks that req is answered with the expected status and a response the OpenAPI document describes
+	covered := make(map[string]bool)
+	validate := func(req *http.Request, expectedStatus int) *httptest.ResponseRecorder {
+		t.Helper()
+		route, params, err := router.FindRoute(req)
+		require.NoError(err, "%s %s is not documented", req.Method, req.URL)
+		covered[route.Operation.OperationID] = true
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		require.Equal(expectedStatus, rec.Code, "unexpected http status code of %s %s: %s", req.Method, req.URL, rec.Body)
+
+		err = openapi3filter.ValidateResponse(ctx, &openapi3filter.ResponseValidationInput{
+			RequestValidationInput: &openapi3filter.RequestValidationInput{Request: req, PathParams: params, Route: route},
+			Status:                 rec.Code,
+			Header:                 rec.Header(),
+			Body:                   io.NopCloser(bytes.NewReader(rec.Body.Bytes())),
+			Options:                &openapi3filter.Options{IncludeResponseStatus: true},
+		})
+		assert.NoError(err, "response to %s %s doesn't match the OpenAPI document", req.Method, req.URL)
+		return rec
+	}
+	decode := func(rec *httptest.ResponseRecorder, v interface{}) {
+		t.Helper()
+		require.NoError(json.Unmarshal(rec.Body.Bytes(), v), "could not decode response")
+	}
+
+	validate(newRequest("POST", "/factlist/v1/fact", `{"question": "what is the capital of Germany?", "answer": "Berlin",
+		"category": "geography", "difficulty": "easy", "sources": [{"url": "https://example.com/germany", "title": "Germany"}]}`), http.StatusOK)
+	rec = validate(newRequest("POST", "/factlist/v1/fact", `{"question": "what's the capital of Germany", "answer": "Berlin"}`), http.StatusOK)
+	assert.Contains(rec.Body.String(), "possibleDuplicates", "expected the duplicate to be reported")
+	validate(newRequest("POST", "/factlist/v1/fact", `{"question": `), http.StatusBadRequest)
+	validate(newRequest("POST", "/factlist/v1/fact", `{"question": " ", "answer": "Berlin"}`), http.StatusUnprocessableEntity)
+	validate(newRequest("POST", "/factlist/v1/fact/1/status", `{"status": "published"}`), http.StatusOK)
+	validate(newRequest("POST", "/factlist/v1/fact/2/status", `{"status": "published"}`), http.StatusOK)
+	validate(httptest.NewRequest("POST", "/factlist/v1/fact/1/status", strings.NewReader(`{"status": "draft"}`)), http.StatusUnauthorized)
+	validate(newRequest("GET", "/factlist/v1/fact?status=all", ""), http.StatusOK)
+	validate(newRequest("GET", "/factlist/v1/fact/random", ""), http.StatusOK)
+	validate(newRequest("GET", "/factlist/v1/fact/search?q=capital", ""), http.StatusOK)
+	validate(newRequest("GET", "/factlist/v1/fact/search", ""), http.StatusBadRequest)
+	validate(newRequest("GET", "/factlist/v1/fact/1", ""), http.StatusOK)
+	validate(newRequest("GET", "/factlist/v1/fact/x", ""), http.StatusBadRequest)
+	validate(newRequest("GET", "/factlist/v1/fact/99", ""), http.StatusNotFound)
+
+	validate(newRequest("PUT", "/factlist/v1/fact/3", `{"question": "who painted the Mona Lisa?", "answer": "Leonardo da Vinci"}`), http.StatusCreated)
+	req := newRequest("PUT", "/factlist/v1/fact/3", `{"question": "who painted the Mona Lisa?", "answer": "da Vinci"}`)
+	req.Header.Set("If-Match", `"99"`)
+	validate(req, http.StatusPreconditionFailed)
+	req = newRequest("PATCH", "/factlist/v1/fact/3", `{"category": "art"}`)
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	validate(req, http.StatusOK)
+	req = newRequest("PATCH", "/factlist/v1/fact/3", `{"category": "art"}`)
+	req.Header.Set("Content-Type", "text/plain")
+	validate(req, http.StatusUnsupportedMediaType)
+	validate(newRequest("GET", "/factlist/v1/fact/3/history", ""), http.StatusOK)
+	validate(newRequest("POST", "/factlist/v1/fact/3/revert/1", ""), http.StatusOK)
+	validate(newRequest("DELETE", "/factlist/v1/fact/3", ""), http.StatusNoContent)
+	validate(newRequest("GET", "/factlist/v1/trash", ""), http.StatusOK)
+	validate(newRequest("POST", "/factlist/v1/trash/3/restore", ""), http.StatusOK)
+
+	validate(newRequest("POST", "/factlist/v1/submission", `{"question": "what is the capital of Australia?", "answer": "Canberra"}`), http.StatusAccepted)
+	validate(newRequest("GET", "/factlist/v1/review", ""), http.StatusOK)
+	req = httptest.NewRequest("GET", "/factlist/v1/review", nil)
+	validate(req.WithContext(authenticatedAs(req.Context(), "player")), http.StatusForbidden)
+	validate(newRequest("POST", "/factlist/v1/fact/4/status", `{"status": "published", "comment": "looks good"}`), http.StatusOK)
+	validate(newRequest("POST", "/factlist/v1/fact/4/status", `{"status": "bogus"}`), http.StatusBadRequest)
+
+	validate(newRequest("GET", "/factlist/v1/fact/1/duplicates", ""), http.StatusOK)
+	validate(newRequest("GET", "/factlist/v1/duplicates", ""), http.StatusOK)
+	validate(newRequest("POST", "/factlist/v1/fact/1/merge", `{"duplicates": [1]}`), http.StatusUnprocessableEntity)
+	validate(newRequest("POST", "/factlist/v1/fact/1/merge", `{"duplicates": [2]}`), http.StatusOK)
+
+	validate(newRequest("PUT", "/factlist/v1/fact/1/translations/de", `{"question": "Was ist die Hauptstadt von Deutschland?", "answer": "Berlin"}`), http.StatusCreated)
+	validate(newRequest("GET", "/factlist/v1/translations/de/missing", ""), http.StatusOK)
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, err := mw.CreateFormFile("file", "flag.png")
+	require.NoError(err, "could not create form file")
+	_, err = fw.Write(append([]byte("\x89PNG\r\n\x1a\n"), []byte("not really an image")...))
+	require.NoError(err, "could not write form file")
+	require.NoError(mw.Close())
+	req = newRequest("POST", "/factlist/v1/fact/1/attachments", "")
+	req.Body, req.ContentLength = io.NopCloser(&body), int64(body.Len())
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	rec = validate(req, http.StatusCreated)
+	var attachment struct {
+		URL string `json:"url"`
+	}
+	decode(rec, &attachment)
+	validate(newRequest("GET", "/factlist/v1/fact/1/attachments", ""), http.StatusOK)
+	validate(newRequest("GET", attachment.URL, ""), http.StatusOK)
+	validate(newRequest("DELETE", attachment.URL, ""), http.StatusNoContent)
+	validate(newRequest("GET", attachment.URL, ""), http.StatusNotFound)
+	validate(newRequest("GET", "/factlist/v1/sources/broken", ""), http.StatusOK)
+
+	validate(newRequest("GET", "/factlist/v1/study/due", ""), http.StatusOK)
+	validate(newRequest("POST", "/factlist/v1/study/cards/1/reviews", `{"grade": 5}`), http.StatusOK)
+	validate(newRequest("POST", "/factlist/v1/study/cards/1/reviews", `{}`), http.StatusBadRequest)
+	validate(newRequest("GET", "/factlist/v1/study/due", ""), http.StatusOK)
+	validate(newRequest("GET", "/factlist/v1/study/stats", ""), http.StatusOK)
+
+	validate(newRequest("POST", "/factlist/v1/fact/1/answers", `{"answer": "Bonn", "latencyMs": 1200}`), http.StatusOK)
+	validate(newRequest("GET", "/factlist/v1/fact/1/stats", ""), http.StatusOK)
+	validate(newRequest("GET", "/factlist/v1/reports/hardest?min=1", ""), http.StatusOK)
+	validate(newRequest("GET", "/factlist/v1/reports/wrong-answers?limit=0", ""), http.StatusBadRequest)
+	validate(newRequest("GET", "/factlist/v1/reports/wrong-answers", ""), http.StatusOK)
+
+	validate(newRequest("POST", "/factlist/v1/collections", `{"name": "Capitals", "factIds": [1, 4]}`), http.StatusCreated)
+	validate(newRequest("GET", "/factlist/v1/collections", ""), http.StatusOK)
+	validate(newRequest("GET", "/factlist/v1/collections/1", ""), http.StatusOK)
+	req = newRequest("GET", "/factlist/v1/collections/1", "")
+	req = req.WithContext(authenticatedAs(req.Context(), "guest"))
+	validate(req, http.StatusForbidden)
+	validate(newRequest("PUT", "/factlist/v1/collections/1", `{"name": "Capitals", "description": "Cities of government", "factIds": [1, 4, 3]}`), http.StatusOK)
+	validate(newRequest("PUT", "/factlist/v1/collections/1/order", `{"factIds": [4, 1, 3]}`), http.StatusOK)
+	rec = validate(newRequest("PUT", "/factlist/v1/collections/1/share", ""), http.StatusOK)
+	var shared struct {
+		Slug string `json:"slug"`
+	}
+	decode(rec, &shared)
+	validate(newRequest("GET", "/factlist/v1/shared/"+shared.Slug, ""), http.StatusOK)
+	validate(newRequest("GET", "/factlist/v1/shared/"+shared.Slug+"/sheet?rounds=2", ""), http.StatusOK)
+	validate(newRequest("GET", "/factlist/v1/collections/1/sheet?format=html", ""), http.StatusOK)
+	validate(newRequest("DELETE", "/factlist/v1/collections/1/share", ""), http.StatusOK)
+	validate(newRequest("DELETE", "/factlist/v1/collections/1", ""), http.StatusNoContent)
+
+	validate(newRequest("GET", "/factlist/v1/sheet?format=text&part=questions", ""), http.StatusOK)
+	validate(newRequest("GET", "/factlist/v1/sheet?format=pdf", ""), http.StatusBadRequest)
+	source := `{"format": "text", "source": "{{define \"questions\"}}{{end}}{{define \"answers\"}}{{end}}"}`
+	validate(newRequest("PUT", "/factlist/v1/sheet/templates/pub-night", source), http.StatusCreated)
+	validate(newRequest("PUT", "/factlist/v1/sheet/templates/pub-night", source), http.StatusOK)
+	validate(newRequest("PUT", "/factlist/v1/sheet/templates/broken", `{"format": "text", "source": "{{"}`), http.StatusUnprocessableEntity)
+	validate(newRequest("GET", "/factlist/v1/sheet/templates", ""), http.StatusOK)
+	validate(newRequest("GET", "/factlist/v1/sheet/templates/pub-night", ""), http.StatusOK)
+	validate(newRequest("DELETE", "/factlist/v1/sheet/templates/pub-night", ""), http.StatusNoContent)
+	validate(newRequest("GET", "/factlist/v1/sheet/templates/pub-night", ""), http.StatusNotFound)
+
+	validate(newRequest("GET", "/factlist/v1/openapi.json", ""), http.StatusOK)
+	rec = validate(newRequest("GET", "/factlist/v1/docs", ""), http.StatusOK)
+	assert.Contains(rec.Header().Get("Content-Security-Policy"), "script-src 'self';", "expected the docs page to only run scripts served by the API")
+	for _, asset := range []string{"docs.js", "swagger-ui.css", "swagger-ui-bundle.js"} {
+		assert.Contains(rec.Body.String(), `"docs/`+asset+`"`, "expected the docs page to load %s", asset)
+		rec := validate(newRequest("GET", "/factlist/v1/docs/"+asset, ""), http.StatusOK)
+		assert.NotZero(rec.Body.Len(), "expected the content of %s", asset)
+	}
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, newRequest("GET", "/factlist/v1/docs/index.html", ""))
+	assert.Equal(http.StatusNotFound, rec.Code, "expected only the assets of the docs page to be served")
+
+	for path, item := range doc.Paths {
+		for method, operation := range item.Operations() {
+			assert.True(covered[operation.OperationID], "%s %s is never requested", method, path)
+		}
+	}
+}
+
+// authenticatedAs returns a copy of ctx carrying the user with the given
+// username and naming them as author, as users.AuthenticationMiddleware does
+func authenticatedAs(ctx context.Context, username string) context.Context {
+	return pkg.WithAuthor(users.WithUser(ctx, &pkg.User{Username: username}), username)
+}
+
+// serve sends a request with body and headers to handler, authenticated as
+// user unless it is empty, and returns the recorded response
+func serve(t *testing.T, handler http.Handler, method, url, user, body string, headers map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	require.NoError(t, err, "could not create http request")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	if user != "" {
+		req = req.WithContext(authenticatedAs(req.Context(), user))
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
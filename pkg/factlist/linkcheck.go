@@ -0,0 +1,211 @@
+package factlist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/markhaur/trivia/pkg"
+)
+
+// LinkChecker is a background worker that periodically fetches the sources
+// of every fact and flags the ones that return errors
+type LinkChecker struct {
+	repository pkg.FactRepository
+	service    Service
+	client     *http.Client
+	interval   time.Duration
+	logger     log.Logger
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// errNonPublicAddress is returned when a source resolves to an address of
+// the host or of a private network, which the checker must not reach
+var errNonPublicAddress = errors.New("source doesn't resolve to a public address")
+
+// NewLinkCheckClient returns a client for NewLinkChecker that only connects
+// to public addresses, redirects included, so sources can't be used to probe
+// the host, cloud metadata endpoints or the private network
+func NewLinkCheckClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+				return errNonPublicAddress
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout: timeout,
+		// a proxy would make the dialer check the address of the proxy instead of the source
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+	}
+}
+
+// cgnat is the shared address space of carrier-grade NATs (RFC 6598)
+var cgnat = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// publicIP reports whether ip is a globally routable unicast address
+func publicIP(ip net.IP) bool {
+	return ip.IsGlobalUnicast() && !ip.IsPrivate() && !cgnat.Contains(ip)
+}
+
+// NewLinkChecker returns a LinkChecker reading the facts stored in
+// repository, as it isn't acting for any user, and recording what it found
+// through service. It fetches sources with client, which should have a
+// timeout set and, outside tests, be a NewLinkCheckClient.
+func NewLinkChecker(repository pkg.FactRepository, service Service, client *http.Client, interval time.Duration, logger log.Logger) *LinkChecker {
+	return &LinkChecker{
+		repository: repository,
+		service:    service,
+		client:     client,
+		interval:   interval,
+		logger:     logger,
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+}
+
+// Run checks the sources of every fact every interval until Shutdown is
+// called or ctx is done
+func (c *LinkChecker) Run(ctx context.Context) error {
+	defer close(c.done)
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		c.Check(ctx)
+		select {
+		case <-ticker.C:
+		case <-c.stop:
+			return nil
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// Shutdown stops the checker and waits for the source being fetched to be done
+func (c *LinkChecker) Shutdown(ctx context.Context) error {
+	close(c.stop)
+	select {
+	case <-c.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Check fetches the sources of every fact once and records which are broken,
+// as Run does every interval. It returns early once Shutdown is called.
+func (c *LinkChecker) Check(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-c.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	list, err := c.repository.FindAll(ctx)
+	if err != nil {
+		level.Error(c.logger).Log("msg", "could not list facts to check", "err", err)
+		return
+	}
+
+	// sources cited by several facts are only fetched once per run
+	problems := make(map[string]string)
+	var checked, broken int
+	for _, fact := range list {
+		if len(fact.Sources) == 0 {
+			continue
+		}
+
+		checks := make([]pkg.SourceCheck, 0, len(fact.Sources))
+		for _, source := range fact.Sources {
+			problem, ok := problems[source.URL]
+			if !ok {
+				problem = c.fetch(ctx, source.URL)
+				if ctx.Err() != nil {
+					return
+				}
+				problems[source.URL] = problem
+				checked++
+				if problem != "" {
+					broken++
+				}
+			}
+			checks = append(checks, pkg.SourceCheck{URL: source.URL, Problem: problem})
+		}
+
+		if _, err := c.service.RecordSourceChecks(ctx, fact.ID, checks); err != nil && err != pkg.ErrFactNotFound {
+			level.Error(c.logger).Log("msg", "could not record source checks", "id", fact.ID, "err", err)
+		}
+	}
+	if checked > 0 {
+		level.Info(c.logger).Log("msg", "checked sources", "count", checked, "broken", broken)
+	}
+}
+
+// fetch requests url and describes why it failed, or returns an empty string
+// if it succeeded. Servers that don't support HEAD are sent a GET instead.
+// Problems are described without the details of the error, as they are
+// shown to every client.
+func (c *LinkChecker) fetch(ctx context.Context, url string) string {
+	resp, err := c.do(ctx, http.MethodHead, url)
+	if err == nil && (resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented) {
+		resp, err = c.do(ctx, http.MethodGet, url)
+	}
+	if err != nil {
+		level.Debug(c.logger).Log("msg", "could not fetch source", "url", url, "err", err)
+		var netErr net.Error
+		switch {
+		case errors.Is(err, errNonPublicAddress):
+			return errNonPublicAddress.Error()
+		case errors.As(err, &netErr) && netErr.Timeout():
+			return "server didn't respond in time"
+		default:
+			return "server couldn't be reached"
+		}
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Sprintf("server responded with %s", resp.Status)
+	}
+	return ""
+}
+
+func (c *LinkChecker) do(ctx context.Context, method, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<10))
+	resp.Body.Close()
+	return resp, nil
+}
@@ -2,12 +2,21 @@ package factlist
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
 	"github.com/markhaur/trivia/pkg"
+	"github.com/markhaur/trivia/pkg/requestid"
+	"github.com/markhaur/trivia/pkg/tracing"
 )
 
+// contextLogger annotates logger with the request and trace IDs found in ctx
+func contextLogger(ctx context.Context, logger log.Logger) log.Logger {
+	return requestid.Logger(ctx, tracing.Logger(ctx, logger))
+}
+
 func LoggingMiddleware(logger log.Logger) Middleware {
 	return func(s Service) Service { return &loggingMiddleware{logger, s} }
 }
@@ -19,7 +28,7 @@ type loggingMiddleware struct {
 
 func (s *loggingMiddleware) Save(ctx context.Context, fact pkg.Fact) (_ *pkg.Fact, err error) {
 	defer func(begin time.Time) {
-		s.logger.Log(
+		level.Info(contextLogger(ctx, s.logger)).Log(
 			"method", "save",
 			"question", fact.Question,
 			"took", time.Since(begin),
@@ -31,7 +40,7 @@ func (s *loggingMiddleware) Save(ctx context.Context, fact pkg.Fact) (_ *pkg.Fac
 
 func (s *loggingMiddleware) List(ctx context.Context) (_ []pkg.Fact, err error) {
 	defer func(begin time.Time) {
-		s.logger.Log(
+		level.Info(contextLogger(ctx, s.logger)).Log(
 			"method", "list",
 			"took", time.Since(begin),
 			"err", err,
@@ -40,21 +49,34 @@ func (s *loggingMiddleware) List(ctx context.Context) (_ []pkg.Fact, err error)
 	return s.Service.List(ctx)
 }
 
-func (s *loggingMiddleware) Remove(ctx context.Context, id int64) (err error) {
+func (s *loggingMiddleware) Get(ctx context.Context, id int64) (_ *pkg.Fact, err error) {
+	defer func(begin time.Time) {
+		level.Info(contextLogger(ctx, s.logger)).Log(
+			"method", "get",
+			"id", id,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+	return s.Service.Get(ctx, id)
+}
+
+func (s *loggingMiddleware) Remove(ctx context.Context, id int64, version int64) (err error) {
 	defer func(begin time.Time) {
-		s.logger.Log(
+		level.Info(contextLogger(ctx, s.logger)).Log(
 			"method", "remove",
 			"id", id,
+			"version", version,
 			"took", time.Since(begin),
 			"err", err,
 		)
 	}(time.Now())
-	return s.Service.Remove(ctx, id)
+	return s.Service.Remove(ctx, id, version)
 }
 
 func (s *loggingMiddleware) Update(ctx context.Context, fact pkg.Fact) (_ *pkg.Fact, _ bool, err error) {
 	defer func(begin time.Time) {
-		s.logger.Log(
+		level.Info(contextLogger(ctx, s.logger)).Log(
 			"method", "update",
 			"question", fact.Question,
 			"took", time.Since(begin),
@@ -63,3 +85,219 @@ func (s *loggingMiddleware) Update(ctx context.Context, fact pkg.Fact) (_ *pkg.F
 	}(time.Now())
 	return s.Service.Update(ctx, fact)
 }
+
+func (s *loggingMiddleware) Replace(ctx context.Context, fact pkg.Fact) (_ *pkg.Fact, err error) {
+	defer func(begin time.Time) {
+		level.Info(contextLogger(ctx, s.logger)).Log(
+			"method", "replace",
+			"id", fact.ID,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+	return s.Service.Replace(ctx, fact)
+}
+
+func (s *loggingMiddleware) Patch(ctx context.Context, id int64, version int64, patch []byte) (_ *pkg.Fact, err error) {
+	defer func(begin time.Time) {
+		level.Info(contextLogger(ctx, s.logger)).Log(
+			"method", "patch",
+			"id", id,
+			"version", version,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+	return s.Service.Patch(ctx, id, version, patch)
+}
+
+func (s *loggingMiddleware) History(ctx context.Context, id int64) (_ []pkg.Revision, err error) {
+	defer func(begin time.Time) {
+		level.Info(contextLogger(ctx, s.logger)).Log(
+			"method", "history",
+			"id", id,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+	return s.Service.History(ctx, id)
+}
+
+func (s *loggingMiddleware) Revert(ctx context.Context, id int64, revision int, version int64) (_ *pkg.Fact, err error) {
+	defer func(begin time.Time) {
+		level.Info(contextLogger(ctx, s.logger)).Log(
+			"method", "revert",
+			"id", id,
+			"revision", revision,
+			"version", version,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+	return s.Service.Revert(ctx, id, revision, version)
+}
+
+func (s *loggingMiddleware) Trash(ctx context.Context) (_ []pkg.Fact, err error) {
+	defer func(begin time.Time) {
+		level.Info(contextLogger(ctx, s.logger)).Log(
+			"method", "trash",
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+	return s.Service.Trash(ctx)
+}
+
+func (s *loggingMiddleware) Restore(ctx context.Context, id int64, version int64) (_ *pkg.Fact, err error) {
+	defer func(begin time.Time) {
+		level.Info(contextLogger(ctx, s.logger)).Log(
+			"method", "restore",
+			"id", id,
+			"version", version,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+	return s.Service.Restore(ctx, id, version)
+}
+
+func (s *loggingMiddleware) Purge(ctx context.Context, before time.Time) (n int, err error) {
+	defer func(begin time.Time) {
+		level.Info(contextLogger(ctx, s.logger)).Log(
+			"method", "purge",
+			"before", before,
+			"purged", n,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+	return s.Service.Purge(ctx, before)
+}
+
+func (s *loggingMiddleware) ListByStatus(ctx context.Context, statuses ...pkg.Status) (_ []pkg.Fact, err error) {
+	defer func(begin time.Time) {
+		level.Info(contextLogger(ctx, s.logger)).Log(
+			"method", "listByStatus",
+			"statuses", fmt.Sprint(statuses),
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+	return s.Service.ListByStatus(ctx, statuses...)
+}
+
+func (s *loggingMiddleware) Random(ctx context.Context) (_ *pkg.Fact, err error) {
+	defer func(begin time.Time) {
+		level.Info(contextLogger(ctx, s.logger)).Log(
+			"method", "random",
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+	return s.Service.Random(ctx)
+}
+
+func (s *loggingMiddleware) Search(ctx context.Context, query string, limit int) (results []pkg.SearchResult, err error) {
+	defer func(begin time.Time) {
+		level.Info(contextLogger(ctx, s.logger)).Log(
+			"method", "search",
+			"query", query,
+			"limit", limit,
+			"results", len(results),
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+	return s.Service.Search(ctx, query, limit)
+}
+
+func (s *loggingMiddleware) Duplicates(ctx context.Context, id int64) (duplicates []pkg.Duplicate, err error) {
+	defer func(begin time.Time) {
+		level.Info(contextLogger(ctx, s.logger)).Log(
+			"method", "duplicates",
+			"id", id,
+			"duplicates", len(duplicates),
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+	return s.Service.Duplicates(ctx, id)
+}
+
+func (s *loggingMiddleware) DuplicateClusters(ctx context.Context) (clusters []pkg.DuplicateCluster, err error) {
+	defer func(begin time.Time) {
+		level.Info(contextLogger(ctx, s.logger)).Log(
+			"method", "duplicateClusters",
+			"clusters", len(clusters),
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+	return s.Service.DuplicateClusters(ctx)
+}
+
+func (s *loggingMiddleware) Merge(ctx context.Context, id int64, duplicates []int64, version int64) (_ *pkg.Fact, err error) {
+	defer func(begin time.Time) {
+		level.Info(contextLogger(ctx, s.logger)).Log(
+			"method", "merge",
+			"id", id,
+			"duplicates", fmt.Sprint(duplicates),
+			"version", version,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+	return s.Service.Merge(ctx, id, duplicates, version)
+}
+
+func (s *loggingMiddleware) SetTranslation(ctx context.Context, id int64, lang string, translation pkg.Translation, version int64) (_ *pkg.Fact, created bool, err error) {
+	defer func(begin time.Time) {
+		level.Info(contextLogger(ctx, s.logger)).Log(
+			"method", "setTranslation",
+			"id", id,
+			"lang", lang,
+			"version", version,
+			"created", created,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+	return s.Service.SetTranslation(ctx, id, lang, translation, version)
+}
+
+func (s *loggingMiddleware) MissingTranslations(ctx context.Context, lang string) (missing []pkg.Fact, err error) {
+	defer func(begin time.Time) {
+		level.Info(contextLogger(ctx, s.logger)).Log(
+			"method", "missingTranslations",
+			"lang", lang,
+			"missing", len(missing),
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+	return s.Service.MissingTranslations(ctx, lang)
+}
+
+func (s *loggingMiddleware) RecordSourceChecks(ctx context.Context, id int64, checks []pkg.SourceCheck) (_ *pkg.Fact, err error) {
+	defer func(begin time.Time) {
+		level.Info(contextLogger(ctx, s.logger)).Log(
+			"method", "recordSourceChecks",
+			"id", id,
+			"checks", len(checks),
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+	return s.Service.RecordSourceChecks(ctx, id, checks)
+}
+
+func (s *loggingMiddleware) BrokenSources(ctx context.Context) (_ []pkg.Fact, err error) {
+	defer func(begin time.Time) {
+		level.Info(contextLogger(ctx, s.logger)).Log(
+			"method", "brokenSources",
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+	return s.Service.BrokenSources(ctx)
+}
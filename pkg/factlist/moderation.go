@@ -0,0 +1,211 @@
+package factlist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+
+	"github.com/markhaur/trivia/pkg"
+	"github.com/markhaur/trivia/pkg/users"
+)
+
+var (
+	ErrNotReviewer   = errors.New("only reviewers may review trivia")
+	ErrFactPublished = errors.New("only reviewers may change published trivia")
+)
+
+// ModerationService lets contributors submit facts and reviewers publish or reject them
+type ModerationService interface {
+	Submit(context.Context, pkg.Fact) (*pkg.Fact, error)
+	ReviewQueue(context.Context) ([]pkg.Fact, error)
+	Transition(ctx context.Context, id int64, status pkg.Status, comment string, version int64) (*pkg.Fact, error)
+}
+
+// ModerationMiddleware describes a ModerationService Middleware
+type ModerationMiddleware func(ModerationService) ModerationService
+
+// NewModerationService returns a ModerationService over the facts stored in
+// repository, configured by the same options as NewService
+func NewModerationService(repository pkg.FactRepository, opts ...Option) ModerationService {
+	return newService(repository, opts...)
+}
+
+// WithReviewers lets the users with the given usernames review facts.
+// Nobody may review facts unless it is given.
+func WithReviewers(usernames ...string) Option {
+	return func(s *service) {
+		s.reviewers = make(map[string]bool, len(usernames))
+		for _, username := range usernames {
+			s.reviewers[username] = true
+		}
+	}
+}
+
+// reviewer returns the user who made the request if they are a reviewer
+func (s *service) reviewer(ctx context.Context) (*pkg.User, error) {
+	user, err := authenticated(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !s.reviewers[user.Username] {
+		return nil, ErrNotReviewer
+	}
+	return user, nil
+}
+
+// mayChange fails unless the user who made the request may change fact.
+// Anybody may change a fact before it is published, but only reviewers may
+// change what players see.
+func (s *service) mayChange(ctx context.Context, fact pkg.Fact) error {
+	if !fact.Published() {
+		return nil
+	}
+	if _, err := s.reviewer(ctx); err != nil {
+		if err == ErrNotReviewer {
+			return ErrFactPublished
+		}
+		return err
+	}
+	return nil
+}
+
+// denied reports whether err is returned by mayChange
+func denied(err error) bool {
+	return err == users.ErrUnauthenticated || err == ErrFactPublished
+}
+
+// visibleTo returns whether the user who made the request may see a fact:
+// reviewers see every fact, anybody else only the live ones
+func (s *service) visibleTo(ctx context.Context) func(pkg.Fact) bool {
+	if _, err := s.reviewer(ctx); err == nil {
+		return func(pkg.Fact) bool { return true }
+	}
+	now := s.clock.Now()
+	return func(fact pkg.Fact) bool { return fact.Live(now) }
+}
+
+// visibleFacts returns the stored facts the user who made the request may see
+func (s *service) visibleFacts(ctx context.Context) ([]pkg.Fact, error) {
+	list, err := s.repository.FindAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not list facts: %v", err)
+	}
+
+	visible := s.visibleTo(ctx)
+	filtered := make([]pkg.Fact, 0, len(list))
+	for _, fact := range list {
+		if visible(fact) {
+			filtered = append(filtered, fact)
+		}
+	}
+	return filtered, nil
+}
+
+// ListByStatus returns the facts in any of the given statuses, or every fact
+// if no status is given. Only reviewers may list facts that are not
+// published; anybody else only gets the published facts that are live.
+func (s *service) ListByStatus(ctx context.Context, statuses ...pkg.Status) ([]pkg.Fact, error) {
+	published := len(statuses) > 0
+	for _, status := range statuses {
+		if !status.Valid() {
+			return nil, pkg.ErrInvalidStatus
+		}
+		published = published && status == pkg.StatusPublished
+	}
+	_, err := s.reviewer(ctx)
+	if err != nil && !published {
+		return nil, err
+	}
+	liveOnly := err != nil
+
+	list, err := s.repository.FindAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not list facts: %v", err)
+	}
+	if len(statuses) == 0 {
+		return list, nil
+	}
+
+	now := s.clock.Now()
+	filtered := make([]pkg.Fact, 0, len(list))
+	for _, fact := range list {
+		if liveOnly && !fact.Live(now) {
+			continue
+		}
+		for _, status := range statuses {
+			if fact.Status == status {
+				filtered = append(filtered, fact)
+				break
+			}
+		}
+	}
+	return filtered, nil
+}
+
+// Random returns a fact players may see, picked at random
+func (s *service) Random(ctx context.Context) (*pkg.Fact, error) {
+	published, err := s.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(published) == 0 {
+		return nil, pkg.ErrFactNotFound
+	}
+	fact := published[rand.Intn(len(published))]
+	return &fact, nil
+}
+
+// Submit stores a new fact proposed by a contributor and queues it for review
+func (s *service) Submit(ctx context.Context, fact pkg.Fact) (*pkg.Fact, error) {
+	fact.Status = pkg.StatusPendingReview
+	return s.Save(ctx, fact)
+}
+
+// ReviewQueue returns the facts waiting to be reviewed to a reviewer
+func (s *service) ReviewQueue(ctx context.Context) ([]pkg.Fact, error) {
+	if _, err := s.reviewer(ctx); err != nil {
+		return nil, err
+	}
+	return s.ListByStatus(ctx, pkg.StatusPendingReview)
+}
+
+// Transition moves the fact with the given ID to status, recording the
+// reviewer making the request and comment on it. A non-zero version is the
+// version the caller expects to move.
+func (s *service) Transition(ctx context.Context, id int64, status pkg.Status, comment string, version int64) (*pkg.Fact, error) {
+	reviewer, err := s.reviewer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !status.Valid() {
+		return nil, pkg.ErrInvalidStatus
+	}
+	author, now := reviewer.Username, s.clock.Now()
+
+	fact, err := s.repository.Modify(ctx, id, version, func(fact *pkg.Fact) error {
+		if !fact.Status.CanTransitionTo(status) {
+			return pkg.ErrInvalidTransition
+		}
+		fact.Comments = append(fact.Comments, pkg.ReviewComment{
+			Author:    author,
+			Body:      comment,
+			From:      fact.Status,
+			To:        status,
+			CreatedAt: now,
+		})
+		fact.Status = status
+		return nil
+	})
+	if err != nil {
+		switch err {
+		case pkg.ErrFactNotFound, pkg.ErrVersionConflict, pkg.ErrInvalidTransition:
+			return nil, err
+		}
+		return nil, fmt.Errorf("could not change fact status: %v", err)
+	}
+	if err := s.record(ctx, pkg.ActionReview, *fact); err != nil {
+		return nil, err
+	}
+	return fact, nil
+}
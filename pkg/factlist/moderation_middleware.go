@@ -0,0 +1,90 @@
+package factlist
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/markhaur/trivia/pkg"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ModerationLoggingMiddleware logs the calls made to a ModerationService
+func ModerationLoggingMiddleware(logger log.Logger) ModerationMiddleware {
+	return func(s ModerationService) ModerationService { return &moderationLoggingMiddleware{logger, s} }
+}
+
+type moderationLoggingMiddleware struct {
+	logger log.Logger
+	ModerationService
+}
+
+func (s *moderationLoggingMiddleware) Submit(ctx context.Context, fact pkg.Fact) (_ *pkg.Fact, err error) {
+	defer func(begin time.Time) {
+		level.Info(contextLogger(ctx, s.logger)).Log(
+			"method", "submit",
+			"question", fact.Question,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+	return s.ModerationService.Submit(ctx, fact)
+}
+
+func (s *moderationLoggingMiddleware) ReviewQueue(ctx context.Context) (_ []pkg.Fact, err error) {
+	defer func(begin time.Time) {
+		level.Info(contextLogger(ctx, s.logger)).Log(
+			"method", "reviewQueue",
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+	return s.ModerationService.ReviewQueue(ctx)
+}
+
+func (s *moderationLoggingMiddleware) Transition(ctx context.Context, id int64, status pkg.Status, comment string, version int64) (_ *pkg.Fact, err error) {
+	defer func(begin time.Time) {
+		level.Info(contextLogger(ctx, s.logger)).Log(
+			"method", "transition",
+			"id", id,
+			"status", status,
+			"version", version,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+	return s.ModerationService.Transition(ctx, id, status, comment, version)
+}
+
+// ModerationTracingMiddleware starts a span for every call made to a ModerationService
+func ModerationTracingMiddleware(tracer trace.Tracer) ModerationMiddleware {
+	return func(s ModerationService) ModerationService { return &moderationTracingMiddleware{tracer, s} }
+}
+
+type moderationTracingMiddleware struct {
+	tracer trace.Tracer
+	ModerationService
+}
+
+func (s *moderationTracingMiddleware) Submit(ctx context.Context, fact pkg.Fact) (_ *pkg.Fact, err error) {
+	ctx, span := s.tracer.Start(ctx, "factlist.Submit")
+	defer func() { endSpan(span, err) }()
+	return s.ModerationService.Submit(ctx, fact)
+}
+
+func (s *moderationTracingMiddleware) ReviewQueue(ctx context.Context) (_ []pkg.Fact, err error) {
+	ctx, span := s.tracer.Start(ctx, "factlist.ReviewQueue")
+	defer func() { endSpan(span, err) }()
+	return s.ModerationService.ReviewQueue(ctx)
+}
+
+func (s *moderationTracingMiddleware) Transition(ctx context.Context, id int64, status pkg.Status, comment string, version int64) (_ *pkg.Fact, err error) {
+	ctx, span := s.tracer.Start(ctx, "factlist.Transition", trace.WithAttributes(
+		attribute.Int64("fact.id", id),
+		attribute.String("fact.status", string(status)),
+	))
+	defer func() { endSpan(span, err) }()
+	return s.ModerationService.Transition(ctx, id, status, comment, version)
+}
@@ -0,0 +1,49 @@
+package factlist
+
+import (
+	_ "embed"
+	"fmt"
+
+	swaggerfiles "github.com/swaggo/files/v2"
+)
+
+// openAPIDocument is the OpenAPI 3 description of the routes of NewServer.
+// The tests validate the responses of the handlers against it, so it has to
+// change along with them.
+//
+//go:embed openapi.json
+var openAPIDocument []byte
+
+// swaggerUI holds the Swagger UI distribution the docs page loads, embedded
+// in the binary so that the page doesn't depend on a CDN
+var swaggerUI = swaggerfiles.FS
+
+// swaggerUIAssets are the files of swaggerUI the docs page loads
+var swaggerUIAssets = map[string]bool{"swagger-ui.css": true, "swagger-ui-bundle.js": true}
+
+// docsScript starts Swagger UI with the OpenAPI document served next to the docs page
+const docsScript = `window.onload = function () { SwaggerUIBundle({url: "openapi.json", dom_id: "#swagger-ui"}); };`
+
+// docsScriptName is the asset docsScript is served as
+const docsScriptName = "docs.js"
+
+var docsPage = fmt.Sprintf(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Trivia factlist API</title>
+<link rel="stylesheet" href="docs/swagger-ui.css">
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="docs/swagger-ui-bundle.js"></script>
+<script src="docs/%s"></script>
+</body>
+</html>
+`, docsScriptName)
+
+// docsPolicy lets the docs page run Swagger UI and fetch the OpenAPI document,
+// all served by the API itself, replacing the stricter policy the rest of the
+// API is served with. Swagger UI sets inline styles.
+const docsPolicy = "default-src 'none'; script-src 'self'; style-src 'self' 'unsafe-inline'; " +
+	"img-src 'self' data:; connect-src 'self'; frame-ancestors 'none'"
@@ -0,0 +1,145 @@
+package factlist
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/markhaur/trivia/pkg"
+)
+
+// ErrInvalidPatch is returned when a patch is well-formed but can't be applied to a fact
+type ErrInvalidPatch struct{ err error }
+
+func (e ErrInvalidPatch) Error() string { return fmt.Sprintf("invalid patch: %v", e.err) }
+
+// factDocument is the JSON representation of a fact that patches are applied to
+type factDocument struct {
+	Question   string           `json:"question"`
+	Answer     string           `json:"answer"`
+	CreatedAt  time.Time        `json:"createdAt"`
+	Category   string           `json:"category,omitempty"`
+	Difficulty pkg.Difficulty   `json:"difficulty,omitempty"`
+	Sources    []sourceDocument `json:"sources,omitempty"`
+	PublishAt  *time.Time       `json:"publishAt,omitempty"`
+	ExpireAt   *time.Time       `json:"expireAt,omitempty"`
+}
+
+// sourceDocument is the JSON representation of a source of a patched fact
+type sourceDocument struct {
+	URL        string     `json:"url"`
+	Title      string     `json:"title,omitempty"`
+	AccessedAt *time.Time `json:"accessedAt,omitempty"`
+}
+
+var requiredMembers = []string{"question", "answer"}
+
+// applyMergePatch applies an RFC 7396 JSON Merge Patch to fact
+func applyMergePatch(fact *pkg.Fact, patch []byte) error {
+	var p interface{}
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return ErrInvalidPatch{err}
+	}
+	if _, ok := p.(map[string]interface{}); !ok {
+		return ErrInvalidPatch{errors.New("patch must be a JSON object")}
+	}
+
+	sources := make([]sourceDocument, 0, len(fact.Sources))
+	for _, source := range fact.Sources {
+		doc := sourceDocument{URL: source.URL, Title: source.Title}
+		if !source.AccessedAt.IsZero() {
+			accessedAt := source.AccessedAt
+			doc.AccessedAt = &accessedAt
+		}
+		sources = append(sources, doc)
+	}
+	original, err := json.Marshal(factDocument{
+		Question:   fact.Question,
+		Answer:     fact.Answer,
+		CreatedAt:  fact.CreatedAt,
+		Category:   fact.Category,
+		Difficulty: fact.Difficulty,
+		Sources:    sources,
+		PublishAt:  timePtr(fact.PublishAt),
+		ExpireAt:   timePtr(fact.ExpireAt),
+	})
+	if err != nil {
+		return err
+	}
+	var target interface{}
+	if err := json.Unmarshal(original, &target); err != nil {
+		return err
+	}
+
+	merged := mergePatch(target, p).(map[string]interface{})
+	for _, member := range requiredMembers {
+		if _, ok := merged[member]; !ok {
+			return ErrInvalidPatch{fmt.Errorf("%s can't be removed", member)}
+		}
+	}
+
+	b, err := json.Marshal(merged)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+
+	var doc factDocument
+	if err := dec.Decode(&doc); err != nil {
+		return ErrInvalidPatch{err}
+	}
+
+	patched := make([]pkg.Source, 0, len(doc.Sources))
+	for _, source := range doc.Sources {
+		var accessedAt time.Time
+		if source.AccessedAt != nil {
+			accessedAt = *source.AccessedAt
+		}
+		patched = append(patched, pkg.Source{URL: source.URL, Title: source.Title, AccessedAt: accessedAt})
+	}
+	fact.Question, fact.Answer, fact.CreatedAt = doc.Question, doc.Answer, doc.CreatedAt
+	fact.Sources = replaceSources(fact.Sources, patched)
+	fact.PublishAt, fact.ExpireAt = timeValue(doc.PublishAt), timeValue(doc.ExpireAt)
+	fact.Category, fact.Difficulty = doc.Category, doc.Difficulty
+	return nil
+}
+
+// timePtr returns a pointer to t, or nil if t is zero, so that unset times are left out of JSON documents
+func timePtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// timeValue returns the time t points to, or the zero time if t is nil
+func timeValue(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
+
+// mergePatch implements the MergePatch function of RFC 7396 section 2
+func mergePatch(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = make(map[string]interface{})
+	}
+	for name, value := range p {
+		if value == nil {
+			delete(t, name)
+			continue
+		}
+		t[name] = mergePatch(t[name], value)
+	}
+	return t
+}
@@ -0,0 +1,79 @@
+package factlist
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/markhaur/trivia/pkg"
+)
+
+// TrashPurger is a background worker that periodically purges facts which
+// have been in the trash for longer than the retention period
+type TrashPurger struct {
+	service   Service
+	clock     pkg.Clock
+	retention time.Duration
+	interval  time.Duration
+	logger    log.Logger
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func NewTrashPurger(service Service, clock pkg.Clock, retention, interval time.Duration, logger log.Logger) *TrashPurger {
+	return &TrashPurger{
+		service:   service,
+		clock:     clock,
+		retention: retention,
+		interval:  interval,
+		logger:    logger,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+}
+
+// Run purges expired facts every interval until Shutdown is called or ctx is done
+func (p *TrashPurger) Run(ctx context.Context) error {
+	defer close(p.done)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.Purge(ctx)
+		select {
+		case <-ticker.C:
+		case <-p.stop:
+			return nil
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// Shutdown stops the purger and waits for a purge in progress to finish
+func (p *TrashPurger) Shutdown(ctx context.Context) error {
+	close(p.stop)
+	select {
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Purge permanently removes the facts that have been in the trash for longer
+// than the retention period, as Run does every interval
+func (p *TrashPurger) Purge(ctx context.Context) {
+	ctx = pkg.WithAuthor(ctx, "trash-purger")
+	n, err := p.service.Purge(ctx, p.clock.Now().Add(-p.retention))
+	if err != nil {
+		level.Error(p.logger).Log("msg", "could not purge trash", "err", err)
+		return
+	}
+	if n > 0 {
+		level.Info(p.logger).Log("msg", "purged trash", "count", n)
+	}
+}
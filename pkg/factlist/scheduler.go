@@ -0,0 +1,105 @@
+package factlist
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/markhaur/trivia/pkg"
+)
+
+// Scheduler is a background worker that periodically looks for published
+// facts that went live or expired since its last run and emits a lifecycle
+// event for each of them
+type Scheduler struct {
+	repository pkg.FactRepository
+	publisher  pkg.EventPublisher
+	clock      pkg.Clock
+	interval   time.Duration
+	logger     log.Logger
+	// last is the time the previous run looked up to
+	last time.Time
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewScheduler returns a Scheduler reading the facts stored in repository
+// directly, as it isn't acting for any user
+func NewScheduler(repository pkg.FactRepository, publisher pkg.EventPublisher, clock pkg.Clock, interval time.Duration, logger log.Logger) *Scheduler {
+	return &Scheduler{
+		repository: repository,
+		publisher:  publisher,
+		clock:      clock,
+		interval:   interval,
+		logger:     logger,
+		last:       clock.Now(),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+}
+
+// Run emits the events scheduled since the previous run every interval until
+// Shutdown is called or ctx is done. Facts that went live or expired before
+// the scheduler was created are not reported.
+func (s *Scheduler) Run(ctx context.Context) error {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.Emit(ctx)
+		case <-s.stop:
+			return nil
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// Shutdown stops the scheduler and waits for a run in progress to finish
+func (s *Scheduler) Shutdown(ctx context.Context) error {
+	close(s.stop)
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Emit publishes the events scheduled since the previous run, as Run does
+// every interval. It must not be called while Run is running.
+func (s *Scheduler) Emit(ctx context.Context) {
+	ctx = pkg.WithAuthor(ctx, "scheduler")
+	now := s.clock.Now()
+	list, err := s.repository.FindAll(ctx)
+	if err != nil {
+		level.Error(s.logger).Log("msg", "could not list scheduled facts", "err", err)
+		return
+	}
+
+	for _, event := range pkg.ScheduledEvents(list, s.last, now) {
+		if err := s.publisher.Publish(ctx, event); err != nil {
+			level.Error(s.logger).Log("msg", "could not publish lifecycle event", "event", event.Type, "id", event.FactID, "err", err)
+		}
+	}
+	s.last = now
+}
+
+// LogPublisher returns an EventPublisher writing every lifecycle event to logger
+func LogPublisher(logger log.Logger) pkg.EventPublisher {
+	return logPublisher{logger}
+}
+
+type logPublisher struct {
+	logger log.Logger
+}
+
+func (p logPublisher) Publish(_ context.Context, event pkg.LifecycleEvent) error {
+	return level.Info(p.logger).Log("msg", "fact lifecycle event", "event", event.Type, "id", event.FactID, "at", event.At)
+}
@@ -0,0 +1,184 @@
+package factlist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/markhaur/trivia/pkg"
+)
+
+var ErrSearchDisabled = errors.New("fact search is not enabled")
+
+// Search is only available through IndexingMiddleware
+func (s *service) Search(_ context.Context, _ string, _ int) ([]pkg.SearchResult, error) {
+	return nil, ErrSearchDisabled
+}
+
+// IndexingMiddleware keeps index in sync with the published facts of the
+// wrapped Service and answers searches from it, telling with clock which
+// scheduled facts are live as the Service does. Index failures are logged to
+// logger rather than failing writes that have already been stored.
+func IndexingMiddleware(index pkg.SearchIndex, clock pkg.Clock, logger log.Logger) Middleware {
+	return func(s Service) Service { return &indexingMiddleware{index, clock, logger, s} }
+}
+
+type indexingMiddleware struct {
+	index  pkg.SearchIndex
+	clock  pkg.Clock
+	logger log.Logger
+	Service
+}
+
+// syncIndex indexes fact if players may see it, and drops it from index
+// otherwise. The fact has already been stored, so a failure is only logged;
+// the index catches up on the next write of the fact.
+func syncIndex(ctx context.Context, index pkg.SearchIndex, logger log.Logger, fact *pkg.Fact) {
+	if fact.Published() && !fact.Deleted() {
+		logIndexError(ctx, logger, fact.ID, index.Index(ctx, *fact))
+	} else {
+		unindex(ctx, index, logger, fact.ID)
+	}
+}
+
+// unindex drops the fact with the given ID from index, logging a failure
+func unindex(ctx context.Context, index pkg.SearchIndex, logger log.Logger, id int64) {
+	logIndexError(ctx, logger, id, index.Remove(ctx, id))
+}
+
+func logIndexError(ctx context.Context, logger log.Logger, id int64, err error) {
+	if err != nil {
+		level.Error(contextLogger(ctx, logger)).Log("msg", "could not index fact", "id", id, "err", err)
+	}
+}
+
+func (s *indexingMiddleware) Search(ctx context.Context, query string, limit int) ([]pkg.SearchResult, error) {
+	// scheduled facts are indexed once published but only found while live,
+	// so more results are asked for until limit of them are live or the index runs out
+	now := s.clock.Now()
+	for n := limit; ; n *= 2 {
+		results, err := s.index.Search(ctx, query, n)
+		if err != nil {
+			return nil, fmt.Errorf("could not search facts: %v", err)
+		}
+
+		live := make([]pkg.SearchResult, 0, len(results))
+		for _, result := range results {
+			if result.Fact.Live(now) {
+				live = append(live, result)
+			}
+			if len(live) == limit {
+				return live, nil
+			}
+		}
+		// a limit of zero already returned every result
+		if limit <= 0 || len(results) < n {
+			return live, nil
+		}
+	}
+}
+
+func (s *indexingMiddleware) Save(ctx context.Context, fact pkg.Fact) (*pkg.Fact, error) {
+	saved, err := s.Service.Save(ctx, fact)
+	if err != nil {
+		return nil, err
+	}
+	syncIndex(ctx, s.index, s.logger, saved)
+	return saved, nil
+}
+
+func (s *indexingMiddleware) Update(ctx context.Context, fact pkg.Fact) (*pkg.Fact, bool, error) {
+	updated, created, err := s.Service.Update(ctx, fact)
+	if err != nil {
+		return nil, false, err
+	}
+	syncIndex(ctx, s.index, s.logger, updated)
+	return updated, created, nil
+}
+
+func (s *indexingMiddleware) Replace(ctx context.Context, fact pkg.Fact) (*pkg.Fact, error) {
+	updated, err := s.Service.Replace(ctx, fact)
+	if err != nil {
+		return nil, err
+	}
+	syncIndex(ctx, s.index, s.logger, updated)
+	return updated, nil
+}
+
+func (s *indexingMiddleware) Patch(ctx context.Context, id int64, version int64, patch []byte) (*pkg.Fact, error) {
+	patched, err := s.Service.Patch(ctx, id, version, patch)
+	if err != nil {
+		return nil, err
+	}
+	syncIndex(ctx, s.index, s.logger, patched)
+	return patched, nil
+}
+
+func (s *indexingMiddleware) Remove(ctx context.Context, id int64, version int64) error {
+	if err := s.Service.Remove(ctx, id, version); err != nil {
+		return err
+	}
+	unindex(ctx, s.index, s.logger, id)
+	return nil
+}
+
+func (s *indexingMiddleware) Revert(ctx context.Context, id int64, revision int, version int64) (*pkg.Fact, error) {
+	reverted, err := s.Service.Revert(ctx, id, revision, version)
+	if err != nil {
+		return nil, err
+	}
+	syncIndex(ctx, s.index, s.logger, reverted)
+	return reverted, nil
+}
+
+func (s *indexingMiddleware) Restore(ctx context.Context, id int64, version int64) (*pkg.Fact, error) {
+	restored, err := s.Service.Restore(ctx, id, version)
+	if err != nil {
+		return nil, err
+	}
+	syncIndex(ctx, s.index, s.logger, restored)
+	return restored, nil
+}
+
+func (s *indexingMiddleware) Merge(ctx context.Context, id int64, duplicates []int64, version int64) (*pkg.Fact, error) {
+	kept, err := s.Service.Merge(ctx, id, duplicates, version)
+	if err != nil {
+		return nil, err
+	}
+	for _, duplicate := range duplicates {
+		unindex(ctx, s.index, s.logger, duplicate)
+	}
+	return kept, nil
+}
+
+// ModerationIndexingMiddleware keeps index in sync with the facts submitted
+// and reviewed through the wrapped ModerationService, logging index failures to logger
+func ModerationIndexingMiddleware(index pkg.SearchIndex, logger log.Logger) ModerationMiddleware {
+	return func(s ModerationService) ModerationService { return &moderationIndexingMiddleware{index, logger, s} }
+}
+
+type moderationIndexingMiddleware struct {
+	index  pkg.SearchIndex
+	logger log.Logger
+	ModerationService
+}
+
+func (s *moderationIndexingMiddleware) Submit(ctx context.Context, fact pkg.Fact) (*pkg.Fact, error) {
+	submitted, err := s.ModerationService.Submit(ctx, fact)
+	if err != nil {
+		return nil, err
+	}
+	syncIndex(ctx, s.index, s.logger, submitted)
+	return submitted, nil
+}
+
+func (s *moderationIndexingMiddleware) Transition(ctx context.Context, id int64, status pkg.Status, comment string, version int64) (*pkg.Fact, error) {
+	moved, err := s.ModerationService.Transition(ctx, id, status, comment, version)
+	if err != nil {
+		return nil, err
+	}
+	syncIndex(ctx, s.index, s.logger, moved)
+	return moved, nil
+}
@@ -2,66 +2,556 @@ package factlist
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/markhaur/trivia/pkg"
+	"github.com/markhaur/trivia/pkg/users"
 )
 
-// Service is an application service that lets us interact with a list of facts
+// Service is an application service that lets us interact with a list of facts.
+// Features built on top of the facts, such as moderation or collections, are
+// offered by their own services constructed with the same options.
 type Service interface {
 	Save(context.Context, pkg.Fact) (*pkg.Fact, error)
 	List(context.Context) ([]pkg.Fact, error)
+	Get(context.Context, int64) (*pkg.Fact, error)
 	Update(context.Context, pkg.Fact) (*pkg.Fact, bool, error)
-	Remove(context.Context, int64) error
+	Replace(context.Context, pkg.Fact) (*pkg.Fact, error)
+	Patch(ctx context.Context, id int64, version int64, patch []byte) (*pkg.Fact, error)
+	Remove(ctx context.Context, id int64, version int64) error
+	History(context.Context, int64) ([]pkg.Revision, error)
+	Revert(ctx context.Context, id int64, revision int, version int64) (*pkg.Fact, error)
+	Trash(context.Context) ([]pkg.Fact, error)
+	Restore(ctx context.Context, id int64, version int64) (*pkg.Fact, error)
+	Purge(ctx context.Context, before time.Time) (int, error)
+	ListByStatus(ctx context.Context, statuses ...pkg.Status) ([]pkg.Fact, error)
+	Random(context.Context) (*pkg.Fact, error)
+	Search(ctx context.Context, query string, limit int) ([]pkg.SearchResult, error)
+	Duplicates(context.Context, int64) ([]pkg.Duplicate, error)
+	DuplicateClusters(context.Context) ([]pkg.DuplicateCluster, error)
+	Merge(ctx context.Context, id int64, duplicates []int64, version int64) (*pkg.Fact, error)
+	SetTranslation(ctx context.Context, id int64, lang string, translation pkg.Translation, version int64) (*pkg.Fact, bool, error)
+	MissingTranslations(ctx context.Context, lang string) ([]pkg.Fact, error)
+	RecordSourceChecks(ctx context.Context, id int64, checks []pkg.SourceCheck) (*pkg.Fact, error)
+	BrokenSources(context.Context) ([]pkg.Fact, error)
+}
+
+var ErrHistoryDisabled = errors.New("fact history is not enabled")
+
+// anonymous is recorded as the author of revisions made without one in the context
+const anonymous = "anonymous"
+
+// authenticated returns the user who made the request, failing with
+// users.ErrUnauthenticated for anonymous requests
+func authenticated(ctx context.Context) (*pkg.User, error) {
+	user, ok := users.FromContext(ctx)
+	if !ok {
+		return nil, users.ErrUnauthenticated
+	}
+	return user, nil
 }
 
 // Middleware describes a Service Middleware
 type Middleware func(Service) Service
 
+// Option configures the services returned by NewService and the other
+// service constructors of the package
+type Option func(*service)
+
+// WithPolicy replaces the default rules facts are validated against
+func WithPolicy(policy pkg.FactPolicy) Option {
+	return func(s *service) { s.policy = policy }
+}
+
+// WithDuplicateDetection looks for facts asking the same question in
+// different words, warning about them or rejecting new ones depending on mode
+func WithDuplicateDetection(detector pkg.DuplicateDetector, mode pkg.DuplicateMode) Option {
+	return func(s *service) { s.detector, s.duplicateMode = detector, mode }
+}
+
+// WithDefaultLanguage sets the language facts saved without one are assumed
+// to be written in, English unless configured otherwise
+func WithDefaultLanguage(lang string) Option {
+	return func(s *service) { s.language = lang }
+}
+
+// WithClock replaces the system clock used to tell whether scheduled facts
+// are live and to timestamp what the services record
+func WithClock(clock pkg.Clock) Option {
+	return func(s *service) { s.clock = clock }
+}
+
+// WithHistory records a revision in history for every change made to a fact
+func WithHistory(history pkg.HistoryRepository) Option {
+	return func(s *service) { s.history = history }
+}
+
 type service struct {
-	repository pkg.FactRepository
+	repository  pkg.FactRepository
+	policy      pkg.FactPolicy
+	history     pkg.HistoryRepository
+	language    string
+	blobs       pkg.BlobStore
+	attachment  pkg.AttachmentPolicy
+	cards       pkg.CardRepository
+	analytics   pkg.AnalyticsSink
+	collections pkg.CollectionRepository
+	templates   pkg.SheetTemplateRepository
+	clock       pkg.Clock
+	reviewers   map[string]bool
+
+	detector      pkg.DuplicateDetector
+	duplicateMode pkg.DuplicateMode
+}
+
+func NewService(repository pkg.FactRepository, opts ...Option) Service {
+	return newService(repository, opts...)
+}
+
+// newService returns the implementation shared by the services of the
+// package. It only holds their configuration, every state being kept by the
+// repositories, so each service may be given its own.
+func newService(repository pkg.FactRepository, opts ...Option) *service {
+	s := &service{repository: repository, policy: pkg.DefaultFactPolicy(), language: "en", clock: pkg.SystemClock}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.policy.Clock == nil {
+		s.policy.Clock = s.clock
+	}
+	return s
 }
 
-func NewService(repository pkg.FactRepository) Service {
-	return &service{repository: repository}
+// validate normalizes fact and checks it against the policy and every stored fact
+func (s *service) validate(ctx context.Context, fact *pkg.Fact) error {
+	existing, err := s.repository.FindAll(ctx)
+	if err != nil {
+		return fmt.Errorf("could not list facts: %v", err)
+	}
+	s.policy.Normalize(fact)
+	return s.policy.Validate(*fact, existing)
 }
 
+// Save stores a new fact. Facts saved without a status wait for a reviewer
+// to publish them. Translations and attachments are added separately.
 func (s *service) Save(ctx context.Context, fact pkg.Fact) (*pkg.Fact, error) {
+	if fact.Status == "" {
+		fact.Status = pkg.StatusPendingReview
+	}
+	if !fact.Status.Valid() {
+		return nil, pkg.ErrInvalidStatus
+	}
+	if err := s.setLanguage(&fact); err != nil {
+		return nil, err
+	}
+	fact.Comments, fact.Translations, fact.Attachments = nil, nil, nil
+	fact.Sources = replaceSources(nil, fact.Sources)
+
+	if err := s.validate(ctx, &fact); err != nil {
+		return nil, err
+	}
+	if s.duplicateMode == pkg.DuplicatesReject {
+		if err := s.rejectDuplicates(ctx, fact); err != nil {
+			return nil, err
+		}
+	}
 	if err := s.repository.Insert(ctx, &fact); err != nil {
 		return nil, fmt.Errorf("could not save fact: %v", err)
 	}
+	if err := s.record(ctx, pkg.ActionSave, fact); err != nil {
+		return nil, err
+	}
 	return &fact, nil
 }
 
+// List returns the facts players may see: the published ones that are
+// within their publication window, if scheduled
 func (s *service) List(ctx context.Context) ([]pkg.Fact, error) {
-	list, err := s.repository.FindAll(ctx)
+	published, err := s.ListByStatus(ctx, pkg.StatusPublished)
 	if err != nil {
-		return nil, fmt.Errorf("could not list all facts: %v", err)
+		return nil, err
 	}
-	return list, nil
+
+	now := s.clock.Now()
+	live := make([]pkg.Fact, 0, len(published))
+	for _, fact := range published {
+		if fact.Live(now) {
+			live = append(live, fact)
+		}
+	}
+	return live, nil
 }
 
+// Get returns the fact with the given ID if it is live, as List would return
+// it. Only reviewers may get facts that are not or no longer published.
+func (s *service) Get(ctx context.Context, id int64) (*pkg.Fact, error) {
+	fact, err := s.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !fact.Live(s.clock.Now()) {
+		if _, err := s.reviewer(ctx); err != nil {
+			return nil, pkg.ErrFactNotFound
+		}
+	}
+	return fact, nil
+}
+
+// find returns the fact with the given ID whatever its status, for the
+// service's own use
+func (s *service) find(ctx context.Context, id int64) (*pkg.Fact, error) {
+	fact, err := s.repository.FindByID(ctx, id)
+	if err != nil {
+		if err == pkg.ErrFactNotFound {
+			return nil, err
+		}
+		return nil, fmt.Errorf("could not get fact: %v", err)
+	}
+	return fact, nil
+}
+
+// Update replaces the content of the fact with the same ID, or creates it for
+// review if it doesn't exist. A non-zero fact.Version is the version the
+// caller expects to replace. The workflow status of an existing fact is left untouched.
 func (s *service) Update(ctx context.Context, fact pkg.Fact) (*pkg.Fact, bool, error) {
-	err := s.repository.Update(ctx, &fact)
+	updated, err := s.overwrite(ctx, fact)
 	if err == pkg.ErrFactNotFound {
+		if fact.Version != 0 {
+			return nil, false, pkg.ErrVersionConflict
+		}
+		fact.Status, fact.Comments, fact.Translations, fact.Attachments = pkg.StatusPendingReview, nil, nil, nil
+		fact.Sources = replaceSources(nil, fact.Sources)
+		if err := s.setLanguage(&fact); err != nil {
+			return nil, false, err
+		}
+		if err := s.validate(ctx, &fact); err != nil {
+			return nil, false, err
+		}
 		err = s.repository.Insert(ctx, &fact)
+		if err == pkg.ErrFactAlreadyExists {
+			return nil, false, err
+		}
 		if err != nil {
 			return nil, false, fmt.Errorf("could not create fact: %v", err)
 		}
+		if err := s.record(ctx, pkg.ActionSave, fact); err != nil {
+			return nil, false, err
+		}
 		return &fact, true, nil
 	}
 	if err != nil {
+		if _, ok := err.(*pkg.ValidationError); ok || err == pkg.ErrVersionConflict || denied(err) {
+			return nil, false, err
+		}
 		return nil, false, fmt.Errorf("could not update fact: %v", err)
 	}
-	return &fact, false, nil
+	if err := s.record(ctx, pkg.ActionUpdate, *updated); err != nil {
+		return nil, false, err
+	}
+	return updated, false, nil
 }
 
-func (s *service) Remove(ctx context.Context, id int64) error {
-	if err := s.repository.DeleteByID(ctx, id); err != nil {
-		if err == pkg.ErrFactNotFound {
+// Replace replaces the content of an existing fact like Update does, but
+// fails with ErrFactNotFound instead of creating a missing fact
+func (s *service) Replace(ctx context.Context, fact pkg.Fact) (*pkg.Fact, error) {
+	updated, err := s.overwrite(ctx, fact)
+	if err != nil {
+		if _, ok := err.(*pkg.ValidationError); ok || err == pkg.ErrVersionConflict || err == pkg.ErrFactNotFound || denied(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("could not update fact: %v", err)
+	}
+	if err := s.record(ctx, pkg.ActionUpdate, *updated); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// setLanguage canonicalizes the language of fact, defaulting it to the
+// language of the service
+func (s *service) setLanguage(fact *pkg.Fact) error {
+	if fact.Language == "" {
+		fact.Language = s.language
+		return nil
+	}
+	lang, err := pkg.ParseLanguage(fact.Language)
+	if err != nil {
+		return err
+	}
+	fact.Language = lang
+	return nil
+}
+
+// overwrite atomically replaces the question, answer, category, difficulty,
+// sources, schedule and creation time of the stored fact with the same ID as
+// fact, if the user who made the request may change it
+func (s *service) overwrite(ctx context.Context, fact pkg.Fact) (*pkg.Fact, error) {
+	existing, err := s.repository.FindAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not list facts: %v", err)
+	}
+
+	return s.repository.Modify(ctx, fact.ID, fact.Version, func(stored *pkg.Fact) error {
+		if err := s.mayChange(ctx, *stored); err != nil {
+			return err
+		}
+		stored.Question, stored.Answer, stored.CreatedAt = fact.Question, fact.Answer, fact.CreatedAt
+		stored.Sources = replaceSources(stored.Sources, fact.Sources)
+		stored.PublishAt, stored.ExpireAt = fact.PublishAt, fact.ExpireAt
+		stored.Category, stored.Difficulty = fact.Category, fact.Difficulty
+		s.policy.Normalize(stored)
+		return s.policy.Validate(*stored, existing)
+	})
+}
+
+// Patch applies an RFC 7396 JSON Merge Patch to the fact with the given ID.
+// The fact is read, patched and validated atomically by the repository.
+func (s *service) Patch(ctx context.Context, id int64, version int64, patch []byte) (*pkg.Fact, error) {
+	existing, err := s.repository.FindAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not list facts: %v", err)
+	}
+
+	fact, err := s.repository.Modify(ctx, id, version, func(fact *pkg.Fact) error {
+		if err := s.mayChange(ctx, *fact); err != nil {
+			return err
+		}
+		if err := applyMergePatch(fact, patch); err != nil {
+			return err
+		}
+		s.policy.Normalize(fact)
+		return s.policy.Validate(*fact, existing)
+	})
+	if err != nil {
+		switch err.(type) {
+		case ErrInvalidPatch, *pkg.ValidationError:
+			return nil, err
+		}
+		if err == pkg.ErrFactNotFound || err == pkg.ErrVersionConflict || denied(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("could not patch fact: %v", err)
+	}
+	if err := s.record(ctx, pkg.ActionUpdate, *fact); err != nil {
+		return nil, err
+	}
+	return fact, nil
+}
+
+// Remove deletes the fact with the given ID. A non-zero version is the
+// version the caller expects to delete. Only reviewers may remove published facts.
+func (s *service) Remove(ctx context.Context, id int64, version int64) error {
+	removed, err := s.find(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.mayChange(ctx, *removed); err != nil {
+		return err
+	}
+
+	if err := s.repository.DeleteByID(ctx, id, version); err != nil {
+		if err == pkg.ErrFactNotFound || err == pkg.ErrVersionConflict {
 			return err
 		}
 		return fmt.Errorf("could not remove fact: %v", err)
 	}
+	return s.record(ctx, pkg.ActionRemove, *removed)
+}
+
+// History lists the revisions of the fact with the given ID. Only reviewers
+// may read the history of facts players don't see.
+func (s *service) History(ctx context.Context, id int64) ([]pkg.Revision, error) {
+	if s.history == nil {
+		return nil, ErrHistoryDisabled
+	}
+	if _, err := s.reviewer(ctx); err != nil {
+		if _, err := s.Get(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+
+	revisions, err := s.history.FindByFactID(ctx, id)
+	if err != nil {
+		if err == pkg.ErrFactNotFound {
+			return nil, err
+		}
+		return nil, fmt.Errorf("could not list revisions: %v", err)
+	}
+	return revisions, nil
+}
+
+// Revert restores the content of the fact with the given ID to the state
+// recorded in a revision, taking it out of the trash or recreating it for
+// review if it has been removed since. A non-zero version is the version the
+// caller expects to replace. Only reviewers may revert published facts.
+func (s *service) Revert(ctx context.Context, id int64, number int, version int64) (*pkg.Fact, error) {
+	if s.history == nil {
+		return nil, ErrHistoryDisabled
+	}
+
+	revision, err := s.history.FindRevision(ctx, id, number)
+	if err != nil {
+		if err == pkg.ErrRevisionNotFound {
+			return nil, err
+		}
+		return nil, fmt.Errorf("could not find revision: %v", err)
+	}
+
+	content := revision.Fact
+	// attachments are never reverted as their content may have been deleted since
+	content.Version, content.DeletedAt, content.Comments, content.Attachments = version, time.Time{}, nil, nil
+	if err := s.validate(ctx, &content); err != nil {
+		return nil, err
+	}
+
+	fact, err := s.overwrite(ctx, content)
+	if err == pkg.ErrFactNotFound {
+		if version != 0 {
+			return nil, pkg.ErrVersionConflict
+		}
+		// the fact has been removed since; take it out of the trash or recreate it if purged
+		var removed *pkg.Fact
+		removed, err = s.trashed(ctx, id)
+		switch err {
+		case nil:
+			if err = s.mayChange(ctx, *removed); err == nil {
+				_, err = s.repository.Restore(ctx, id, 0)
+			}
+			if err == nil {
+				fact, err = s.overwrite(ctx, content)
+			}
+		case pkg.ErrFactNotFound:
+			content.Status = pkg.StatusPendingReview
+			fact, err = &content, s.repository.Insert(ctx, &content)
+		}
+	}
+	if err != nil {
+		if _, ok := err.(*pkg.ValidationError); ok || err == pkg.ErrVersionConflict || denied(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("could not revert fact: %v", err)
+	}
+
+	if err := s.record(ctx, pkg.ActionRevert, *fact); err != nil {
+		return nil, err
+	}
+	return fact, nil
+}
+
+// Trash lists the facts that have been removed but not yet purged. Only
+// reviewers may list them.
+func (s *service) Trash(ctx context.Context) ([]pkg.Fact, error) {
+	if _, err := s.reviewer(ctx); err != nil {
+		return nil, err
+	}
+
+	list, err := s.repository.FindDeleted(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not list removed facts: %v", err)
+	}
+	return list, nil
+}
+
+// trashed returns the removed fact with the given ID
+func (s *service) trashed(ctx context.Context, id int64) (*pkg.Fact, error) {
+	trash, err := s.repository.FindDeleted(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not list removed facts: %v", err)
+	}
+	for _, removed := range trash {
+		if removed.ID == id {
+			return &removed, nil
+		}
+	}
+	return nil, pkg.ErrFactNotFound
+}
+
+// Restore takes a removed fact out of the trash. A non-zero version is the
+// version the caller expects to restore. Only reviewers may restore
+// published facts.
+func (s *service) Restore(ctx context.Context, id int64, version int64) (*pkg.Fact, error) {
+	removed, err := s.trashed(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.mayChange(ctx, *removed); err != nil {
+		return nil, err
+	}
+	if err := s.validate(ctx, removed); err != nil {
+		return nil, err
+	}
+
+	fact, err := s.repository.Restore(ctx, id, version)
+	if err != nil {
+		if err == pkg.ErrFactNotFound || err == pkg.ErrVersionConflict {
+			return nil, err
+		}
+		return nil, fmt.Errorf("could not restore fact: %v", err)
+	}
+	if err := s.record(ctx, pkg.ActionRestore, *fact); err != nil {
+		return nil, err
+	}
+	return fact, nil
+}
+
+// Purge permanently removes the facts that were moved to the trash before the
+// given time and returns how many were purged
+func (s *service) Purge(ctx context.Context, before time.Time) (int, error) {
+	purged, err := s.repository.Purge(ctx, before)
+	if err != nil {
+		return 0, fmt.Errorf("could not purge facts: %v", err)
+	}
+	for _, fact := range purged {
+		if err := s.deleteBlobs(ctx, fact.Attachments); err != nil {
+			return len(purged), err
+		}
+		if err := s.record(ctx, pkg.ActionPurge, fact); err != nil {
+			return len(purged), err
+		}
+	}
+	return len(purged), nil
+}
+
+// record appends a revision of fact to the history, if one is configured,
+// listing extra changes after the ones found by comparing it to the previous revision
+func (s *service) record(ctx context.Context, action string, fact pkg.Fact, extra ...pkg.Change) error {
+	if s.history == nil {
+		return nil
+	}
+
+	var previous pkg.Fact
+	revisions, err := s.history.FindByFactID(ctx, fact.ID)
+	switch {
+	case err == nil:
+		previous = revisions[len(revisions)-1].Fact
+	case err != pkg.ErrFactNotFound:
+		return fmt.Errorf("could not list revisions: %v", err)
+	}
+
+	changes := pkg.Diff(previous, fact)
+	switch action {
+	case pkg.ActionRemove, pkg.ActionPurge:
+		changes = pkg.Diff(fact, pkg.Fact{})
+	}
+	changes = append(changes, extra...)
+
+	author := pkg.AuthorFromContext(ctx)
+	if author == "" {
+		author = anonymous
+	}
+
+	revision := pkg.Revision{
+		FactID:    fact.ID,
+		Action:    action,
+		Author:    author,
+		Timestamp: s.clock.Now(),
+		Changes:   changes,
+		Fact:      fact,
+	}
+	if err := s.history.Append(ctx, &revision); err != nil {
+		return fmt.Errorf("could not record revision: %v", err)
+	}
 	return nil
 }
@@ -2,8 +2,15 @@ package factlist_test
 
 import (
 	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/go-kit/log"
 	"github.com/markhaur/trivia/pkg"
 	"github.com/markhaur/trivia/pkg/factlist"
 	"github.com/markhaur/trivia/pkg/inmem"
@@ -26,6 +33,7 @@ func TestSave(t *testing.T) {
 	assert.Equal(savedFact.Question, fact.Question)
 	assert.Equal(savedFact.Answer, fact.Answer)
 	assert.NotNil(savedFact.CreatedAt)
+	assert.Equal(pkg.StatusPendingReview, savedFact.Status, "expected the fact to wait for review")
 }
 
 func TestList(t *testing.T) {
@@ -36,9 +44,9 @@ func TestList(t *testing.T) {
 	)
 
 	expected := []pkg.Fact{
-		{Question: "what is your github username?", Answer: "markhaur"},
-		{Question: "what's your favourite language?", Answer: "Go"},
-		{Question: "what's the name of current project?", Answer: "trivia"},
+		{Question: "what is your github username?", Answer: "markhaur", Status: pkg.StatusPublished},
+		{Question: "what's your favourite language?", Answer: "Go", Status: pkg.StatusPublished},
+		{Question: "what's the name of current project?", Answer: "trivia", Status: pkg.StatusPublished},
 	}
 
 	for _, fact := range expected {
@@ -48,6 +56,7 @@ func TestList(t *testing.T) {
 
 	list, err := svc.List(context.TODO())
 	require.NoError(err, "could not list facts")
+	require.Len(list, len(expected), "unexpected number of facts")
 
 	for i := range list {
 		assert.Positive(list[i].ID)
@@ -57,19 +66,75 @@ func TestList(t *testing.T) {
 	}
 }
 
+func TestGet(t *testing.T) {
+	var (
+		require = require.New(t)
+		start   = time.Date(2026, time.October, 31, 0, 0, 0, 0, time.UTC)
+		clock   = &fakeClock{}
+		svc     = factlist.NewService(inmem.NewFactRepository(), factlist.WithClock(clock), factlist.WithReviewers("editor"))
+	)
+	clock.Set(start)
+
+	for _, fact := range []pkg.Fact{
+		{Question: "what is the capital of Germany?", Answer: "Berlin", Status: pkg.StatusPublished},
+		{Question: "what is the capital of Austria?", Answer: "Vienna"},
+		{Question: "what is the capital of France?", Answer: "Paris", Status: pkg.StatusPublished, PublishAt: start.Add(time.Hour)},
+		{Question: "what is the capital of Italy?", Answer: "Rome", Status: pkg.StatusPublished, ExpireAt: start.Add(time.Hour)},
+	} {
+		_, err := svc.Save(context.TODO(), fact)
+		require.NoError(err, "could not save fact")
+	}
+	clock.Add(2 * time.Hour)
+
+	tt := []struct {
+		Name          string
+		ID            int64
+		User          string
+		ExpectedError error
+	}{
+		{Name: "Returns a live fact to anyone", ID: 1},
+		{Name: "Hides a fact pending review", ID: 2, ExpectedError: pkg.ErrFactNotFound},
+		{Name: "Hides a fact pending review from players", ID: 2, User: "player", ExpectedError: pkg.ErrFactNotFound},
+		{Name: "Hides an expired fact", ID: 4, ExpectedError: pkg.ErrFactNotFound},
+		{Name: "Returns a fact pending review to reviewers", ID: 2, User: "editor"},
+		{Name: "Returns an expired fact to reviewers", ID: 4, User: "editor"},
+		{Name: "Returns a fact gone live since", ID: 3},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			ctx := context.TODO()
+			if tc.User != "" {
+				ctx = authenticatedAs(ctx, tc.User)
+			}
+			fact, err := svc.Get(ctx, tc.ID)
+			if tc.ExpectedError != nil {
+				assert.Equal(t, tc.ExpectedError, err, "unexpected error")
+				return
+			}
+			require.NoError(err, "could not get fact")
+			assert.Equal(t, tc.ID, fact.ID, "unexpected fact")
+		})
+	}
+}
+
 func TestRemove(t *testing.T) {
 	var (
 		require = require.New(t)
 		assert  = assert.New(t)
-		svc     = factlist.NewService(inmem.NewFactRepository())
+		svc     = factlist.NewService(inmem.NewFactRepository(), factlist.WithReviewers("reviewer"))
 	)
 
-	fact, err := svc.Save(context.TODO(), pkg.Fact{Question: "what is your github username?", Answer: "markhaur"})
+	fact, err := svc.Save(context.TODO(), pkg.Fact{Question: "what is your github username?", Answer: "markhaur", Status: pkg.StatusPublished})
 	require.NoError(err, "could not save fact")
 
-	require.NoError(svc.Remove(context.TODO(), fact.ID), "could not remove fact")
-
 	list, err := svc.List(context.TODO())
+	require.NoError(err, "could not list facts")
+	require.NotEmpty(list, "expected the saved fact to be listed before removing it")
+
+	require.NoError(svc.Remove(authenticatedAs(context.TODO(), "reviewer"), fact.ID, 0), "could not remove fact")
+
+	list, err = svc.List(context.TODO())
 	assert.NoError(err, "could not list facts")
 	assert.Empty(list, "expected list to be empty after removing fact")
 }
@@ -78,16 +143,16 @@ func TestUpdate(t *testing.T) {
 	var (
 		require = require.New(t)
 		assert  = assert.New(t)
-		svc     = factlist.NewService(inmem.NewFactRepository())
+		svc     = factlist.NewService(inmem.NewFactRepository(), factlist.WithReviewers("reviewer"))
 	)
 
-	fact, err := svc.Save(context.TODO(), pkg.Fact{Question: "what is your github username?", Answer: "I don't know"})
+	fact, err := svc.Save(context.TODO(), pkg.Fact{Question: "what is your github username?", Answer: "I don't know", Status: pkg.StatusPublished})
 	require.NoError(err, "could not save fact")
 
 	fact.Question = "have you figured out your username?"
 	fact.Answer = "yeah yeah, it's markhaur :p"
 
-	fact, isCreated, err := svc.Update(context.TODO(), *fact)
+	fact, isCreated, err := svc.Update(authenticatedAs(context.TODO(), "reviewer"), *fact)
 	require.NoError(err, "could not update fact")
 	assert.False(isCreated)
 	assert.NotNil(fact)
@@ -100,3 +165,573 @@ func TestUpdate(t *testing.T) {
 	assert.Equal(list[0].Answer, fact.Answer, "expected Answer to be updated")
 	assert.Equal(list[0].CreatedAt, fact.CreatedAt, "expected CreatedAt to be match")
 }
+
+func TestUpdateVersionConflict(t *testing.T) {
+	var (
+		require = require.New(t)
+		assert  = assert.New(t)
+		svc     = factlist.NewService(inmem.NewFactRepository())
+	)
+
+	fact, err := svc.Save(context.TODO(), pkg.Fact{Question: "what is your github username?", Answer: "markhaur"})
+	require.NoError(err, "could not save fact")
+	assert.Equal(int64(1), fact.Version)
+
+	updated, _, err := svc.Update(context.TODO(), *fact)
+	require.NoError(err, "could not update fact")
+	assert.Equal(int64(2), updated.Version, "expected version to be incremented")
+
+	_, _, err = svc.Update(context.TODO(), *fact)
+	assert.Equal(pkg.ErrVersionConflict, err, "expected stale update to be rejected")
+
+	err = svc.Remove(context.TODO(), fact.ID, fact.Version)
+	assert.Equal(pkg.ErrVersionConflict, err, "expected stale remove to be rejected")
+}
+
+func TestRevertPurgedFact(t *testing.T) {
+	var (
+		require  = require.New(t)
+		assert   = assert.New(t)
+		svc      = factlist.NewService(inmem.NewFactRepository(), factlist.WithHistory(inmem.NewHistoryRepository()), factlist.WithReviewers("reviewer"))
+		reviewer = authenticatedAs(context.TODO(), "reviewer")
+	)
+
+	fact, err := svc.Save(context.TODO(), pkg.Fact{Question: "what is the capital of Germany?", Answer: "Berlin", Status: pkg.StatusPublished})
+	require.NoError(err, "could not save fact")
+	require.NoError(svc.Remove(reviewer, fact.ID, 0), "could not remove fact")
+	_, err = svc.Purge(context.TODO(), time.Now().Add(time.Minute))
+	require.NoError(err, "could not purge facts")
+
+	reverted, err := svc.Revert(context.TODO(), fact.ID, 1, 0)
+	require.NoError(err, "could not revert fact")
+	assert.Equal(pkg.StatusPendingReview, reverted.Status, "expected the purged fact to be recreated for review")
+
+	list, err := svc.List(context.TODO())
+	require.NoError(err, "could not list facts")
+	assert.Empty(list, "expected the recreated fact to be hidden from players")
+}
+
+func TestTrashPurger(t *testing.T) {
+	tt := []struct {
+		Name          string
+		Retention     time.Duration
+		ExpectedTrash int
+	}{
+		{
+			Name:          "Purges facts removed before the retention period",
+			Retention:     0,
+			ExpectedTrash: 0,
+		},
+		{
+			Name:          "Keeps facts removed within the retention period",
+			Retention:     time.Hour,
+			ExpectedTrash: 1,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			var (
+				require  = require.New(t)
+				assert   = assert.New(t)
+				clock    = &fakeClock{}
+				svc      = factlist.NewService(inmem.NewFactRepository(inmem.WithClock(clock)), factlist.WithClock(clock), factlist.WithReviewers("reviewer"))
+				purger   = factlist.NewTrashPurger(svc, clock, tc.Retention, time.Hour, log.NewNopLogger())
+				reviewer = authenticatedAs(context.TODO(), "reviewer")
+			)
+			clock.Set(time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC))
+
+			fact, err := svc.Save(context.TODO(), pkg.Fact{Question: "what is your github username?", Answer: "markhaur"})
+			require.NoError(err, "could not save fact")
+			require.NoError(svc.Remove(context.TODO(), fact.ID, 0), "could not remove fact")
+
+			clock.Add(30 * time.Minute)
+			purger.Purge(context.TODO())
+
+			trash, err := svc.Trash(reviewer)
+			require.NoError(err, "could not list trash")
+			assert.Len(trash, tc.ExpectedTrash, "unexpected number of facts in trash")
+
+			_, err = svc.Restore(reviewer, fact.ID, 0)
+			if tc.ExpectedTrash == 0 {
+				assert.Equal(pkg.ErrFactNotFound, err, "expected purged fact to be gone")
+			} else {
+				assert.NoError(err, "expected fact to be restorable")
+			}
+		})
+	}
+}
+
+func TestUpdateKeepsStatus(t *testing.T) {
+	var (
+		require    = require.New(t)
+		assert     = assert.New(t)
+		repo       = inmem.NewFactRepository()
+		svc        = factlist.NewService(repo, factlist.WithReviewers("reviewer"))
+		moderation = factlist.NewModerationService(repo, factlist.WithReviewers("reviewer"))
+	)
+
+	fact, err := moderation.Submit(context.TODO(), pkg.Fact{Question: "what is your github username?", Answer: "markhaur"})
+	require.NoError(err, "could not submit fact")
+
+	_, err = moderation.Transition(authenticatedAs(context.TODO(), "reviewer"), fact.ID, pkg.StatusRejected, "needs a source", 0)
+	require.NoError(err, "could not reject fact")
+
+	updated, _, err := svc.Update(context.TODO(), pkg.Fact{ID: fact.ID, Question: fact.Question, Answer: "Mark Haur"})
+	require.NoError(err, "could not update fact")
+	assert.Equal(pkg.StatusRejected, updated.Status, "expected update to keep the workflow status")
+	assert.Len(updated.Comments, 1, "expected update to keep review comments")
+
+	list, err := svc.List(context.TODO())
+	require.NoError(err, "could not list facts")
+	assert.Empty(list, "expected rejected fact to be hidden")
+}
+
+func TestDuplicateDetection(t *testing.T) {
+	detector := pkg.DuplicateDetector{Threshold: 0.6}
+	existing := pkg.Fact{Question: "What is the capital of France?", Answer: "Paris"}
+
+	tt := []struct {
+		Name               string
+		Mode               pkg.DuplicateMode
+		User               string
+		Question           string
+		ExpectedErr        string
+		ExpectedDuplicates []int64
+	}{
+		{
+			Name:               "Warns about a rephrased question",
+			Mode:               pkg.DuplicatesWarn,
+			User:               "reviewer",
+			Question:           "What's France's capital city?",
+			ExpectedDuplicates: []int64{1},
+		},
+		{
+			Name:        "Rejects a rephrased question",
+			Mode:        pkg.DuplicatesReject,
+			User:        "reviewer",
+			Question:    "france: what is its capital?",
+			ExpectedErr: "invalid trivia: question is similar to trivia 1",
+		},
+		{
+			Name:        "Rejects a rephrased question without naming a fact hidden from the user",
+			Mode:        pkg.DuplicatesReject,
+			Question:    "france: what is its capital?",
+			ExpectedErr: "invalid trivia: question is similar to another trivia",
+		},
+		{
+			Name:               "Accepts a different question",
+			Mode:               pkg.DuplicatesReject,
+			User:               "reviewer",
+			Question:           "What is the capital of Germany?",
+			ExpectedDuplicates: []int64{},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			var (
+				require = require.New(t)
+				assert  = assert.New(t)
+				svc     = factlist.NewService(inmem.NewFactRepository(), factlist.WithDuplicateDetection(detector, tc.Mode), factlist.WithReviewers("reviewer"))
+				ctx     = context.TODO()
+			)
+			if tc.User != "" {
+				ctx = authenticatedAs(ctx, tc.User)
+			}
+
+			_, err := svc.Save(ctx, existing)
+			require.NoError(err, "could not save fact")
+
+			fact, err := svc.Save(ctx, pkg.Fact{Question: tc.Question, Answer: "Paris"})
+			if tc.ExpectedErr != "" {
+				assert.EqualError(err, tc.ExpectedErr)
+				return
+			}
+			require.NoError(err, "could not save fact")
+
+			duplicates, err := svc.Duplicates(ctx, fact.ID)
+			require.NoError(err, "could not find duplicates")
+			ids := []int64{}
+			for _, d := range duplicates {
+				ids = append(ids, d.Fact.ID)
+			}
+			assert.Equal(tc.ExpectedDuplicates, ids)
+		})
+	}
+
+	_, err := factlist.NewService(inmem.NewFactRepository()).DuplicateClusters(context.TODO())
+	assert.Equal(t, factlist.ErrDuplicateDetectionDisabled, err)
+}
+
+// racingRepository modifies a fact right before facts are merged
+type racingRepository struct {
+	pkg.FactRepository
+	modified int64
+}
+
+func (r racingRepository) Merge(ctx context.Context, id int64, version int64, duplicates map[int64]int64) (*pkg.Fact, error) {
+	if _, err := r.Modify(ctx, r.modified, 0, func(*pkg.Fact) error { return nil }); err != nil {
+		return nil, err
+	}
+	return r.FactRepository.Merge(ctx, id, version, duplicates)
+}
+
+func TestMergeIsAtomic(t *testing.T) {
+	var (
+		require = require.New(t)
+		assert  = assert.New(t)
+		repo    = inmem.NewFactRepository()
+		svc     = factlist.NewService(racingRepository{FactRepository: repo, modified: 3},
+			factlist.WithDuplicateDetection(pkg.DuplicateDetector{Threshold: 0.6}, pkg.DuplicatesWarn),
+			factlist.WithReviewers("reviewer"),
+		)
+	)
+
+	for _, question := range []string{"What is the capital of France?", "What's France's capital city?", "Capital of France?"} {
+		_, err := svc.Save(context.TODO(), pkg.Fact{Question: question, Answer: "Paris", Status: pkg.StatusPublished})
+		require.NoError(err, "could not save fact")
+	}
+
+	_, err := svc.Merge(authenticatedAs(context.TODO(), "reviewer"), 1, []int64{2, 3}, 0)
+	assert.Equal(pkg.ErrVersionConflict, err, "expected the modified duplicate to fail the merge")
+
+	list, err := repo.FindAll(context.TODO())
+	require.NoError(err, "could not list facts")
+	require.Len(list, 3, "expected no duplicate to be moved to the trash")
+	assert.Equal(int64(1), list[0].Version, "expected the kept fact to be left untouched")
+}
+
+// brokenIndex fails every change to the search index
+type brokenIndex struct{ pkg.SearchIndex }
+
+func (brokenIndex) Index(context.Context, pkg.Fact) error { return errors.New("index is down") }
+func (brokenIndex) Remove(context.Context, int64) error   { return errors.New("index is down") }
+
+func TestIndexingFailureKeepsWrite(t *testing.T) {
+	var (
+		require  = require.New(t)
+		assert   = assert.New(t)
+		repo     = inmem.NewFactRepository()
+		index    = brokenIndex{inmem.NewSearchIndex()}
+		svc      = factlist.IndexingMiddleware(index, pkg.SystemClock, log.NewNopLogger())(factlist.NewService(repo, factlist.WithReviewers("reviewer")))
+		reviewer = authenticatedAs(context.TODO(), "reviewer")
+	)
+
+	fact, err := svc.Save(context.TODO(), pkg.Fact{Question: "what is your github username?", Answer: "markhaur", Status: pkg.StatusPublished})
+	require.NoError(err, "expected the stored fact to be returned although it couldn't be indexed")
+	assert.Equal(int64(1), fact.ID, "unexpected fact id")
+
+	fact.Answer = "Mark Haur"
+	updated, _, err := svc.Update(reviewer, *fact)
+	require.NoError(err, "expected the stored fact to be returned although it couldn't be indexed")
+	assert.Equal("Mark Haur", updated.Answer, "unexpected answer")
+
+	assert.NoError(svc.Remove(reviewer, fact.ID, 0), "expected the fact to be removed although it couldn't be unindexed")
+	_, err = repo.FindByID(context.TODO(), fact.ID)
+	assert.Equal(pkg.ErrFactNotFound, err, "expected the fact to be in the trash")
+}
+
+func TestLinkChecker(t *testing.T) {
+	var (
+		require = require.New(t)
+		assert  = assert.New(t)
+		repo    = inmem.NewFactRepository()
+		svc     = factlist.NewService(repo)
+		moved   int32
+	)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/get-only", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&moved) == 1 {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	fact, err := svc.Save(context.TODO(), pkg.Fact{
+		Question: "what is the capital of Germany?",
+		Answer:   "Berlin",
+		Sources:  []pkg.Source{{URL: server.URL + "/ok"}, {URL: server.URL + "/get-only"}, {URL: server.URL + "/moved"}},
+		Status:   pkg.StatusPublished,
+	})
+	require.NoError(err, "could not save fact")
+	_, err = svc.Save(context.TODO(), pkg.Fact{
+		Question: "what is the capital of Austria?",
+		Answer:   "Vienna",
+		Sources:  []pkg.Source{{URL: server.URL + "/moved"}},
+		Status:   pkg.StatusPublished,
+	})
+	require.NoError(err, "could not save fact")
+
+	checker := factlist.NewLinkChecker(repo, svc, server.Client(), time.Hour, log.NewNopLogger())
+	check := func() []pkg.Fact {
+		checker.Check(context.TODO())
+		broken, err := svc.BrokenSources(context.TODO())
+		require.NoError(err, "could not list broken sources")
+		return broken
+	}
+
+	assert.Empty(check(), "expected every source to work")
+
+	atomic.StoreInt32(&moved, 1)
+	broken := check()
+	require.Len(broken, 2, "expected both facts citing the moved page to be flagged")
+	sources := broken[0].Sources
+	require.Len(sources, 3, "unexpected number of sources")
+	assert.False(sources[0].Broken(), "expected the working source not to be flagged")
+	assert.False(sources[1].Broken(), "expected a source only answering GET not to be flagged")
+	assert.Equal("server responded with 404 Not Found", sources[2].Problem, "unexpected problem")
+	assert.False(sources[2].BrokenSince.IsZero(), "expected the time the source broke")
+
+	updated, err := svc.Get(context.TODO(), fact.ID)
+	require.NoError(err, "could not get fact")
+	version := updated.Version
+	assert.Len(check(), 2, "expected the moved page to still be flagged")
+	updated, err = svc.Get(context.TODO(), fact.ID)
+	require.NoError(err, "could not get fact")
+	assert.Equal(version, updated.Version, "expected the fact not to be written when nothing changed")
+
+	atomic.StoreInt32(&moved, 0)
+	assert.Empty(check(), "expected the fixed source to be cleared")
+}
+
+func TestLinkCheckClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	tt := []struct {
+		Name string
+		URL  string
+	}{
+		{Name: "Refuses loopback addresses", URL: server.URL},
+		{Name: "Refuses IPv6 loopback addresses", URL: "http://[::1]/"},
+		{Name: "Refuses the cloud metadata endpoint", URL: "http://169.254.169.254/latest/meta-data/"},
+		{Name: "Refuses private networks", URL: "http://10.0.0.1/"},
+		{Name: "Refuses the unspecified address", URL: "http://0.0.0.0/"},
+	}
+
+	client := factlist.NewLinkCheckClient(time.Second)
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			_, err := client.Get(tc.URL)
+			require.Error(t, err, "expected the request to be refused")
+			assert.Contains(t, err.Error(), "source doesn't resolve to a public address")
+		})
+	}
+}
+
+func TestConcurrentGrades(t *testing.T) {
+	var (
+		require = require.New(t)
+		assert  = assert.New(t)
+		repo    = inmem.NewFactRepository()
+		svc     = factlist.NewService(repo)
+		study   = factlist.NewStudyService(repo, factlist.WithStudy(inmem.NewCardRepository()))
+		wg      sync.WaitGroup
+	)
+
+	fact, err := svc.Save(context.TODO(), pkg.Fact{Question: "what is the capital of Germany?", Answer: "Berlin", Status: pkg.StatusPublished})
+	require.NoError(err, "could not save fact")
+
+	const reviews = 50
+	for i := 0; i < reviews; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := study.Grade(context.TODO(), "alice", fact.ID, pkg.GradePerfect)
+			assert.NoError(err, "could not grade card")
+		}()
+	}
+	wg.Wait()
+
+	stats, err := study.StudyStats(context.TODO(), "alice")
+	require.NoError(err, "could not get stats")
+	assert.Equal(reviews, stats.Reviews, "expected no review to be lost")
+}
+
+func TestAnalyticsKeepsLatestAttempts(t *testing.T) {
+	var (
+		require   = require.New(t)
+		assert    = assert.New(t)
+		repo      = inmem.NewFactRepository()
+		svc       = factlist.NewService(repo)
+		analytics = factlist.NewAnalyticsService(repo, factlist.WithAnalytics(inmem.NewAnalyticsSink(3)))
+	)
+
+	fact, err := svc.Save(context.TODO(), pkg.Fact{Question: "what is the capital of Australia?", Answer: "Canberra", Status: pkg.StatusPublished})
+	require.NoError(err, "could not save fact")
+
+	for _, answer := range []string{"Sydney", "Melbourne", "Canberra", "Canberra", "Perth"} {
+		_, _, err := analytics.RecordAnswer(context.TODO(), fact.ID, answer, time.Second)
+		require.NoError(err, "could not record answer")
+	}
+
+	stats, err := analytics.FactStats(context.TODO(), fact.ID)
+	require.NoError(err, "could not get stats")
+	assert.Equal(3, stats.Attempts, "expected the oldest attempts to be evicted")
+	assert.Equal(2, stats.Correct, "unexpected number of correct attempts")
+	assert.Equal([]pkg.AnswerCount{{Answer: "perth", Count: 1}}, stats.WrongAnswers, "expected only the latest wrong answer")
+}
+
+// fakeClock is a pkg.Clock whose time is set by the test
+type fakeClock struct{ now int64 }
+
+func (c *fakeClock) Now() time.Time      { return time.Unix(0, atomic.LoadInt64(&c.now)).UTC() }
+func (c *fakeClock) Set(t time.Time)     { atomic.StoreInt64(&c.now, t.UnixNano()) }
+func (c *fakeClock) Add(d time.Duration) { atomic.AddInt64(&c.now, int64(d)) }
+
+type eventRecorder chan pkg.LifecycleEvent
+
+func (r eventRecorder) Publish(_ context.Context, event pkg.LifecycleEvent) error {
+	r <- event
+	return nil
+}
+
+func TestTimestampsFollowClock(t *testing.T) {
+	var (
+		require    = require.New(t)
+		assert     = assert.New(t)
+		start      = time.Date(2026, time.February, 2, 0, 0, 0, 0, time.UTC)
+		clock      = &fakeClock{}
+		repo       = inmem.NewFactRepository(inmem.WithClock(clock))
+		opts       = []factlist.Option{factlist.WithClock(clock), factlist.WithHistory(inmem.NewHistoryRepository()), factlist.WithReviewers("reviewer")}
+		svc        = factlist.NewService(repo, opts...)
+		moderation = factlist.NewModerationService(repo, opts...)
+		reviewer   = authenticatedAs(context.TODO(), "reviewer")
+	)
+	clock.Set(start)
+
+	fact, err := svc.Save(context.TODO(), pkg.Fact{Question: "what is the capital of Germany?", Answer: "Berlin"})
+	require.NoError(err, "could not save fact")
+	clock.Add(time.Hour)
+	fact, err = moderation.Transition(reviewer, fact.ID, pkg.StatusPublished, "", 0)
+	require.NoError(err, "could not publish fact")
+	assert.Equal(start.Add(time.Hour), fact.Comments[0].CreatedAt, "expected the review to be timestamped by the clock")
+
+	clock.Add(time.Hour)
+	require.NoError(svc.Remove(reviewer, fact.ID, 0), "could not remove fact")
+	trash, err := svc.Trash(reviewer)
+	require.NoError(err, "could not list trash")
+	require.Len(trash, 1, "expected the removed fact in the trash")
+	assert.Equal(start.Add(2*time.Hour), trash[0].DeletedAt, "expected the removal to be timestamped by the clock")
+
+	revisions, err := svc.History(reviewer, fact.ID)
+	require.NoError(err, "could not list revisions")
+	require.Len(revisions, 3, "expected a revision per change")
+	for i, revision := range revisions {
+		assert.Equal(start.Add(time.Duration(i)*time.Hour), revision.Timestamp, "expected revision %d to be timestamped by the clock", i+1)
+	}
+}
+
+func TestScheduledVisibility(t *testing.T) {
+	var (
+		require = require.New(t)
+		assert  = assert.New(t)
+		start   = time.Date(2026, time.October, 31, 0, 0, 0, 0, time.UTC)
+		clock   = &fakeClock{}
+		svc     = factlist.NewService(inmem.NewFactRepository(), factlist.WithClock(clock))
+	)
+	clock.Set(start)
+
+	_, err := svc.Save(context.TODO(), pkg.Fact{Question: "what is the capital of Germany?", Answer: "Berlin", Status: pkg.StatusPublished})
+	require.NoError(err, "could not save fact")
+	halloween, err := svc.Save(context.TODO(), pkg.Fact{
+		Question:  "which vegetable were jack-o'-lanterns first carved from?",
+		Answer:    "Turnips",
+		Status:    pkg.StatusPublished,
+		PublishAt: start.Add(time.Hour),
+		ExpireAt:  start.Add(24 * time.Hour),
+	})
+	require.NoError(err, "could not save fact")
+
+	ids := func() []int64 {
+		list, err := svc.List(context.TODO())
+		require.NoError(err, "could not list facts")
+		var ids []int64
+		for _, fact := range list {
+			ids = append(ids, fact.ID)
+		}
+		return ids
+	}
+
+	assert.Equal([]int64{1}, ids(), "expected the scheduled fact to be hidden before it goes live")
+	clock.Add(time.Hour)
+	assert.Equal([]int64{1, halloween.ID}, ids(), "expected the scheduled fact to be live")
+	clock.Add(23 * time.Hour)
+	assert.Equal([]int64{1}, ids(), "expected the scheduled fact to be hidden once expired")
+
+	for i := 0; i < 10; i++ {
+		fact, err := svc.Random(context.TODO())
+		require.NoError(err, "could not pick a random fact")
+		assert.NotEqual(halloween.ID, fact.ID, "expected an expired fact never to be picked")
+	}
+
+	_, err = svc.Save(context.TODO(), pkg.Fact{
+		Question:  "who won the first world cup?",
+		Answer:    "Uruguay",
+		PublishAt: start.Add(time.Hour),
+		ExpireAt:  start,
+	})
+	require.Error(err, "expected a fact expiring before it goes live to be rejected")
+	assert.EqualError(err, "invalid trivia: expireAt must be after publishAt")
+}
+
+func TestScheduler(t *testing.T) {
+	var (
+		require = require.New(t)
+		assert  = assert.New(t)
+		start   = time.Date(2026, time.June, 11, 0, 0, 0, 0, time.UTC)
+		clock   = &fakeClock{}
+		repo    = inmem.NewFactRepository()
+		svc     = factlist.NewService(repo, factlist.WithClock(clock))
+		events  = make(eventRecorder, 10)
+	)
+	clock.Set(start)
+
+	fact, err := svc.Save(context.TODO(), pkg.Fact{
+		Question:  "which country hosts the opening match of the world cup?",
+		Answer:    "Mexico",
+		Status:    pkg.StatusPublished,
+		PublishAt: start.Add(time.Hour),
+		ExpireAt:  start.Add(2 * time.Hour),
+	})
+	require.NoError(err, "could not save fact")
+	_, err = svc.Save(context.TODO(), pkg.Fact{
+		Question:  "how many teams play in the world cup?",
+		Answer:    "48",
+		Status:    pkg.StatusDraft,
+		PublishAt: start.Add(time.Hour),
+	})
+	require.NoError(err, "could not save fact")
+
+	scheduler := factlist.NewScheduler(repo, events, clock, time.Hour, log.NewNopLogger())
+
+	next := func() pkg.LifecycleEvent {
+		scheduler.Emit(context.TODO())
+		select {
+		case event := <-events:
+			return event
+		default:
+			require.FailNow("expected a lifecycle event")
+			return pkg.LifecycleEvent{}
+		}
+	}
+
+	clock.Add(time.Hour)
+	assert.Equal(pkg.LifecycleEvent{Type: pkg.EventFactLive, FactID: fact.ID, At: start.Add(time.Hour)}, next(), "expected only the published fact to go live")
+	assert.Empty(events, "expected only the published fact to go live")
+
+	clock.Add(time.Hour)
+	assert.Equal(pkg.LifecycleEvent{Type: pkg.EventFactExpired, FactID: fact.ID, At: start.Add(2 * time.Hour)}, next())
+
+	scheduler.Emit(context.TODO())
+	assert.Empty(events, "expected every event to be emitted once")
+}
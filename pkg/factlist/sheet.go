@@ -0,0 +1,379 @@
+package factlist
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"math/rand"
+	"regexp"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/markhaur/trivia/pkg"
+)
+
+var (
+	ErrUnsupportedFormat       = errors.New("format must be markdown, html or text")
+	ErrUnsupportedPart         = errors.New("part must be questions or answers")
+	ErrInvalidRandom           = errors.New("random must be a number between 1 and 200")
+	ErrSheetTemplatesDisabled  = errors.New("custom sheet templates are not enabled")
+	ErrNotSheetTemplateOwner   = errors.New("sheet template belongs to another author")
+	errMissingSheetDefinitions = errors.New(`template must define "questions" and "answers"`)
+	errSheetTooSlow            = fmt.Errorf("sheet must render within %v", maxSheetRenderTime)
+	errSheetTooLarge           = fmt.Errorf("sheet must be at most %d bytes", maxSheetSize)
+)
+
+// ErrInvalidSheetTemplate is returned when a custom sheet template can't be parsed or executed
+type ErrInvalidSheetTemplate struct{ err error }
+
+func (e ErrInvalidSheetTemplate) Error() string {
+	return fmt.Sprintf("invalid sheet template: %v", e.err)
+}
+
+// Sheet formats quiz sheets can be rendered in
+const (
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+	FormatText     = "text"
+)
+
+// Sheet parts that can be rendered on their own; both are rendered unless one is asked for
+const (
+	PartQuestions = "questions"
+	PartAnswers   = "answers"
+)
+
+const (
+	maxSheetTemplateSize = 64 << 10
+	// maxSheetQuestions bounds the number of questions of a quiz sheet
+	maxSheetQuestions = pkg.MaxCollectionFacts
+	// maxSheetRenderTime and maxSheetSize bound the work of custom templates,
+	// which may loop for as long as they like
+	maxSheetRenderTime = time.Second
+	maxSheetSize       = 2 << 20
+)
+
+var sheetTemplateName = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,49}$`)
+
+var sheetContentTypes = map[string]string{
+	FormatMarkdown: "text/markdown; charset=utf-8",
+	FormatHTML:     "text/html; charset=utf-8",
+	FormatText:     "text/plain; charset=utf-8",
+}
+
+//go:embed sheets/*.tmpl
+var sheetTemplates embed.FS
+
+// builtinSheets holds the renderers used unless a custom template is asked for, keyed by format
+var builtinSheets = map[string]*sheetRenderer{}
+
+func init() {
+	for format, file := range map[string]string{
+		FormatMarkdown: "sheets/markdown.tmpl",
+		FormatHTML:     "sheets/html.tmpl",
+		FormatText:     "sheets/text.tmpl",
+	} {
+		source, err := sheetTemplates.ReadFile(file)
+		if err != nil {
+			panic(err)
+		}
+		r, err := newSheetRenderer(file, format, string(source))
+		if err != nil {
+			panic(fmt.Sprintf("%s: %v", file, err))
+		}
+		builtinSheets[format] = r
+	}
+}
+
+// sheetRenderer executes the templates of a quiz sheet with the package
+// matching its format, html/template escaping the text of facts for HTML
+type sheetRenderer struct {
+	execute func(w io.Writer, name string, data interface{}) error
+	defined func(name string) bool
+}
+
+// newSheetRenderer parses source into a template called name, which must
+// differ from the templates source defines
+func newSheetRenderer(name, format, source string) (*sheetRenderer, error) {
+	var r sheetRenderer
+	switch format {
+	case FormatHTML:
+		t, err := htmltemplate.New(name).Parse(source)
+		if err != nil {
+			return nil, ErrInvalidSheetTemplate{err}
+		}
+		r.execute, r.defined = t.ExecuteTemplate, func(name string) bool { return t.Lookup(name) != nil }
+	case FormatMarkdown, FormatText:
+		t, err := texttemplate.New(name).Parse(source)
+		if err != nil {
+			return nil, ErrInvalidSheetTemplate{err}
+		}
+		r.execute, r.defined = t.ExecuteTemplate, func(name string) bool { return t.Lookup(name) != nil }
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+
+	if !r.defined(PartQuestions) || !r.defined(PartAnswers) {
+		return nil, ErrInvalidSheetTemplate{errMissingSheetDefinitions}
+	}
+	return &r, nil
+}
+
+// render writes part of sheet to w, or the whole sheet if part is empty. The
+// whole sheet is rendered by the "sheet" template if the source defines one,
+// or by the questions followed by the answers otherwise.
+func (r *sheetRenderer) render(w io.Writer, part string, sheet pkg.QuizSheet) error {
+	var err error
+	switch part {
+	case PartQuestions, PartAnswers:
+		err = r.execute(w, part, sheet)
+	case "":
+		if r.defined("sheet") {
+			err = r.execute(w, "sheet", sheet)
+			break
+		}
+		if err = r.execute(w, PartQuestions, sheet); err == nil {
+			err = r.execute(w, PartAnswers, sheet)
+		}
+	default:
+		return ErrUnsupportedPart
+	}
+	if err != nil {
+		return ErrInvalidSheetTemplate{err}
+	}
+	return nil
+}
+
+// RenderSheet writes part of sheet to w in the given format with the built-in
+// templates, the answer key on a page of its own when both parts are rendered
+func RenderSheet(w io.Writer, format, part string, sheet pkg.QuizSheet) error {
+	r, ok := builtinSheets[format]
+	if !ok {
+		return ErrUnsupportedFormat
+	}
+	return r.render(w, part, sheet)
+}
+
+// RenderCustomSheet writes part of sheet to w with a custom template. The
+// template fails if it takes longer than maxSheetRenderTime or writes more
+// than maxSheetSize bytes, and nothing is written to w then.
+func RenderCustomSheet(ctx context.Context, w io.Writer, tmpl pkg.SheetTemplate, part string, sheet pkg.QuizSheet) error {
+	r, err := newSheetRenderer(tmpl.Name, tmpl.Format, tmpl.Source)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, maxSheetRenderTime)
+	defer cancel()
+	out := &sheetWriter{ctx: ctx}
+	done := make(chan error, 1)
+	// templates can't be interrupted, so one that keeps looping without
+	// writing is left behind; it stops at its next write
+	go func() { done <- r.render(out, part, sheet) }()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return err
+		}
+		_, err = out.buf.WriteTo(w)
+		return err
+	case <-ctx.Done():
+		if ctx.Err() == context.DeadlineExceeded {
+			return ErrInvalidSheetTemplate{errSheetTooSlow}
+		}
+		return ctx.Err()
+	}
+}
+
+// sheetWriter buffers the output of a custom template, failing the template
+// once ctx is done or the output grows past maxSheetSize
+type sheetWriter struct {
+	ctx context.Context
+	buf bytes.Buffer
+}
+
+func (w *sheetWriter) Write(p []byte) (int, error) {
+	if w.ctx.Err() != nil {
+		return 0, errSheetTooSlow
+	}
+	if w.buf.Len()+len(p) > maxSheetSize {
+		return 0, errSheetTooLarge
+	}
+	return w.buf.Write(p)
+}
+
+// SheetService selects the facts of quiz sheets and keeps the custom
+// templates they may be rendered with
+type SheetService interface {
+	SelectFacts(context.Context, pkg.FactSelection) ([]pkg.Fact, error)
+	SaveSheetTemplate(context.Context, pkg.SheetTemplate) (*pkg.SheetTemplate, bool, error)
+	SheetTemplates(context.Context) ([]pkg.SheetTemplate, error)
+	SheetTemplate(ctx context.Context, name string) (*pkg.SheetTemplate, error)
+	RemoveSheetTemplate(ctx context.Context, name string) error
+}
+
+// SheetMiddleware describes a SheetService Middleware
+type SheetMiddleware func(SheetService) SheetService
+
+// NewSheetService returns a SheetService over the facts stored in repository,
+// configured by the same options as NewService. Facts are selected from
+// collections only if WithCollections is given, and custom templates are
+// kept only if WithSheetTemplates is given.
+func NewSheetService(repository pkg.FactRepository, opts ...Option) SheetService {
+	return newService(repository, opts...)
+}
+
+// WithSheetTemplates lets quiz hosts upload their own quiz sheet templates, stored in templates
+func WithSheetTemplates(templates pkg.SheetTemplateRepository) Option {
+	return func(s *service) { s.templates = templates }
+}
+
+// SelectFacts returns the facts players may see matching selection, in the
+// order of the selected collection or of their IDs, or in random order if
+// only some of them are picked at random
+func (s *service) SelectFacts(ctx context.Context, selection pkg.FactSelection) ([]pkg.Fact, error) {
+	category := strings.ToLower(strings.TrimSpace(selection.Category))
+	if !selection.Difficulty.Valid() {
+		return nil, pkg.ErrInvalidDifficulty
+	}
+	if selection.Random < 0 || selection.Random > maxSheetQuestions {
+		return nil, ErrInvalidRandom
+	}
+
+	var (
+		facts []pkg.Fact
+		err   error
+	)
+	if selection.CollectionID != 0 {
+		_, facts, err = s.GetCollection(ctx, selection.CollectionID)
+	} else {
+		facts, err = s.List(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	selected := make([]pkg.Fact, 0, len(facts))
+	for _, fact := range facts {
+		if category != "" && fact.Category != category {
+			continue
+		}
+		if selection.Difficulty != "" && fact.Difficulty != selection.Difficulty {
+			continue
+		}
+		selected = append(selected, fact)
+	}
+
+	limit := maxSheetQuestions
+	if selection.Random > 0 {
+		rand.Shuffle(len(selected), func(i, j int) { selected[i], selected[j] = selected[j], selected[i] })
+		limit = selection.Random
+	}
+	if len(selected) > limit {
+		selected = selected[:limit]
+	}
+	return selected, nil
+}
+
+// SaveSheetTemplate stores a custom quiz sheet template owned by the
+// authenticated user, replacing the one with the same name if the user owns
+// it. It reports whether the template was created.
+func (s *service) SaveSheetTemplate(ctx context.Context, tmpl pkg.SheetTemplate) (*pkg.SheetTemplate, bool, error) {
+	if s.templates == nil {
+		return nil, false, ErrSheetTemplatesDisabled
+	}
+	owner, err := authenticated(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	verr := &pkg.ValidationError{Subject: "sheet template"}
+	if !sheetTemplateName.MatchString(tmpl.Name) {
+		verr.Add("name", "must be up to 50 lower-case letters, digits and '-'")
+	}
+	if len(tmpl.Source) > maxSheetTemplateSize {
+		verr.Add("source", fmt.Sprintf("must be at most %d bytes", maxSheetTemplateSize))
+	}
+	if err := verr.Err(); err != nil {
+		return nil, false, err
+	}
+	// execute the template against an empty sheet so that mistakes show up now rather than when printing
+	if err := RenderCustomSheet(ctx, io.Discard, tmpl, "", pkg.NewQuizSheet("", "", nil, nil, pkg.SheetLayout{})); err != nil {
+		return nil, false, err
+	}
+
+	existing, err := s.templates.FindByName(ctx, tmpl.Name)
+	switch {
+	case err == pkg.ErrSheetTemplateNotFound:
+	case err != nil:
+		return nil, false, fmt.Errorf("could not find sheet template: %v", err)
+	case existing.Owner != owner.Username:
+		return nil, false, ErrNotSheetTemplateOwner
+	}
+
+	tmpl.Owner, tmpl.UpdatedAt = owner.Username, s.clock.Now()
+	if err := s.templates.Save(ctx, &tmpl); err != nil {
+		return nil, false, fmt.Errorf("could not save sheet template: %v", err)
+	}
+	return &tmpl, existing == nil, nil
+}
+
+// SheetTemplates returns every custom quiz sheet template
+func (s *service) SheetTemplates(ctx context.Context) ([]pkg.SheetTemplate, error) {
+	if s.templates == nil {
+		return nil, ErrSheetTemplatesDisabled
+	}
+
+	list, err := s.templates.FindAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not list sheet templates: %v", err)
+	}
+	return list, nil
+}
+
+func (s *service) SheetTemplate(ctx context.Context, name string) (*pkg.SheetTemplate, error) {
+	if s.templates == nil {
+		return nil, ErrSheetTemplatesDisabled
+	}
+
+	tmpl, err := s.templates.FindByName(ctx, name)
+	if err != nil {
+		if err == pkg.ErrSheetTemplateNotFound {
+			return nil, err
+		}
+		return nil, fmt.Errorf("could not find sheet template: %v", err)
+	}
+	return tmpl, nil
+}
+
+// RemoveSheetTemplate deletes the custom quiz sheet template with the given
+// name, if the authenticated user owns it
+func (s *service) RemoveSheetTemplate(ctx context.Context, name string) error {
+	if s.templates == nil {
+		return ErrSheetTemplatesDisabled
+	}
+	owner, err := authenticated(ctx)
+	if err != nil {
+		return err
+	}
+	tmpl, err := s.SheetTemplate(ctx, name)
+	if err != nil {
+		return err
+	}
+	if tmpl.Owner != owner.Username {
+		return ErrNotSheetTemplateOwner
+	}
+	if err := s.templates.DeleteByName(ctx, name); err != nil {
+		if err == pkg.ErrSheetTemplateNotFound {
+			return err
+		}
+		return fmt.Errorf("could not remove sheet template: %v", err)
+	}
+	return nil
+}
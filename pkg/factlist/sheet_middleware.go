@@ -0,0 +1,133 @@
+package factlist
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/markhaur/trivia/pkg"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SheetLoggingMiddleware logs the calls made to a SheetService
+func SheetLoggingMiddleware(logger log.Logger) SheetMiddleware {
+	return func(s SheetService) SheetService { return &sheetLoggingMiddleware{logger, s} }
+}
+
+type sheetLoggingMiddleware struct {
+	logger log.Logger
+	SheetService
+}
+
+func (s *sheetLoggingMiddleware) SelectFacts(ctx context.Context, selection pkg.FactSelection) (facts []pkg.Fact, err error) {
+	defer func(begin time.Time) {
+		level.Info(contextLogger(ctx, s.logger)).Log(
+			"method", "selectFacts",
+			"category", selection.Category,
+			"difficulty", selection.Difficulty,
+			"collection", selection.CollectionID,
+			"random", selection.Random,
+			"count", len(facts),
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+	return s.SheetService.SelectFacts(ctx, selection)
+}
+
+func (s *sheetLoggingMiddleware) SaveSheetTemplate(ctx context.Context, tmpl pkg.SheetTemplate) (_ *pkg.SheetTemplate, created bool, err error) {
+	defer func(begin time.Time) {
+		level.Info(contextLogger(ctx, s.logger)).Log(
+			"method", "saveSheetTemplate",
+			"name", tmpl.Name,
+			"format", tmpl.Format,
+			"created", created,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+	return s.SheetService.SaveSheetTemplate(ctx, tmpl)
+}
+
+func (s *sheetLoggingMiddleware) SheetTemplates(ctx context.Context) (list []pkg.SheetTemplate, err error) {
+	defer func(begin time.Time) {
+		level.Info(contextLogger(ctx, s.logger)).Log(
+			"method", "sheetTemplates",
+			"count", len(list),
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+	return s.SheetService.SheetTemplates(ctx)
+}
+
+func (s *sheetLoggingMiddleware) SheetTemplate(ctx context.Context, name string) (_ *pkg.SheetTemplate, err error) {
+	defer func(begin time.Time) {
+		level.Info(contextLogger(ctx, s.logger)).Log(
+			"method", "sheetTemplate",
+			"name", name,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+	return s.SheetService.SheetTemplate(ctx, name)
+}
+
+func (s *sheetLoggingMiddleware) RemoveSheetTemplate(ctx context.Context, name string) (err error) {
+	defer func(begin time.Time) {
+		level.Info(contextLogger(ctx, s.logger)).Log(
+			"method", "removeSheetTemplate",
+			"name", name,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+	return s.SheetService.RemoveSheetTemplate(ctx, name)
+}
+
+// SheetTracingMiddleware starts a span for every call made to a SheetService
+func SheetTracingMiddleware(tracer trace.Tracer) SheetMiddleware {
+	return func(s SheetService) SheetService { return &sheetTracingMiddleware{tracer, s} }
+}
+
+type sheetTracingMiddleware struct {
+	tracer trace.Tracer
+	SheetService
+}
+
+func (s *sheetTracingMiddleware) SelectFacts(ctx context.Context, selection pkg.FactSelection) (_ []pkg.Fact, err error) {
+	ctx, span := s.tracer.Start(ctx, "factlist.SelectFacts", trace.WithAttributes(
+		attribute.String("selection.category", selection.Category),
+		attribute.String("selection.difficulty", string(selection.Difficulty)),
+		attribute.Int64("selection.collection", selection.CollectionID),
+		attribute.Int("selection.random", selection.Random),
+	))
+	defer func() { endSpan(span, err) }()
+	return s.SheetService.SelectFacts(ctx, selection)
+}
+
+func (s *sheetTracingMiddleware) SaveSheetTemplate(ctx context.Context, tmpl pkg.SheetTemplate) (_ *pkg.SheetTemplate, _ bool, err error) {
+	ctx, span := s.tracer.Start(ctx, "factlist.SaveSheetTemplate", trace.WithAttributes(attribute.String("template.name", tmpl.Name)))
+	defer func() { endSpan(span, err) }()
+	return s.SheetService.SaveSheetTemplate(ctx, tmpl)
+}
+
+func (s *sheetTracingMiddleware) SheetTemplates(ctx context.Context) (_ []pkg.SheetTemplate, err error) {
+	ctx, span := s.tracer.Start(ctx, "factlist.SheetTemplates")
+	defer func() { endSpan(span, err) }()
+	return s.SheetService.SheetTemplates(ctx)
+}
+
+func (s *sheetTracingMiddleware) SheetTemplate(ctx context.Context, name string) (_ *pkg.SheetTemplate, err error) {
+	ctx, span := s.tracer.Start(ctx, "factlist.SheetTemplate", trace.WithAttributes(attribute.String("template.name", name)))
+	defer func() { endSpan(span, err) }()
+	return s.SheetService.SheetTemplate(ctx, name)
+}
+
+func (s *sheetTracingMiddleware) RemoveSheetTemplate(ctx context.Context, name string) (err error) {
+	ctx, span := s.tracer.Start(ctx, "factlist.RemoveSheetTemplate", trace.WithAttributes(attribute.String("template.name", name)))
+	defer func() { endSpan(span, err) }()
+	return s.SheetService.RemoveSheetTemplate(ctx, name)
+}
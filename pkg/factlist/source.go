@@ -0,0 +1,100 @@
+package factlist
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/markhaur/trivia/pkg"
+)
+
+// replaceSources returns sources as given by a client, keeping what the link
+// checker found out about the ones already listed in stored
+func replaceSources(stored, sources []pkg.Source) []pkg.Source {
+	checked := make(map[string]pkg.Source, len(stored))
+	for _, source := range stored {
+		checked[source.URL] = source
+	}
+
+	replaced := make([]pkg.Source, 0, len(sources))
+	for _, source := range sources {
+		source.Problem, source.BrokenSince = "", time.Time{}
+		if previous, ok := checked[source.URL]; ok {
+			source.Problem, source.BrokenSince = previous.Problem, previous.BrokenSince
+		}
+		replaced = append(replaced, source)
+	}
+	if len(replaced) == 0 {
+		return nil
+	}
+	return replaced
+}
+
+// RecordSourceChecks flags the sources of the fact with the given ID that
+// couldn't be fetched and clears the ones that could. The fact is only
+// written, and its version bumped, if a source broke or was fixed.
+func (s *service) RecordSourceChecks(ctx context.Context, id int64, checks []pkg.SourceCheck) (*pkg.Fact, error) {
+	fact, err := s.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	problems := make(map[string]string, len(checks))
+	for _, check := range checks {
+		problems[check.URL] = check.Problem
+	}
+	changed := func(fact *pkg.Fact) bool {
+		for _, source := range fact.Sources {
+			if problem, ok := problems[source.URL]; ok && problem != source.Problem {
+				return true
+			}
+		}
+		return false
+	}
+	if !changed(fact) {
+		return fact, nil
+	}
+
+	now := s.clock.Now()
+	fact, err = s.repository.Modify(ctx, id, 0, func(fact *pkg.Fact) error {
+		for i, source := range fact.Sources {
+			problem, ok := problems[source.URL]
+			if !ok {
+				continue
+			}
+			switch {
+			case problem == "":
+				source.BrokenSince = time.Time{}
+			case !source.Broken():
+				source.BrokenSince = now
+			}
+			source.Problem = problem
+			fact.Sources[i] = source
+		}
+		return nil
+	})
+	if err != nil {
+		if err == pkg.ErrFactNotFound {
+			return nil, err
+		}
+		return nil, fmt.Errorf("could not record source checks: %v", err)
+	}
+	return fact, nil
+}
+
+// BrokenSources returns the facts the user may see with at least one source
+// the link checker couldn't fetch
+func (s *service) BrokenSources(ctx context.Context) ([]pkg.Fact, error) {
+	list, err := s.visibleFacts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	flagged := make([]pkg.Fact, 0, len(list))
+	for _, fact := range list {
+		if fact.HasBrokenSources() {
+			flagged = append(flagged, fact)
+		}
+	}
+	return flagged, nil
+}
@@ -0,0 +1,158 @@
+package factlist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/markhaur/trivia/pkg"
+)
+
+var ErrStudyDisabled = errors.New("study mode is not enabled")
+
+// StudyService lets learners study the published facts as flashcards
+type StudyService interface {
+	DueCards(ctx context.Context, learner string, limit int) ([]pkg.DueCard, error)
+	Grade(ctx context.Context, learner string, factID int64, grade pkg.Grade) (*pkg.Card, error)
+	StudyStats(ctx context.Context, learner string) (*pkg.StudyStats, error)
+}
+
+// StudyMiddleware describes a StudyService Middleware
+type StudyMiddleware func(StudyService) StudyService
+
+// NewStudyService returns a StudyService over the facts stored in repository,
+// configured by the same options as NewService. It fails with
+// ErrStudyDisabled unless WithStudy is given.
+func NewStudyService(repository pkg.FactRepository, opts ...Option) StudyService {
+	return newService(repository, opts...)
+}
+
+// WithStudy lets learners study the published facts as flashcards, keeping
+// their progress in cards
+func WithStudy(cards pkg.CardRepository) Option {
+	return func(s *service) { s.cards = cards }
+}
+
+// deck returns the facts players may see along with the cards learner has of them,
+// keyed by fact ID
+func (s *service) deck(ctx context.Context, learner string) ([]pkg.Fact, map[int64]pkg.Card, error) {
+	if s.cards == nil {
+		return nil, nil, ErrStudyDisabled
+	}
+	if strings.TrimSpace(learner) == "" {
+		return nil, nil, pkg.ErrInvalidLearner
+	}
+
+	published, err := s.List(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	cards, err := s.cards.FindByLearner(ctx, learner)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not list cards: %v", err)
+	}
+	studied := make(map[int64]pkg.Card, len(cards))
+	for _, card := range cards {
+		studied[card.FactID] = card
+	}
+	return published, studied, nil
+}
+
+// DueCards returns up to limit published facts for learner to study: the
+// ones due for review first, longest overdue first, then the ones never
+// studied, oldest first
+func (s *service) DueCards(ctx context.Context, learner string, limit int) ([]pkg.DueCard, error) {
+	published, studied, err := s.deck(ctx, learner)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		now    = s.clock.Now()
+		due    []pkg.DueCard
+		unseen []pkg.DueCard
+	)
+	for _, fact := range published {
+		card, ok := studied[fact.ID]
+		switch {
+		case !ok:
+			unseen = append(unseen, pkg.DueCard{Card: pkg.NewCard(learner, fact.ID), Fact: fact, New: true})
+		case !card.Due.After(now):
+			due = append(due, pkg.DueCard{Card: card, Fact: fact})
+		}
+	}
+	sort.SliceStable(due, func(i, j int) bool { return due[i].Card.Due.Before(due[j].Card.Due) })
+
+	next := append(due, unseen...)
+	if limit > 0 && len(next) > limit {
+		next = next[:limit]
+	}
+	return next, nil
+}
+
+// Grade records how well learner recalled the answer of the fact with the
+// given ID and schedules its next review
+func (s *service) Grade(ctx context.Context, learner string, factID int64, grade pkg.Grade) (*pkg.Card, error) {
+	if s.cards == nil {
+		return nil, ErrStudyDisabled
+	}
+	if strings.TrimSpace(learner) == "" {
+		return nil, pkg.ErrInvalidLearner
+	}
+	if !grade.Valid() {
+		return nil, pkg.ErrInvalidGrade
+	}
+
+	fact, err := s.Get(ctx, factID)
+	if err != nil {
+		return nil, err
+	}
+	now := s.clock.Now()
+	if !fact.Live(now) {
+		return nil, pkg.ErrFactNotFound
+	}
+
+	card, err := s.cards.Modify(ctx, learner, factID, func(card *pkg.Card) error {
+		card.Review(grade, now)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not save card: %v", err)
+	}
+	return card, nil
+}
+
+// StudyStats summarizes the progress of learner over the published facts
+func (s *service) StudyStats(ctx context.Context, learner string) (*pkg.StudyStats, error) {
+	published, studied, err := s.deck(ctx, learner)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		stats pkg.StudyStats
+		now   = s.clock.Now()
+	)
+	for _, fact := range published {
+		card, ok := studied[fact.ID]
+		if !ok {
+			stats.New++
+			continue
+		}
+		stats.Cards++
+		stats.Reviews += card.Reviews
+		stats.Lapses += card.Lapses
+		if !card.Due.After(now) {
+			stats.Due++
+		}
+		if card.Mature() {
+			stats.Mature++
+		}
+	}
+	if stats.Reviews > 0 {
+		stats.Retention = float64(stats.Reviews-stats.Lapses) / float64(stats.Reviews)
+	}
+	return &stats, nil
+}
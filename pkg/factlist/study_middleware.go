@@ -0,0 +1,93 @@
+package factlist
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/markhaur/trivia/pkg"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// StudyLoggingMiddleware logs the calls made to a StudyService
+func StudyLoggingMiddleware(logger log.Logger) StudyMiddleware {
+	return func(s StudyService) StudyService { return &studyLoggingMiddleware{logger, s} }
+}
+
+type studyLoggingMiddleware struct {
+	logger log.Logger
+	StudyService
+}
+
+func (s *studyLoggingMiddleware) DueCards(ctx context.Context, learner string, limit int) (_ []pkg.DueCard, err error) {
+	defer func(begin time.Time) {
+		level.Info(contextLogger(ctx, s.logger)).Log(
+			"method", "dueCards",
+			"learner", learner,
+			"limit", limit,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+	return s.StudyService.DueCards(ctx, learner, limit)
+}
+
+func (s *studyLoggingMiddleware) Grade(ctx context.Context, learner string, factID int64, grade pkg.Grade) (_ *pkg.Card, err error) {
+	defer func(begin time.Time) {
+		level.Info(contextLogger(ctx, s.logger)).Log(
+			"method", "grade",
+			"learner", learner,
+			"id", factID,
+			"grade", grade,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+	return s.StudyService.Grade(ctx, learner, factID, grade)
+}
+
+func (s *studyLoggingMiddleware) StudyStats(ctx context.Context, learner string) (_ *pkg.StudyStats, err error) {
+	defer func(begin time.Time) {
+		level.Info(contextLogger(ctx, s.logger)).Log(
+			"method", "studyStats",
+			"learner", learner,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+	return s.StudyService.StudyStats(ctx, learner)
+}
+
+// StudyTracingMiddleware starts a span for every call made to a StudyService
+func StudyTracingMiddleware(tracer trace.Tracer) StudyMiddleware {
+	return func(s StudyService) StudyService { return &studyTracingMiddleware{tracer, s} }
+}
+
+type studyTracingMiddleware struct {
+	tracer trace.Tracer
+	StudyService
+}
+
+func (s *studyTracingMiddleware) DueCards(ctx context.Context, learner string, limit int) (_ []pkg.DueCard, err error) {
+	ctx, span := s.tracer.Start(ctx, "factlist.DueCards", trace.WithAttributes(attribute.String("study.learner", learner)))
+	defer func() { endSpan(span, err) }()
+	return s.StudyService.DueCards(ctx, learner, limit)
+}
+
+func (s *studyTracingMiddleware) Grade(ctx context.Context, learner string, factID int64, grade pkg.Grade) (_ *pkg.Card, err error) {
+	ctx, span := s.tracer.Start(ctx, "factlist.Grade", trace.WithAttributes(
+		attribute.String("study.learner", learner),
+		attribute.Int64("fact.id", factID),
+		attribute.Int("study.grade", int(grade)),
+	))
+	defer func() { endSpan(span, err) }()
+	return s.StudyService.Grade(ctx, learner, factID, grade)
+}
+
+func (s *studyTracingMiddleware) StudyStats(ctx context.Context, learner string) (_ *pkg.StudyStats, err error) {
+	ctx, span := s.tracer.Start(ctx, "factlist.StudyStats", trace.WithAttributes(attribute.String("study.learner", learner)))
+	defer func() { endSpan(span, err) }()
+	return s.StudyService.StudyStats(ctx, learner)
+}
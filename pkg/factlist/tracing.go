@@ -0,0 +1,172 @@
+package factlist
+
+import (
+	"context"
+	"time"
+
+	"github.com/markhaur/trivia/pkg"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TracingMiddleware(tracer trace.Tracer) Middleware {
+	return func(s Service) Service { return &tracingMiddleware{tracer, s} }
+}
+
+type tracingMiddleware struct {
+	tracer trace.Tracer
+	Service
+}
+
+func (s *tracingMiddleware) Save(ctx context.Context, fact pkg.Fact) (_ *pkg.Fact, err error) {
+	ctx, span := s.tracer.Start(ctx, "factlist.Save")
+	defer func() { endSpan(span, err) }()
+	return s.Service.Save(ctx, fact)
+}
+
+func (s *tracingMiddleware) List(ctx context.Context) (_ []pkg.Fact, err error) {
+	ctx, span := s.tracer.Start(ctx, "factlist.List")
+	defer func() { endSpan(span, err) }()
+	return s.Service.List(ctx)
+}
+
+func (s *tracingMiddleware) Get(ctx context.Context, id int64) (_ *pkg.Fact, err error) {
+	ctx, span := s.tracer.Start(ctx, "factlist.Get", trace.WithAttributes(attribute.Int64("fact.id", id)))
+	defer func() { endSpan(span, err) }()
+	return s.Service.Get(ctx, id)
+}
+
+func (s *tracingMiddleware) Remove(ctx context.Context, id int64, version int64) (err error) {
+	ctx, span := s.tracer.Start(ctx, "factlist.Remove", trace.WithAttributes(attribute.Int64("fact.id", id)))
+	defer func() { endSpan(span, err) }()
+	return s.Service.Remove(ctx, id, version)
+}
+
+func (s *tracingMiddleware) Update(ctx context.Context, fact pkg.Fact) (_ *pkg.Fact, _ bool, err error) {
+	ctx, span := s.tracer.Start(ctx, "factlist.Update", trace.WithAttributes(attribute.Int64("fact.id", fact.ID)))
+	defer func() { endSpan(span, err) }()
+	return s.Service.Update(ctx, fact)
+}
+
+func (s *tracingMiddleware) Replace(ctx context.Context, fact pkg.Fact) (_ *pkg.Fact, err error) {
+	ctx, span := s.tracer.Start(ctx, "factlist.Replace", trace.WithAttributes(attribute.Int64("fact.id", fact.ID)))
+	defer func() { endSpan(span, err) }()
+	return s.Service.Replace(ctx, fact)
+}
+
+func (s *tracingMiddleware) Patch(ctx context.Context, id int64, version int64, patch []byte) (_ *pkg.Fact, err error) {
+	ctx, span := s.tracer.Start(ctx, "factlist.Patch", trace.WithAttributes(attribute.Int64("fact.id", id)))
+	defer func() { endSpan(span, err) }()
+	return s.Service.Patch(ctx, id, version, patch)
+}
+
+func (s *tracingMiddleware) History(ctx context.Context, id int64) (_ []pkg.Revision, err error) {
+	ctx, span := s.tracer.Start(ctx, "factlist.History", trace.WithAttributes(attribute.Int64("fact.id", id)))
+	defer func() { endSpan(span, err) }()
+	return s.Service.History(ctx, id)
+}
+
+func (s *tracingMiddleware) Revert(ctx context.Context, id int64, revision int, version int64) (_ *pkg.Fact, err error) {
+	ctx, span := s.tracer.Start(ctx, "factlist.Revert", trace.WithAttributes(
+		attribute.Int64("fact.id", id),
+		attribute.Int("fact.revision", revision),
+	))
+	defer func() { endSpan(span, err) }()
+	return s.Service.Revert(ctx, id, revision, version)
+}
+
+func (s *tracingMiddleware) Trash(ctx context.Context) (_ []pkg.Fact, err error) {
+	ctx, span := s.tracer.Start(ctx, "factlist.Trash")
+	defer func() { endSpan(span, err) }()
+	return s.Service.Trash(ctx)
+}
+
+func (s *tracingMiddleware) Restore(ctx context.Context, id int64, version int64) (_ *pkg.Fact, err error) {
+	ctx, span := s.tracer.Start(ctx, "factlist.Restore", trace.WithAttributes(attribute.Int64("fact.id", id)))
+	defer func() { endSpan(span, err) }()
+	return s.Service.Restore(ctx, id, version)
+}
+
+func (s *tracingMiddleware) Purge(ctx context.Context, before time.Time) (_ int, err error) {
+	ctx, span := s.tracer.Start(ctx, "factlist.Purge")
+	defer func() { endSpan(span, err) }()
+	return s.Service.Purge(ctx, before)
+}
+
+func (s *tracingMiddleware) ListByStatus(ctx context.Context, statuses ...pkg.Status) (_ []pkg.Fact, err error) {
+	ctx, span := s.tracer.Start(ctx, "factlist.ListByStatus")
+	defer func() { endSpan(span, err) }()
+	return s.Service.ListByStatus(ctx, statuses...)
+}
+
+func (s *tracingMiddleware) Random(ctx context.Context) (_ *pkg.Fact, err error) {
+	ctx, span := s.tracer.Start(ctx, "factlist.Random")
+	defer func() { endSpan(span, err) }()
+	return s.Service.Random(ctx)
+}
+
+func (s *tracingMiddleware) Search(ctx context.Context, query string, limit int) (_ []pkg.SearchResult, err error) {
+	ctx, span := s.tracer.Start(ctx, "factlist.Search", trace.WithAttributes(attribute.Int("search.limit", limit)))
+	defer func() { endSpan(span, err) }()
+	return s.Service.Search(ctx, query, limit)
+}
+
+func (s *tracingMiddleware) Duplicates(ctx context.Context, id int64) (_ []pkg.Duplicate, err error) {
+	ctx, span := s.tracer.Start(ctx, "factlist.Duplicates", trace.WithAttributes(attribute.Int64("fact.id", id)))
+	defer func() { endSpan(span, err) }()
+	return s.Service.Duplicates(ctx, id)
+}
+
+func (s *tracingMiddleware) DuplicateClusters(ctx context.Context) (_ []pkg.DuplicateCluster, err error) {
+	ctx, span := s.tracer.Start(ctx, "factlist.DuplicateClusters")
+	defer func() { endSpan(span, err) }()
+	return s.Service.DuplicateClusters(ctx)
+}
+
+func (s *tracingMiddleware) Merge(ctx context.Context, id int64, duplicates []int64, version int64) (_ *pkg.Fact, err error) {
+	ctx, span := s.tracer.Start(ctx, "factlist.Merge", trace.WithAttributes(
+		attribute.Int64("fact.id", id),
+		attribute.Int64Slice("fact.duplicates", duplicates),
+	))
+	defer func() { endSpan(span, err) }()
+	return s.Service.Merge(ctx, id, duplicates, version)
+}
+
+func (s *tracingMiddleware) SetTranslation(ctx context.Context, id int64, lang string, translation pkg.Translation, version int64) (_ *pkg.Fact, _ bool, err error) {
+	ctx, span := s.tracer.Start(ctx, "factlist.SetTranslation", trace.WithAttributes(
+		attribute.Int64("fact.id", id),
+		attribute.String("fact.language", lang),
+	))
+	defer func() { endSpan(span, err) }()
+	return s.Service.SetTranslation(ctx, id, lang, translation, version)
+}
+
+func (s *tracingMiddleware) MissingTranslations(ctx context.Context, lang string) (_ []pkg.Fact, err error) {
+	ctx, span := s.tracer.Start(ctx, "factlist.MissingTranslations", trace.WithAttributes(attribute.String("fact.language", lang)))
+	defer func() { endSpan(span, err) }()
+	return s.Service.MissingTranslations(ctx, lang)
+}
+
+func (s *tracingMiddleware) RecordSourceChecks(ctx context.Context, id int64, checks []pkg.SourceCheck) (_ *pkg.Fact, err error) {
+	ctx, span := s.tracer.Start(ctx, "factlist.RecordSourceChecks", trace.WithAttributes(
+		attribute.Int64("fact.id", id),
+		attribute.Int("source.checks", len(checks)),
+	))
+	defer func() { endSpan(span, err) }()
+	return s.Service.RecordSourceChecks(ctx, id, checks)
+}
+
+func (s *tracingMiddleware) BrokenSources(ctx context.Context) (_ []pkg.Fact, err error) {
+	ctx, span := s.tracer.Start(ctx, "factlist.BrokenSources")
+	defer func() { endSpan(span, err) }()
+	return s.Service.BrokenSources(ctx)
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,85 @@
+package factlist
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/markhaur/trivia/pkg"
+)
+
+// SetTranslation adds or replaces the translation of the fact with the given
+// ID to lang, reporting whether it was added. A non-zero version is the
+// version the caller expects to translate. Only reviewers may translate
+// published facts.
+func (s *service) SetTranslation(ctx context.Context, id int64, lang string, translation pkg.Translation, version int64) (*pkg.Fact, bool, error) {
+	lang, err := pkg.ParseLanguage(lang)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var created bool
+	fact, err := s.repository.Modify(ctx, id, version, func(fact *pkg.Fact) error {
+		if err := s.mayChange(ctx, *fact); err != nil {
+			return err
+		}
+		if fact.Language == lang {
+			return pkg.ErrTranslationRedundant
+		}
+
+		// translations follow the same rules as the original, but may repeat
+		// questions asked in other languages
+		translated := pkg.Fact{ID: fact.ID, Question: translation.Question, Answer: translation.Answer}
+		s.policy.Normalize(&translated)
+		if err := s.policy.Validate(translated, nil); err != nil {
+			return err
+		}
+
+		if fact.Translations == nil {
+			fact.Translations = make(map[string]pkg.Translation)
+		}
+		_, exists := fact.Translations[lang]
+		created = !exists
+		fact.Translations[lang] = pkg.Translation{Question: translated.Question, Answer: translated.Answer}
+		return nil
+	})
+	if err != nil {
+		if _, ok := err.(*pkg.ValidationError); ok {
+			return nil, false, err
+		}
+		switch err {
+		case pkg.ErrFactNotFound, pkg.ErrVersionConflict, pkg.ErrTranslationRedundant:
+			return nil, false, err
+		}
+		if denied(err) {
+			return nil, false, err
+		}
+		return nil, false, fmt.Errorf("could not translate fact: %v", err)
+	}
+
+	if err := s.record(ctx, pkg.ActionTranslate, *fact); err != nil {
+		return nil, false, err
+	}
+	return fact, created, nil
+}
+
+// MissingTranslations returns the facts the user may see that are not
+// available in lang
+func (s *service) MissingTranslations(ctx context.Context, lang string) ([]pkg.Fact, error) {
+	lang, err := pkg.ParseLanguage(lang)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := s.visibleFacts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	missing := make([]pkg.Fact, 0, len(list))
+	for _, fact := range list {
+		if !fact.Translated(lang) {
+			missing = append(missing, fact)
+		}
+	}
+	return missing, nil
+}
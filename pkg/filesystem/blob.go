@@ -0,0 +1,92 @@
+// Package filesystem stores data in files on the local filesystem
+package filesystem
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/markhaur/trivia/pkg"
+)
+
+type blobStore struct {
+	dir string
+}
+
+// NewBlobStore returns a BlobStore keeping every blob in its own file under
+// dir, which is created if it doesn't exist
+func NewBlobStore(dir string) (pkg.BlobStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("could not create blob directory: %v", err)
+	}
+	return &blobStore{dir: dir}, nil
+}
+
+// path returns the file holding the blob stored under key. Blobs are spread
+// over subdirectories named after the first two characters of their key.
+func (bs *blobStore) path(key string) (string, error) {
+	if len(key) < 3 {
+		return "", pkg.ErrInvalidBlobKey
+	}
+	for _, r := range key {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return "", pkg.ErrInvalidBlobKey
+		}
+	}
+	return filepath.Join(bs.dir, key[:2], key), nil
+}
+
+// Put writes content to a temporary file first so that readers never see a
+// partially written blob
+func (bs *blobStore) Put(_ context.Context, key string, content io.Reader) error {
+	path, err := bs.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (bs *blobStore) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	path, err := bs.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, pkg.ErrBlobNotFound
+		}
+		return nil, err
+	}
+	return f, nil
+}
+
+func (bs *blobStore) Delete(_ context.Context, key string) error {
+	path, err := bs.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package filesystem_test
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/markhaur/trivia/pkg"
+	"github.com/markhaur/trivia/pkg/filesystem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlobStore(t *testing.T) {
+	var (
+		require = require.New(t)
+		assert  = assert.New(t)
+		ctx     = context.TODO()
+	)
+
+	store, err := filesystem.NewBlobStore(t.TempDir())
+	require.NoError(err, "could not create blob store")
+
+	_, err = store.Get(ctx, "c0ffee")
+	assert.Equal(pkg.ErrBlobNotFound, err)
+
+	require.NoError(store.Put(ctx, "c0ffee", strings.NewReader("first")), "could not put blob")
+	require.NoError(store.Put(ctx, "c0ffee", strings.NewReader("second")), "could not replace blob")
+
+	content, err := store.Get(ctx, "c0ffee")
+	require.NoError(err, "could not get blob")
+	b, err := io.ReadAll(content)
+	require.NoError(err, "could not read blob")
+	require.NoError(content.Close())
+	assert.Equal("second", string(b))
+
+	require.NoError(store.Delete(ctx, "c0ffee"), "could not delete blob")
+	require.NoError(store.Delete(ctx, "c0ffee"), "expected deleting a missing blob to succeed")
+	_, err = store.Get(ctx, "c0ffee")
+	assert.Equal(pkg.ErrBlobNotFound, err)
+}
+
+func TestBlobStoreRejectsInvalidKeys(t *testing.T) {
+	store, err := filesystem.NewBlobStore(t.TempDir())
+	require.NoError(t, err, "could not create blob store")
+
+	for _, key := range []string{"", "ab", "../../etc/passwd", "a/b/c", `a\b\c`, "key with spaces"} {
+		t.Run(key, func(t *testing.T) {
+			assert.Equal(t, pkg.ErrInvalidBlobKey, store.Put(context.TODO(), key, strings.NewReader("content")))
+			_, err := store.Get(context.TODO(), key)
+			assert.Equal(t, pkg.ErrInvalidBlobKey, err)
+			assert.Equal(t, pkg.ErrInvalidBlobKey, store.Delete(context.TODO(), key))
+		})
+	}
+}
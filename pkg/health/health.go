@@ -0,0 +1,82 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	"github.com/markhaur/trivia/pkg"
+)
+
+var (
+	ErrDraining = errors.New("shutting down")
+	ErrNotReady = errors.New("not ready")
+)
+
+// BuildInfo describes the running binary. Its values are injected at link time.
+type BuildInfo struct {
+	Version   string
+	Commit    string
+	BuildDate string
+	GoVersion string
+}
+
+// Readiness tracks whether the application is able to serve traffic
+type Readiness struct {
+	draining int32
+
+	mu      sync.RWMutex
+	pingers map[string]pkg.Pinger
+}
+
+func NewReadiness() *Readiness {
+	return &Readiness{pingers: make(map[string]pkg.Pinger)}
+}
+
+// Register adds dependency to the readiness checks if it implements pkg.Pinger.
+// Dependencies that cannot be pinged are assumed to be always available.
+func (r *Readiness) Register(name string, dependency interface{}) {
+	pinger, ok := dependency.(pkg.Pinger)
+	if !ok {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pingers[name] = pinger
+}
+
+// Drain marks the application as not ready for the rest of its lifetime
+func (r *Readiness) Drain() { atomic.StoreInt32(&r.draining, 1) }
+
+// Draining reports whether Drain has been called
+func (r *Readiness) Draining() bool { return atomic.LoadInt32(&r.draining) == 1 }
+
+// Check pings every registered dependency and returns the error of each one
+// keyed by name. The returned error is non-nil if any check failed or the
+// application is draining.
+func (r *Readiness) Check(ctx context.Context) (map[string]error, error) {
+	r.mu.RLock()
+	pingers := make(map[string]pkg.Pinger, len(r.pingers))
+	for name, pinger := range r.pingers {
+		pingers[name] = pinger
+	}
+	r.mu.RUnlock()
+
+	var failed bool
+	results := make(map[string]error, len(pingers))
+	for name, pinger := range pingers {
+		err := pinger.Ping(ctx)
+		results[name] = err
+		failed = failed || err != nil
+	}
+
+	switch {
+	case r.Draining():
+		return results, ErrDraining
+	case failed:
+		return results, ErrNotReady
+	}
+	return results, nil
+}
@@ -0,0 +1,93 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/matryer/way"
+)
+
+const checkTimeout = 2 * time.Second
+
+func NewServer(readiness *Readiness, info BuildInfo) http.Handler {
+	s := server{readiness: readiness, info: info}
+
+	router := way.NewRouter()
+
+	router.Handle("GET", "/healthz", s.handleLiveness())
+	router.Handle("GET", "/readyz", s.handleReadiness())
+	router.Handle("GET", "/version", s.handleVersion())
+
+	return router
+}
+
+const (
+	contentTypeKey   = "Content-Type"
+	contentTypeValue = "application/json; charset=utf-8"
+)
+
+type server struct {
+	readiness *Readiness
+	info      BuildInfo
+}
+
+func (s *server) handleLiveness() http.HandlerFunc {
+	type response struct {
+		Status string `json:"status"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(contentTypeKey, contentTypeValue)
+		json.NewEncoder(w).Encode(response{Status: "ok"})
+	}
+}
+
+func (s *server) handleReadiness() http.HandlerFunc {
+	type response struct {
+		Status string            `json:"status"`
+		Checks map[string]string `json:"checks"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), checkTimeout)
+		defer cancel()
+
+		results, err := s.readiness.Check(ctx)
+
+		resp := response{Status: "ready", Checks: make(map[string]string, len(results))}
+		for name, checkErr := range results {
+			resp.Checks[name] = "ok"
+			if checkErr != nil {
+				resp.Checks[name] = checkErr.Error()
+			}
+		}
+
+		w.Header().Set(contentTypeKey, contentTypeValue)
+		if err != nil {
+			resp.Status = err.Error()
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+func (s *server) handleVersion() http.HandlerFunc {
+	type response struct {
+		Version   string `json:"version"`
+		Commit    string `json:"commit"`
+		BuildDate string `json:"buildDate"`
+		GoVersion string `json:"goVersion"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(contentTypeKey, contentTypeValue)
+		json.NewEncoder(w).Encode(response{
+			Version:   s.info.Version,
+			Commit:    s.info.Commit,
+			BuildDate: s.info.BuildDate,
+			GoVersion: s.info.GoVersion,
+		})
+	}
+}
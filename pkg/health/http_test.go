@@ -0,0 +1,103 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/markhaur/trivia/pkg/health"
+	"github.com/markhaur/trivia/pkg/inmem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type pingerFunc func(context.Context) error
+
+func (f pingerFunc) Ping(ctx context.Context) error { return f(ctx) }
+
+func TestHealthEndpoints(t *testing.T) {
+	tt := []struct {
+		Name         string
+		Path         string
+		Dependency   interface{}
+		Drain        bool
+		ExpectedCode int
+		Expected     string
+	}{
+		{
+			Name:         "Returns 200 for liveness probe",
+			Path:         "/healthz",
+			ExpectedCode: http.StatusOK,
+			Expected:     `{"status": "ok"}`,
+		},
+		{
+			Name:         "Returns 200 for readiness probe if repository is reachable",
+			Path:         "/readyz",
+			Dependency:   inmem.NewFactRepository(),
+			ExpectedCode: http.StatusOK,
+			Expected:     `{"status": "ready", "checks": {"facts": "ok"}}`,
+		},
+		{
+			Name:         "Returns 200 for readiness probe if repository cannot be pinged",
+			Path:         "/readyz",
+			Dependency:   struct{}{},
+			ExpectedCode: http.StatusOK,
+			Expected:     `{"status": "ready", "checks": {}}`,
+		},
+		{
+			Name:         "Returns 503 for readiness probe if repository is unreachable",
+			Path:         "/readyz",
+			Dependency:   pingerFunc(func(context.Context) error { return errors.New("connection refused") }),
+			ExpectedCode: http.StatusServiceUnavailable,
+			Expected:     `{"status": "not ready", "checks": {"facts": "connection refused"}}`,
+		},
+		{
+			Name:         "Returns 503 for readiness probe while draining",
+			Path:         "/readyz",
+			Dependency:   inmem.NewFactRepository(),
+			Drain:        true,
+			ExpectedCode: http.StatusServiceUnavailable,
+			Expected:     `{"status": "shutting down", "checks": {"facts": "ok"}}`,
+		},
+		{
+			Name:         "Returns 200 and build info",
+			Path:         "/version",
+			ExpectedCode: http.StatusOK,
+			Expected:     `{"version": "v1.2.3", "commit": "8476058", "buildDate": "2022-11-20T10:00:00Z", "goVersion": "go1.19"}`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			var (
+				require   = require.New(t)
+				assert    = assert.New(t)
+				readiness = health.NewReadiness()
+				handler   = health.NewServer(readiness, health.BuildInfo{
+					Version:   "v1.2.3",
+					Commit:    "8476058",
+					BuildDate: "2022-11-20T10:00:00Z",
+					GoVersion: "go1.19",
+				})
+			)
+
+			if tc.Dependency != nil {
+				readiness.Register("facts", tc.Dependency)
+			}
+			if tc.Drain {
+				readiness.Drain()
+			}
+
+			rec := httptest.NewRecorder()
+			req, err := http.NewRequest("GET", tc.Path, nil)
+			require.NoError(err, "could not create http request")
+
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(tc.ExpectedCode, rec.Result().StatusCode, "unexpected http status code")
+			assert.JSONEq(tc.Expected, rec.Body.String(), "unexpected http response body")
+		})
+	}
+}
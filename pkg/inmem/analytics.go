@@ -0,0 +1,46 @@
+package inmem
+
+import (
+	"context"
+	"sync"
+
+	"github.com/markhaur/trivia/pkg"
+)
+
+type analyticsSink struct {
+	sync.RWMutex
+	attempts []pkg.Attempt
+	// next is the index the next attempt is written at once the buffer is full
+	next int
+}
+
+// NewAnalyticsSink returns a ring buffer keeping the last capacity attempts
+func NewAnalyticsSink(capacity int) pkg.AnalyticsSink {
+	return &analyticsSink{attempts: make([]pkg.Attempt, 0, capacity)}
+}
+
+func (as *analyticsSink) Record(_ context.Context, attempt pkg.Attempt) error {
+	as.Lock()
+	defer as.Unlock()
+
+	if cap(as.attempts) == 0 {
+		return nil
+	}
+	if len(as.attempts) < cap(as.attempts) {
+		as.attempts = append(as.attempts, attempt)
+		return nil
+	}
+	as.attempts[as.next] = attempt
+	as.next = (as.next + 1) % len(as.attempts)
+	return nil
+}
+
+func (as *analyticsSink) Attempts(_ context.Context) ([]pkg.Attempt, error) {
+	as.RLock()
+	defer as.RUnlock()
+
+	attempts := make([]pkg.Attempt, 0, len(as.attempts))
+	attempts = append(attempts, as.attempts[as.next:]...)
+	attempts = append(attempts, as.attempts[:as.next]...)
+	return attempts, nil
+}
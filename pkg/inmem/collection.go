@@ -0,0 +1,113 @@
+package inmem
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/markhaur/trivia/pkg"
+)
+
+type collectionRepository struct {
+	sync.RWMutex
+	counter     int64
+	collections map[int64]pkg.Collection
+}
+
+func NewCollectionRepository() pkg.CollectionRepository {
+	return &collectionRepository{collections: make(map[int64]pkg.Collection)}
+}
+
+// cloneCollection returns a copy of collection that shares no memory with it
+func cloneCollection(collection pkg.Collection) pkg.Collection {
+	collection.FactIDs = append([]int64(nil), collection.FactIDs...)
+	return collection
+}
+
+// slugTaken reports whether a collection other than the one with the given ID is shared under slug
+func (cr *collectionRepository) slugTaken(id int64, slug string) bool {
+	if slug == "" {
+		return false
+	}
+	for _, c := range cr.collections {
+		if c.ID != id && c.Slug == slug {
+			return true
+		}
+	}
+	return false
+}
+
+func (cr *collectionRepository) Insert(_ context.Context, collection *pkg.Collection) error {
+	cr.Lock()
+	defer cr.Unlock()
+
+	if cr.slugTaken(0, collection.Slug) {
+		return pkg.ErrSlugTaken
+	}
+	cr.counter++
+	collection.ID = cr.counter
+	cr.collections[collection.ID] = cloneCollection(*collection)
+	return nil
+}
+
+func (cr *collectionRepository) FindAll(_ context.Context) ([]pkg.Collection, error) {
+	cr.RLock()
+	defer cr.RUnlock()
+
+	list := make([]pkg.Collection, 0, len(cr.collections))
+	for _, c := range cr.collections {
+		list = append(list, cloneCollection(c))
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list, nil
+}
+
+func (cr *collectionRepository) FindByID(_ context.Context, id int64) (*pkg.Collection, error) {
+	cr.RLock()
+	defer cr.RUnlock()
+
+	c, ok := cr.collections[id]
+	if !ok {
+		return nil, pkg.ErrCollectionNotFound
+	}
+	c = cloneCollection(c)
+	return &c, nil
+}
+
+func (cr *collectionRepository) FindBySlug(_ context.Context, slug string) (*pkg.Collection, error) {
+	cr.RLock()
+	defer cr.RUnlock()
+
+	for _, c := range cr.collections {
+		if slug != "" && c.Slug == slug {
+			c = cloneCollection(c)
+			return &c, nil
+		}
+	}
+	return nil, pkg.ErrCollectionNotFound
+}
+
+func (cr *collectionRepository) Update(_ context.Context, collection *pkg.Collection) error {
+	cr.Lock()
+	defer cr.Unlock()
+
+	if _, ok := cr.collections[collection.ID]; !ok {
+		return pkg.ErrCollectionNotFound
+	}
+	if cr.slugTaken(collection.ID, collection.Slug) {
+		return pkg.ErrSlugTaken
+	}
+	cr.collections[collection.ID] = cloneCollection(*collection)
+	return nil
+}
+
+func (cr *collectionRepository) DeleteByID(_ context.Context, id int64) error {
+	cr.Lock()
+	defer cr.Unlock()
+
+	if _, ok := cr.collections[id]; !ok {
+		return pkg.ErrCollectionNotFound
+	}
+	delete(cr.collections, id)
+	return nil
+}
@@ -0,0 +1,49 @@
+package inmem
+
+import (
+	"context"
+	"sync"
+
+	"github.com/markhaur/trivia/pkg"
+)
+
+type historyRepository struct {
+	sync.RWMutex
+	revisions map[int64][]pkg.Revision
+}
+
+func NewHistoryRepository() pkg.HistoryRepository {
+	return &historyRepository{revisions: make(map[int64][]pkg.Revision)}
+}
+
+func (hr *historyRepository) Append(_ context.Context, revision *pkg.Revision) error {
+	hr.Lock()
+	defer hr.Unlock()
+
+	revision.Number = len(hr.revisions[revision.FactID]) + 1
+	hr.revisions[revision.FactID] = append(hr.revisions[revision.FactID], *revision)
+	return nil
+}
+
+func (hr *historyRepository) FindByFactID(_ context.Context, factID int64) ([]pkg.Revision, error) {
+	hr.RLock()
+	defer hr.RUnlock()
+
+	revisions := hr.revisions[factID]
+	if len(revisions) == 0 {
+		return nil, pkg.ErrFactNotFound
+	}
+	return append([]pkg.Revision(nil), revisions...), nil
+}
+
+func (hr *historyRepository) FindRevision(_ context.Context, factID int64, number int) (*pkg.Revision, error) {
+	hr.RLock()
+	defer hr.RUnlock()
+
+	revisions := hr.revisions[factID]
+	if number < 1 || number > len(revisions) {
+		return nil, pkg.ErrRevisionNotFound
+	}
+	revision := revisions[number-1]
+	return &revision, nil
+}
@@ -3,6 +3,7 @@ package inmem
 import (
 	"context"
 	"sync"
+	"time"
 
 	"github.com/markhaur/trivia/pkg"
 )
@@ -12,12 +13,42 @@ type triviaRepository struct {
 	trivialist []pkg.Fact
 	exists     map[int64]bool
 	counter    int64
+	clock      pkg.Clock
 }
 
-func NewFactRepository() pkg.FactRepository {
-	return &triviaRepository{trivialist: []pkg.Fact{}, exists: make(map[int64]bool)}
+// FactRepositoryOption configures the repository returned by NewFactRepository
+type FactRepositoryOption func(*triviaRepository)
+
+// WithClock replaces the system clock telling when facts are moved to the trash
+func WithClock(clock pkg.Clock) FactRepositoryOption {
+	return func(tr *triviaRepository) { tr.clock = clock }
+}
+
+func NewFactRepository(opts ...FactRepositoryOption) pkg.FactRepository {
+	tr := &triviaRepository{trivialist: []pkg.Fact{}, exists: make(map[int64]bool), clock: pkg.SystemClock}
+	for _, opt := range opts {
+		opt(tr)
+	}
+	return tr
+}
+
+// clone returns a copy of fact that shares no memory with it
+func clone(fact pkg.Fact) pkg.Fact {
+	fact.Comments = append([]pkg.ReviewComment(nil), fact.Comments...)
+	fact.Attachments = append([]pkg.Attachment(nil), fact.Attachments...)
+	fact.Sources = append([]pkg.Source(nil), fact.Sources...)
+	if fact.Translations != nil {
+		translations := make(map[string]pkg.Translation, len(fact.Translations))
+		for lang, t := range fact.Translations {
+			translations[lang] = t
+		}
+		fact.Translations = translations
+	}
+	return fact
 }
 
+func (tr *triviaRepository) Ping(_ context.Context) error { return nil }
+
 func (tr *triviaRepository) Insert(_ context.Context, newFact *pkg.Fact) error {
 	tr.Lock()
 	defer tr.Unlock()
@@ -28,7 +59,8 @@ func (tr *triviaRepository) Insert(_ context.Context, newFact *pkg.Fact) error {
 		}
 
 		tr.exists[newFact.ID] = true
-		tr.trivialist = append(tr.trivialist, *newFact)
+		newFact.Version = 1
+		tr.trivialist = append(tr.trivialist, clone(*newFact))
 		return nil
 	}
 
@@ -38,7 +70,8 @@ func (tr *triviaRepository) Insert(_ context.Context, newFact *pkg.Fact) error {
 	}
 	tr.exists[tr.counter] = true
 	newFact.ID = tr.counter
-	tr.trivialist = append(tr.trivialist, *newFact)
+	newFact.Version = 1
+	tr.trivialist = append(tr.trivialist, clone(*newFact))
 	return nil
 }
 
@@ -46,7 +79,13 @@ func (tr *triviaRepository) FindAll(_ context.Context) ([]pkg.Fact, error) {
 	tr.RLock()
 	defer tr.RUnlock()
 
-	return tr.trivialist, nil
+	list := make([]pkg.Fact, 0, len(tr.trivialist))
+	for _, t := range tr.trivialist {
+		if !t.Deleted() {
+			list = append(list, clone(t))
+		}
+	}
+	return list, nil
 }
 
 func (tr *triviaRepository) FindByID(_ context.Context, id int64) (*pkg.Fact, error) {
@@ -54,8 +93,9 @@ func (tr *triviaRepository) FindByID(_ context.Context, id int64) (*pkg.Fact, er
 	defer tr.RUnlock()
 
 	for _, t := range tr.trivialist {
-		if t.ID == id {
-			return &t, nil
+		if t.ID == id && !t.Deleted() {
+			fact := clone(t)
+			return &fact, nil
 		}
 	}
 	return nil, pkg.ErrFactNotFound
@@ -66,23 +106,141 @@ func (tr *triviaRepository) Update(_ context.Context, updatedFact *pkg.Fact) err
 	defer tr.Unlock()
 
 	for i, t := range tr.trivialist {
-		if t.ID == updatedFact.ID {
-			tr.trivialist[i] = *updatedFact
+		if t.ID == updatedFact.ID && !t.Deleted() {
+			if updatedFact.Version != 0 && updatedFact.Version != t.Version {
+				return pkg.ErrVersionConflict
+			}
+			updatedFact.Version = t.Version + 1
+			tr.trivialist[i] = clone(*updatedFact)
 			return nil
 		}
 	}
 	return pkg.ErrFactNotFound
 }
 
-func (tr *triviaRepository) DeleteByID(_ context.Context, id int64) error {
+func (tr *triviaRepository) Modify(_ context.Context, id int64, version int64, fn func(*pkg.Fact) error) (*pkg.Fact, error) {
 	tr.Lock()
 	defer tr.Unlock()
 
 	for i, t := range tr.trivialist {
-		if t.ID == id {
-			tr.trivialist = append(tr.trivialist[:i], tr.trivialist[i+1:]...)
+		if t.ID == id && !t.Deleted() {
+			if version != 0 && version != t.Version {
+				return nil, pkg.ErrVersionConflict
+			}
+			fact := clone(t)
+			if err := fn(&fact); err != nil {
+				return nil, err
+			}
+			fact.ID, fact.DeletedAt = id, time.Time{}
+			fact.Version = t.Version + 1
+			tr.trivialist[i] = clone(fact)
+			return &fact, nil
+		}
+	}
+	return nil, pkg.ErrFactNotFound
+}
+
+func (tr *triviaRepository) DeleteByID(_ context.Context, id int64, version int64) error {
+	tr.Lock()
+	defer tr.Unlock()
+
+	for i, t := range tr.trivialist {
+		if t.ID == id && !t.Deleted() {
+			if version != 0 && version != t.Version {
+				return pkg.ErrVersionConflict
+			}
+			tr.trivialist[i].DeletedAt = tr.clock.Now().UTC()
+			tr.trivialist[i].Version++
 			return nil
 		}
 	}
 	return pkg.ErrFactNotFound
 }
+
+func (tr *triviaRepository) Merge(_ context.Context, id int64, version int64, duplicates map[int64]int64) (*pkg.Fact, error) {
+	tr.Lock()
+	defer tr.Unlock()
+
+	kept := -1
+	deleted := make([]int, 0, len(duplicates))
+	for i, t := range tr.trivialist {
+		if t.Deleted() {
+			continue
+		}
+		if t.ID == id {
+			if version != 0 && version != t.Version {
+				return nil, pkg.ErrVersionConflict
+			}
+			kept = i
+			continue
+		}
+		if expected, ok := duplicates[t.ID]; ok {
+			if expected != 0 && expected != t.Version {
+				return nil, pkg.ErrVersionConflict
+			}
+			deleted = append(deleted, i)
+		}
+	}
+	if kept < 0 || len(deleted) != len(duplicates) {
+		return nil, pkg.ErrFactNotFound
+	}
+
+	now := tr.clock.Now().UTC()
+	for _, i := range deleted {
+		tr.trivialist[i].DeletedAt = now
+		tr.trivialist[i].Version++
+	}
+	tr.trivialist[kept].Version++
+	fact := clone(tr.trivialist[kept])
+	return &fact, nil
+}
+
+func (tr *triviaRepository) FindDeleted(_ context.Context) ([]pkg.Fact, error) {
+	tr.RLock()
+	defer tr.RUnlock()
+
+	list := []pkg.Fact{}
+	for _, t := range tr.trivialist {
+		if t.Deleted() {
+			list = append(list, clone(t))
+		}
+	}
+	return list, nil
+}
+
+func (tr *triviaRepository) Restore(_ context.Context, id int64, version int64) (*pkg.Fact, error) {
+	tr.Lock()
+	defer tr.Unlock()
+
+	for i, t := range tr.trivialist {
+		if t.ID == id && t.Deleted() {
+			if version != 0 && version != t.Version {
+				return nil, pkg.ErrVersionConflict
+			}
+			t.DeletedAt = time.Time{}
+			t.Version++
+			tr.trivialist[i] = t
+			fact := clone(t)
+			return &fact, nil
+		}
+	}
+	return nil, pkg.ErrFactNotFound
+}
+
+func (tr *triviaRepository) Purge(_ context.Context, before time.Time) ([]pkg.Fact, error) {
+	tr.Lock()
+	defer tr.Unlock()
+
+	var purged []pkg.Fact
+	kept := tr.trivialist[:0]
+	for _, t := range tr.trivialist {
+		if t.Deleted() && t.DeletedAt.Before(before) {
+			purged = append(purged, t)
+			delete(tr.exists, t.ID)
+			continue
+		}
+		kept = append(kept, t)
+	}
+	tr.trivialist = kept
+	return purged, nil
+}
@@ -0,0 +1,136 @@
+package inmem
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/markhaur/trivia/pkg"
+	"github.com/markhaur/trivia/pkg/search"
+)
+
+// BM25 parameters and the weight of a term found in the question relative to
+// one found in the answer
+const (
+	k1             = 1.2
+	b              = 0.75
+	questionWeight = 2
+)
+
+type document struct {
+	fact   pkg.Fact
+	terms  []string
+	length float64
+}
+
+type searchIndex struct {
+	sync.RWMutex
+	documents map[int64]document
+	// postings maps every term to the weighted frequency of the term in each document
+	postings    map[string]map[int64]float64
+	totalLength float64
+}
+
+// NewSearchIndex returns an inverted index ranking facts with BM25
+func NewSearchIndex() pkg.SearchIndex {
+	return &searchIndex{documents: make(map[int64]document), postings: make(map[string]map[int64]float64)}
+}
+
+func (si *searchIndex) Index(_ context.Context, fact pkg.Fact) error {
+	si.Lock()
+	defer si.Unlock()
+
+	si.remove(fact.ID)
+
+	frequencies := make(map[string]float64)
+	for _, token := range search.Analyze(fact.Question) {
+		frequencies[token.Term] += questionWeight
+	}
+	for _, token := range search.Analyze(fact.Answer) {
+		frequencies[token.Term]++
+	}
+
+	var (
+		terms  = make([]string, 0, len(frequencies))
+		length float64
+	)
+	for term, frequency := range frequencies {
+		if si.postings[term] == nil {
+			si.postings[term] = make(map[int64]float64)
+		}
+		si.postings[term][fact.ID] = frequency
+		terms = append(terms, term)
+		length += frequency
+	}
+	si.documents[fact.ID] = document{fact: clone(fact), terms: terms, length: length}
+	si.totalLength += length
+	return nil
+}
+
+func (si *searchIndex) Remove(_ context.Context, id int64) error {
+	si.Lock()
+	defer si.Unlock()
+
+	si.remove(id)
+	return nil
+}
+
+func (si *searchIndex) remove(id int64) {
+	doc, ok := si.documents[id]
+	if !ok {
+		return
+	}
+	for _, term := range doc.terms {
+		delete(si.postings[term], id)
+		if len(si.postings[term]) == 0 {
+			delete(si.postings, term)
+		}
+	}
+	si.totalLength -= doc.length
+	delete(si.documents, id)
+}
+
+func (si *searchIndex) Search(_ context.Context, query string, limit int) ([]pkg.SearchResult, error) {
+	si.RLock()
+	defer si.RUnlock()
+
+	terms := search.Terms(query)
+	if len(terms) == 0 || len(si.documents) == 0 {
+		return []pkg.SearchResult{}, nil
+	}
+
+	n := float64(len(si.documents))
+	avgLength := si.totalLength / n
+	scores := make(map[int64]float64)
+	for _, term := range terms {
+		postings := si.postings[term]
+		df := float64(len(postings))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		for id, tf := range postings {
+			norm := k1 * (1 - b + b*si.documents[id].length/avgLength)
+			scores[id] += idf * tf * (k1 + 1) / (tf + norm)
+		}
+	}
+
+	results := make([]pkg.SearchResult, 0, len(scores))
+	for id, score := range scores {
+		fact := clone(si.documents[id].fact)
+		results = append(results, pkg.SearchResult{
+			Fact:     fact,
+			Score:    score,
+			Question: search.Highlight(fact.Question, terms),
+			Answer:   search.Highlight(fact.Answer, terms),
+		})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Fact.ID < results[j].Fact.ID
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
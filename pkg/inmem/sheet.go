@@ -0,0 +1,60 @@
+package inmem
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/markhaur/trivia/pkg"
+)
+
+type sheetTemplateRepository struct {
+	sync.RWMutex
+	templates map[string]pkg.SheetTemplate
+}
+
+func NewSheetTemplateRepository() pkg.SheetTemplateRepository {
+	return &sheetTemplateRepository{templates: make(map[string]pkg.SheetTemplate)}
+}
+
+func (tr *sheetTemplateRepository) Save(_ context.Context, tmpl *pkg.SheetTemplate) error {
+	tr.Lock()
+	defer tr.Unlock()
+
+	tr.templates[tmpl.Name] = *tmpl
+	return nil
+}
+
+func (tr *sheetTemplateRepository) FindAll(_ context.Context) ([]pkg.SheetTemplate, error) {
+	tr.RLock()
+	defer tr.RUnlock()
+
+	list := make([]pkg.SheetTemplate, 0, len(tr.templates))
+	for _, tmpl := range tr.templates {
+		list = append(list, tmpl)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list, nil
+}
+
+func (tr *sheetTemplateRepository) FindByName(_ context.Context, name string) (*pkg.SheetTemplate, error) {
+	tr.RLock()
+	defer tr.RUnlock()
+
+	tmpl, ok := tr.templates[name]
+	if !ok {
+		return nil, pkg.ErrSheetTemplateNotFound
+	}
+	return &tmpl, nil
+}
+
+func (tr *sheetTemplateRepository) DeleteByName(_ context.Context, name string) error {
+	tr.Lock()
+	defer tr.Unlock()
+
+	if _, ok := tr.templates[name]; !ok {
+		return pkg.ErrSheetTemplateNotFound
+	}
+	delete(tr.templates, name)
+	return nil
+}
@@ -0,0 +1,70 @@
+package inmem
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/markhaur/trivia/pkg"
+)
+
+type cardRepository struct {
+	sync.RWMutex
+	cards map[string]map[int64]pkg.Card
+}
+
+func NewCardRepository() pkg.CardRepository {
+	return &cardRepository{cards: make(map[string]map[int64]pkg.Card)}
+}
+
+func (cr *cardRepository) Find(_ context.Context, learner string, factID int64) (*pkg.Card, error) {
+	cr.RLock()
+	defer cr.RUnlock()
+
+	card, ok := cr.cards[learner][factID]
+	if !ok {
+		return nil, pkg.ErrCardNotFound
+	}
+	return &card, nil
+}
+
+func (cr *cardRepository) FindByLearner(_ context.Context, learner string) ([]pkg.Card, error) {
+	cr.RLock()
+	defer cr.RUnlock()
+
+	cards := make([]pkg.Card, 0, len(cr.cards[learner]))
+	for _, card := range cr.cards[learner] {
+		cards = append(cards, card)
+	}
+	sort.Slice(cards, func(i, j int) bool { return cards[i].FactID < cards[j].FactID })
+	return cards, nil
+}
+
+func (cr *cardRepository) Modify(_ context.Context, learner string, factID int64, fn func(*pkg.Card) error) (*pkg.Card, error) {
+	cr.Lock()
+	defer cr.Unlock()
+
+	card, ok := cr.cards[learner][factID]
+	if !ok {
+		card = pkg.NewCard(learner, factID)
+	}
+	if err := fn(&card); err != nil {
+		return nil, err
+	}
+	if cr.cards[learner] == nil {
+		cr.cards[learner] = make(map[int64]pkg.Card)
+	}
+	cr.cards[learner][factID] = card
+	return &card, nil
+}
+
+func (cr *cardRepository) Save(_ context.Context, card *pkg.Card) error {
+	cr.Lock()
+	defer cr.Unlock()
+
+	if cr.cards[card.Learner] == nil {
+		cr.cards[card.Learner] = make(map[int64]pkg.Card)
+	}
+	cr.cards[card.Learner][card.FactID] = *card
+	return nil
+}
@@ -0,0 +1,119 @@
+package inmem
+
+import (
+	"context"
+	"sync"
+
+	"github.com/markhaur/trivia/pkg"
+)
+
+type userRepository struct {
+	sync.RWMutex
+	counter    int64
+	users      map[int64]pkg.User
+	byUsername map[string]int64
+}
+
+func NewUserRepository() pkg.UserRepository {
+	return &userRepository{users: make(map[int64]pkg.User), byUsername: make(map[string]int64)}
+}
+
+// cloneUser returns a copy of user that shares no memory with it
+func cloneUser(user pkg.User) pkg.User {
+	user.PasswordHash = append([]byte(nil), user.PasswordHash...)
+	user.Profile.Categories = append([]string(nil), user.Profile.Categories...)
+	return user
+}
+
+func (ur *userRepository) Insert(_ context.Context, user *pkg.User) error {
+	ur.Lock()
+	defer ur.Unlock()
+
+	if _, ok := ur.byUsername[user.Username]; ok {
+		return pkg.ErrUsernameTaken
+	}
+	ur.counter++
+	user.ID = ur.counter
+	ur.users[user.ID] = cloneUser(*user)
+	ur.byUsername[user.Username] = user.ID
+	return nil
+}
+
+func (ur *userRepository) FindByID(_ context.Context, id int64) (*pkg.User, error) {
+	ur.RLock()
+	defer ur.RUnlock()
+
+	user, ok := ur.users[id]
+	if !ok {
+		return nil, pkg.ErrUserNotFound
+	}
+	user = cloneUser(user)
+	return &user, nil
+}
+
+func (ur *userRepository) FindByUsername(_ context.Context, username string) (*pkg.User, error) {
+	ur.RLock()
+	defer ur.RUnlock()
+
+	id, ok := ur.byUsername[username]
+	if !ok {
+		return nil, pkg.ErrUserNotFound
+	}
+	user := cloneUser(ur.users[id])
+	return &user, nil
+}
+
+func (ur *userRepository) Update(_ context.Context, user *pkg.User) error {
+	ur.Lock()
+	defer ur.Unlock()
+
+	stored, ok := ur.users[user.ID]
+	if !ok {
+		return pkg.ErrUserNotFound
+	}
+	if user.Username != stored.Username {
+		if _, taken := ur.byUsername[user.Username]; taken {
+			return pkg.ErrUsernameTaken
+		}
+		delete(ur.byUsername, stored.Username)
+		ur.byUsername[user.Username] = user.ID
+	}
+	ur.users[user.ID] = cloneUser(*user)
+	return nil
+}
+
+type sessionRepository struct {
+	sync.RWMutex
+	sessions map[string]pkg.Session
+}
+
+func NewSessionRepository() pkg.SessionRepository {
+	return &sessionRepository{sessions: make(map[string]pkg.Session)}
+}
+
+func (sr *sessionRepository) Insert(_ context.Context, session *pkg.Session) error {
+	sr.Lock()
+	defer sr.Unlock()
+
+	sr.sessions[session.TokenHash] = *session
+	return nil
+}
+
+func (sr *sessionRepository) Find(_ context.Context, tokenHash string) (*pkg.Session, error) {
+	sr.RLock()
+	defer sr.RUnlock()
+
+	session, ok := sr.sessions[tokenHash]
+	if !ok {
+		return nil, pkg.ErrSessionNotFound
+	}
+	return &session, nil
+}
+
+func (sr *sessionRepository) Delete(_ context.Context, tokenHash string) error {
+	sr.Lock()
+	defer sr.Unlock()
+
+	delete(sr.sessions, tokenHash)
+	return nil
+}
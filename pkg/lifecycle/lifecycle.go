@@ -0,0 +1,204 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/markhaur/trivia/pkg"
+)
+
+// Exit codes returned by Manager.Run
+const (
+	ExitOK              = 0
+	ExitComponentFailed = 1
+	ExitShutdownFailed  = 2
+)
+
+// Component is a long running part of the application such as a transport or
+// a background worker. Run blocks until the component stops on its own or
+// fails; Shutdown asks it to stop and returns once it has drained.
+type Component interface {
+	Run(context.Context) error
+	Shutdown(context.Context) error
+}
+
+type namedComponent struct {
+	name string
+	Component
+}
+
+type hook struct {
+	name string
+	fn   func(context.Context) error
+}
+
+// Manager runs components until the process receives SIGINT or SIGTERM and
+// then shuts them down within the configured timeout
+type Manager struct {
+	logger     log.Logger
+	timeout    time.Duration
+	components []namedComponent
+	before     []func()
+	delay      time.Duration
+	after      []hook
+}
+
+func NewManager(logger log.Logger, timeout time.Duration) *Manager {
+	return &Manager{logger: logger, timeout: timeout}
+}
+
+// Add registers a component. Components are started in the order they are
+// added and shut down in reverse order.
+func (m *Manager) Add(name string, c Component) {
+	m.components = append(m.components, namedComponent{name, c})
+}
+
+// BeforeShutdown registers fn to be called as soon as shutdown begins,
+// before any component is asked to stop
+func (m *Manager) BeforeShutdown(fn func()) {
+	m.before = append(m.before, fn)
+}
+
+// DelayShutdown makes the manager wait for d after calling the BeforeShutdown
+// hooks and before asking components to stop, e.g. so that load balancers
+// notice the application is draining while it still accepts connections. A
+// second signal cuts the wait short. The delay isn't part of the shutdown timeout.
+func (m *Manager) DelayShutdown(d time.Duration) {
+	m.delay = d
+}
+
+// AfterShutdown registers fn to be called once every component has stopped,
+// e.g. to flush pending repository writes. It shares the shutdown timeout.
+func (m *Manager) AfterShutdown(name string, fn func(context.Context) error) {
+	m.after = append(m.after, hook{name, fn})
+}
+
+// AddFlusher registers dependency to be flushed after shutdown if it implements pkg.Flusher
+func (m *Manager) AddFlusher(name string, dependency interface{}) {
+	if flusher, ok := dependency.(pkg.Flusher); ok {
+		m.AfterShutdown(name, flusher.Flush)
+	}
+}
+
+// Run starts every component and blocks until ctx is done, a signal is
+// received or a component stops. It returns the exit code for the process.
+// A second signal during shutdown aborts the graceful drain.
+func (m *Manager) Run(ctx context.Context) int {
+	sig := make(chan os.Signal, 2)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sig)
+
+	errc := make(chan error, len(m.components))
+	for _, c := range m.components {
+		go func(c namedComponent) {
+			m.logger.Log("component", c.name, "msg", "starting")
+			if err := c.Run(ctx); err != nil {
+				errc <- fmt.Errorf("%s: %w", c.name, err)
+				return
+			}
+			errc <- nil
+		}(c)
+	}
+
+	code := ExitOK
+	select {
+	case s := <-sig:
+		m.logger.Log("received", s, "msg", "terminating")
+	case <-ctx.Done():
+		m.logger.Log("msg", "terminating", "reason", ctx.Err())
+	case err := <-errc:
+		if err != nil {
+			m.logger.Log("msg", "component failed", "err", err)
+			code = ExitComponentFailed
+		} else {
+			m.logger.Log("msg", "component stopped")
+		}
+	}
+
+	for _, fn := range m.before {
+		fn()
+	}
+	if m.delay > 0 {
+		m.logger.Log("msg", "delaying shutdown", "delay", m.delay)
+		select {
+		case s := <-sig:
+			m.logger.Log("received", s, "msg", "skipping shutdown delay")
+		case <-time.After(m.delay):
+		}
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), m.timeout)
+	defer cancel()
+	go func() {
+		select {
+		case s := <-sig:
+			m.logger.Log("received", s, "msg", "aborting graceful shutdown")
+			cancel()
+		case <-shutdownCtx.Done():
+		}
+	}()
+
+	if err := m.shutdown(shutdownCtx); err != nil && code == ExitOK {
+		code = ExitShutdownFailed
+	}
+	return code
+}
+
+func (m *Manager) shutdown(ctx context.Context) error {
+	var errs []error
+	for i := len(m.components) - 1; i >= 0; i-- {
+		c := m.components[i]
+		if err := c.Shutdown(ctx); err != nil {
+			m.logger.Log("component", c.name, "msg", "could not shutdown", "err", err)
+			errs = append(errs, err)
+			continue
+		}
+		m.logger.Log("component", c.name, "msg", "stopped")
+	}
+
+	for _, h := range m.after {
+		if err := h.fn(ctx); err != nil {
+			m.logger.Log("hook", h.name, "msg", "failed", "err", err)
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+type httpServer struct {
+	server *http.Server
+}
+
+// HTTPServer adapts an http.Server to a Component. The server is served over
+// HTTPS if it has a TLSConfig, which must provide its certificates.
+func HTTPServer(server *http.Server) Component {
+	return &httpServer{server}
+}
+
+func (s *httpServer) Run(_ context.Context) error {
+	var err error
+	if s.server.TLSConfig != nil {
+		err = s.server.ListenAndServeTLS("", "")
+	} else {
+		err = s.server.ListenAndServe()
+	}
+	if !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func (s *httpServer) Shutdown(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
+}
@@ -0,0 +1,148 @@
+package lifecycle_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/markhaur/trivia/pkg/lifecycle"
+	"github.com/stretchr/testify/assert"
+)
+
+type component struct {
+	name     string
+	runErr   error
+	drain    time.Duration
+	recorder *recorder
+	stop     chan struct{}
+}
+
+func newComponent(name string, r *recorder) *component {
+	return &component{name: name, recorder: r, stop: make(chan struct{})}
+}
+
+func (c *component) Run(ctx context.Context) error {
+	if c.runErr != nil {
+		return c.runErr
+	}
+	<-c.stop
+	return nil
+}
+
+func (c *component) Shutdown(ctx context.Context) error {
+	defer close(c.stop)
+	select {
+	case <-time.After(c.drain):
+		c.recorder.record(c.name)
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+type recorder struct {
+	sync.Mutex
+	events []string
+}
+
+func (r *recorder) record(event string) {
+	r.Lock()
+	defer r.Unlock()
+	r.events = append(r.events, event)
+}
+
+type flusher struct{ recorder *recorder }
+
+func (f flusher) Flush(context.Context) error {
+	f.recorder.record("flush")
+	return nil
+}
+
+func TestManagerRun(t *testing.T) {
+	tt := []struct {
+		Name           string
+		RunErr         error
+		Drain          time.Duration
+		Delay          time.Duration
+		ExpectedCode   int
+		ExpectedEvents []string
+	}{
+		{
+			Name:           "Returns 0 and stops components in reverse order",
+			ExpectedCode:   lifecycle.ExitOK,
+			ExpectedEvents: []string{"drain", "worker", "http", "flush"},
+		},
+		{
+			Name:           "Returns 1 if a component fails",
+			RunErr:         errors.New("address already in use"),
+			ExpectedCode:   lifecycle.ExitComponentFailed,
+			ExpectedEvents: []string{"drain", "worker", "http", "flush"},
+		},
+		{
+			Name:           "Returns 2 if components do not drain in time",
+			Drain:          time.Second,
+			ExpectedCode:   lifecycle.ExitShutdownFailed,
+			ExpectedEvents: []string{"drain", "flush"},
+		},
+		{
+			Name:           "Waits the shutdown delay outside the shutdown timeout",
+			Delay:          100 * time.Millisecond,
+			ExpectedCode:   lifecycle.ExitOK,
+			ExpectedEvents: []string{"drain", "worker", "http", "flush"},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			var (
+				assert  = assert.New(t)
+				events  = &recorder{}
+				manager = lifecycle.NewManager(log.NewNopLogger(), 50*time.Millisecond)
+				http    = newComponent("http", events)
+				worker  = newComponent("worker", events)
+			)
+
+			http.runErr = tc.RunErr
+			http.drain = tc.Drain
+			worker.drain = tc.Drain
+
+			manager.BeforeShutdown(func() { events.record("drain") })
+			manager.DelayShutdown(tc.Delay)
+			manager.Add("http", http)
+			manager.Add("worker", worker)
+			manager.AddFlusher("repository", flusher{events})
+			manager.AddFlusher("not a flusher", struct{}{})
+
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+
+			start := time.Now()
+			assert.Equal(tc.ExpectedCode, manager.Run(ctx), "unexpected exit code")
+			assert.GreaterOrEqual(time.Since(start), tc.Delay, "shutdown delay not honoured")
+			assert.Equal(tc.ExpectedEvents, events.events, "unexpected shutdown sequence")
+		})
+	}
+}
+
+func TestManagerRunSIGTERM(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		events  = &recorder{}
+		manager = lifecycle.NewManager(log.NewNopLogger(), time.Second)
+	)
+
+	manager.BeforeShutdown(func() { events.record("drain") })
+	manager.Add("http", newComponent("http", events))
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		syscall.Kill(syscall.Getpid(), syscall.SIGTERM)
+	}()
+
+	assert.Equal(lifecycle.ExitOK, manager.Run(context.Background()), "unexpected exit code")
+	assert.Equal([]string{"drain", "http"}, events.events, "unexpected shutdown sequence")
+}
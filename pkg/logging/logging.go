@@ -0,0 +1,110 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"sync/atomic"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+)
+
+const (
+	FormatJSON   = "json"
+	FormatLogfmt = "logfmt"
+)
+
+// New returns a logger writing to w in the given format ("json" or "logfmt")
+// which drops leveled records below lvl ("debug", "info", "warn" or "error")
+func New(w io.Writer, format, lvl string) (log.Logger, error) {
+	l, err := NewLevel(lvl)
+	if err != nil {
+		return nil, err
+	}
+	return NewWithLevel(w, format, l)
+}
+
+// NewWithLevel is like New but drops leveled records below the current value
+// of lvl, which may be changed while the logger is in use
+func NewWithLevel(w io.Writer, format string, lvl *Level) (log.Logger, error) {
+	var logger log.Logger
+	switch format {
+	case FormatJSON:
+		logger = log.NewJSONLogger(log.NewSyncWriter(w))
+	case FormatLogfmt:
+		logger = log.NewLogfmtLogger(log.NewSyncWriter(w))
+	default:
+		return nil, fmt.Errorf("unknown log format %q", format)
+	}
+
+	logger = log.With(logger, "ts", log.DefaultTimestampUTC)
+	return &filter{next: logger, level: lvl}, nil
+}
+
+// Level is a log level that can be changed at runtime, e.g. when the
+// configuration is reloaded
+type Level struct {
+	value atomic.Value // levelValue
+}
+
+type levelValue struct {
+	name   string
+	option level.Option
+}
+
+// NewLevel returns lvl ("debug", "info", "warn" or "error") as a Level
+func NewLevel(lvl string) (*Level, error) {
+	l := &Level{}
+	if err := l.Set(lvl); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Set changes the level, leaving it as it was if lvl is unknown
+func (l *Level) Set(lvl string) error {
+	option, err := levelOption(lvl)
+	if err != nil {
+		return err
+	}
+	l.value.Store(levelValue{name: lvl, option: option})
+	return nil
+}
+
+// String returns the name of the current level
+func (l *Level) String() string { return l.value.Load().(levelValue).name }
+
+// ValidLevel reports whether lvl is a known log level
+func ValidLevel(lvl string) bool {
+	_, err := levelOption(lvl)
+	return err == nil
+}
+
+// ValidFormat reports whether format is a known log format
+func ValidFormat(format string) bool {
+	return format == FormatJSON || format == FormatLogfmt
+}
+
+// filter drops leveled records below the current value of its level
+type filter struct {
+	next  log.Logger
+	level *Level
+}
+
+func (f *filter) Log(keyvals ...interface{}) error {
+	return level.NewFilter(f.next, f.level.value.Load().(levelValue).option).Log(keyvals...)
+}
+
+func levelOption(lvl string) (level.Option, error) {
+	switch lvl {
+	case "debug":
+		return level.AllowDebug(), nil
+	case "info":
+		return level.AllowInfo(), nil
+	case "warn":
+		return level.AllowWarn(), nil
+	case "error":
+		return level.AllowError(), nil
+	}
+	return nil, fmt.Errorf("unknown log level %q", lvl)
+}
@@ -0,0 +1,96 @@
+package logging_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/go-kit/log/level"
+	"github.com/markhaur/trivia/pkg/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	tt := []struct {
+		Name     string
+		Format   string
+		Level    string
+		Contains []string
+		Excludes []string
+	}{
+		{
+			Name:     "Writes json and drops debug records at info level",
+			Format:   "json",
+			Level:    "info",
+			Contains: []string{`"msg":"info"`, `"msg":"unleveled"`},
+			Excludes: []string{`"msg":"debug"`},
+		},
+		{
+			Name:     "Writes logfmt and keeps debug records at debug level",
+			Format:   "logfmt",
+			Level:    "debug",
+			Contains: []string{"msg=info", "msg=debug", "msg=unleveled"},
+		},
+		{
+			Name:     "Drops info records at error level",
+			Format:   "logfmt",
+			Level:    "error",
+			Contains: []string{"msg=unleveled"},
+			Excludes: []string{"msg=info", "msg=debug"},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			var (
+				require = require.New(t)
+				assert  = assert.New(t)
+				buf     bytes.Buffer
+			)
+
+			logger, err := logging.New(&buf, tc.Format, tc.Level)
+			require.NoError(err, "could not create logger")
+
+			level.Debug(logger).Log("msg", "debug")
+			level.Info(logger).Log("msg", "info")
+			logger.Log("msg", "unleveled")
+
+			for _, s := range tc.Contains {
+				assert.Contains(buf.String(), s)
+			}
+			for _, s := range tc.Excludes {
+				assert.NotContains(buf.String(), s)
+			}
+		})
+	}
+}
+
+func TestNewInvalid(t *testing.T) {
+	_, err := logging.New(&bytes.Buffer{}, "xml", "info")
+	assert.Error(t, err, "expected unknown format to be rejected")
+
+	_, err = logging.New(&bytes.Buffer{}, "json", "verbose")
+	assert.Error(t, err, "expected unknown level to be rejected")
+}
+
+func TestLevelChange(t *testing.T) {
+	var (
+		require = require.New(t)
+		assert  = assert.New(t)
+		buf     bytes.Buffer
+	)
+
+	lvl, err := logging.NewLevel("info")
+	require.NoError(err, "could not create level")
+	logger, err := logging.NewWithLevel(&buf, "logfmt", lvl)
+	require.NoError(err, "could not create logger")
+
+	level.Debug(logger).Log("msg", "before")
+	require.NoError(lvl.Set("debug"), "could not change level")
+	level.Debug(logger).Log("msg", "after")
+	assert.Error(lvl.Set("verbose"), "expected unknown level to be rejected")
+	assert.Equal("debug", lvl.String(), "expected the level to stay as it was")
+
+	assert.NotContains(buf.String(), "msg=before")
+	assert.Contains(buf.String(), "msg=after", "expected debug records once the level is lowered")
+}
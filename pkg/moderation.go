@@ -0,0 +1,54 @@
+package pkg
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrInvalidStatus     = errors.New("unknown trivia status")
+	ErrInvalidTransition = errors.New("trivia can't be moved to the requested status")
+)
+
+// Status is the stage of the editorial workflow a Fact is in
+type Status string
+
+const (
+	StatusDraft         Status = "draft"
+	StatusPendingReview Status = "pending_review"
+	StatusPublished     Status = "published"
+	StatusRejected      Status = "rejected"
+)
+
+// transitions lists the statuses a Fact may move to from each status
+var transitions = map[Status][]Status{
+	StatusDraft:         {StatusPendingReview},
+	StatusPendingReview: {StatusPublished, StatusRejected, StatusDraft},
+	StatusRejected:      {StatusDraft, StatusPendingReview},
+	StatusPublished:     {StatusDraft},
+}
+
+// Valid reports whether s is a known status
+func (s Status) Valid() bool {
+	_, ok := transitions[s]
+	return ok
+}
+
+// CanTransitionTo reports whether the workflow allows moving from s to status
+func (s Status) CanTransitionTo(status Status) bool {
+	for _, allowed := range transitions[s] {
+		if allowed == status {
+			return true
+		}
+	}
+	return false
+}
+
+// ReviewComment is left by a reviewer when moving a Fact through the workflow
+type ReviewComment struct {
+	Author    string
+	Body      string
+	From      Status
+	To        Status
+	CreatedAt time.Time
+}
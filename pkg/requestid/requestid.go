@@ -0,0 +1,72 @@
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/go-kit/log"
+)
+
+// HeaderKey is the header used to receive and return request IDs
+const HeaderKey = "X-Request-ID"
+
+const maxLength = 128
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying id
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the request ID stored in ctx, or an empty string
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// Logger returns a logger that annotates every line with the request ID found in ctx, if any
+func Logger(ctx context.Context, logger log.Logger) log.Logger {
+	id := FromContext(ctx)
+	if id == "" {
+		return logger
+	}
+	return log.With(logger, "requestID", id)
+}
+
+// Middleware reuses the X-Request-ID of the incoming request or generates a new
+// one, stores it in the request context and echoes it in the response headers
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(HeaderKey)
+		if !valid(id) {
+			id = generate()
+		}
+
+		w.Header().Set(HeaderKey, id)
+		next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), id)))
+	})
+}
+
+// valid rejects IDs that are empty, overly long or could be used to forge log lines
+func valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for _, c := range id {
+		if c < 0x21 || c > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func generate() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
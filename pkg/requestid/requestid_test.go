@@ -0,0 +1,71 @@
+package requestid_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/markhaur/trivia/pkg/requestid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware(t *testing.T) {
+	tt := []struct {
+		Name      string
+		RequestID string
+		Generated bool
+	}{
+		{
+			Name:      "Reuses the request id sent by the client",
+			RequestID: "5f0c9a2e-req",
+		},
+		{
+			Name:      "Generates a request id if none is sent",
+			Generated: true,
+		},
+		{
+			Name:      "Generates a request id if the one sent is too long",
+			RequestID: strings.Repeat("a", 129),
+			Generated: true,
+		},
+		{
+			Name:      "Generates a request id if the one sent contains whitespace",
+			RequestID: "abc def",
+			Generated: true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			var (
+				require = require.New(t)
+				assert  = assert.New(t)
+				seen    string
+			)
+
+			handler := requestid.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				seen = requestid.FromContext(r.Context())
+			}))
+
+			rec := httptest.NewRecorder()
+			req, err := http.NewRequest("GET", "/factlist/v1/fact", nil)
+			require.NoError(err, "could not create http request")
+			if tc.RequestID != "" {
+				req.Header.Set(requestid.HeaderKey, tc.RequestID)
+			}
+
+			handler.ServeHTTP(rec, req)
+
+			returned := rec.Header().Get(requestid.HeaderKey)
+			assert.Equal(seen, returned, "expected the same request id in context and response")
+			if tc.Generated {
+				assert.Len(returned, 32, "expected a generated request id")
+				assert.NotEqual(tc.RequestID, returned)
+				return
+			}
+			assert.Equal(tc.RequestID, returned, "expected the client request id to be reused")
+		})
+	}
+}
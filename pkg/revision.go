@@ -0,0 +1,139 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+)
+
+var ErrRevisionNotFound = errors.New("revision not found")
+
+// Actions recorded in a Revision
+const (
+	ActionSave      = "save"
+	ActionUpdate    = "update"
+	ActionRemove    = "remove"
+	ActionRevert    = "revert"
+	ActionRestore   = "restore"
+	ActionPurge     = "purge"
+	ActionReview    = "review"
+	ActionMerge     = "merge"
+	ActionTranslate = "translate"
+	ActionAttach    = "attach"
+	ActionDetach    = "detach"
+)
+
+// Change describes how a single field of a Fact changed between two revisions
+type Change struct {
+	Field string
+	From  string
+	To    string
+}
+
+// Revision records a single change made to a Fact
+type Revision struct {
+	FactID    int64
+	Number    int
+	Action    string
+	Author    string
+	Timestamp time.Time
+	Changes   []Change
+	// Fact is the state of the fact after the action was applied, or the
+	// state it was in when it was removed
+	Fact Fact
+}
+
+// HistoryRepository is the interface used to persist the Revision(s) of Fact(s)
+type HistoryRepository interface {
+	// Append stores revision, numbering it after the latest revision of the same fact
+	Append(context.Context, *Revision) error
+	FindByFactID(context.Context, int64) ([]Revision, error)
+	FindRevision(ctx context.Context, factID int64, number int) (*Revision, error)
+}
+
+// Diff lists the fields whose values differ between before and after
+func Diff(before, after Fact) []Change {
+	var changes []Change
+	if before.Question != after.Question {
+		changes = append(changes, Change{Field: "question", From: before.Question, To: after.Question})
+	}
+	if before.Answer != after.Answer {
+		changes = append(changes, Change{Field: "answer", From: before.Answer, To: after.Answer})
+	}
+	if before.Language != after.Language {
+		changes = append(changes, Change{Field: "language", From: before.Language, To: after.Language})
+	}
+	for _, lang := range translationLanguages(before, after) {
+		b, a := before.Translations[lang], after.Translations[lang]
+		if b.Question != a.Question {
+			changes = append(changes, Change{Field: "translations." + lang + ".question", From: b.Question, To: a.Question})
+		}
+		if b.Answer != a.Answer {
+			changes = append(changes, Change{Field: "translations." + lang + ".answer", From: b.Answer, To: a.Answer})
+		}
+	}
+	if before.Category != after.Category {
+		changes = append(changes, Change{Field: "category", From: before.Category, To: after.Category})
+	}
+	if before.Difficulty != after.Difficulty {
+		changes = append(changes, Change{Field: "difficulty", From: string(before.Difficulty), To: string(after.Difficulty)})
+	}
+	if b, a := formatSources(before.Sources), formatSources(after.Sources); b != a {
+		changes = append(changes, Change{Field: "sources", From: b, To: a})
+	}
+	if b, a := formatTime(before.PublishAt), formatTime(after.PublishAt); b != a {
+		changes = append(changes, Change{Field: "publishAt", From: b, To: a})
+	}
+	if b, a := formatTime(before.ExpireAt), formatTime(after.ExpireAt); b != a {
+		changes = append(changes, Change{Field: "expireAt", From: b, To: a})
+	}
+	if before.Status != after.Status {
+		changes = append(changes, Change{Field: "status", From: string(before.Status), To: string(after.Status)})
+	}
+	if !before.CreatedAt.Equal(after.CreatedAt) {
+		changes = append(changes, Change{
+			Field: "createdAt",
+			From:  before.CreatedAt.Format(time.RFC3339),
+			To:    after.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	return changes
+}
+
+// formatTime formats t as RFC 3339, or as an empty string if it is zero
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// translationLanguages returns the sorted languages either fact has been translated to
+func translationLanguages(facts ...Fact) []string {
+	var langs []string
+	seen := make(map[string]bool)
+	for _, fact := range facts {
+		for lang := range fact.Translations {
+			if !seen[lang] {
+				seen[lang] = true
+				langs = append(langs, lang)
+			}
+		}
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+type authorKey struct{}
+
+// WithAuthor returns a copy of ctx carrying the name of whoever makes the changes
+func WithAuthor(ctx context.Context, author string) context.Context {
+	return context.WithValue(ctx, authorKey{}, author)
+}
+
+// AuthorFromContext returns the author stored in ctx, or an empty string
+func AuthorFromContext(ctx context.Context) string {
+	author, _ := ctx.Value(authorKey{}).(string)
+	return author
+}
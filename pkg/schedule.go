@@ -0,0 +1,81 @@
+package pkg
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// Clock tells the time, letting tests control when scheduled facts go live or
+// expire and when sessions expire
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc adapts an ordinary function to the Clock interface
+type ClockFunc func() time.Time
+
+func (f ClockFunc) Now() time.Time { return f() }
+
+// SystemClock tells the time of the system clock
+var SystemClock Clock = ClockFunc(time.Now)
+
+// LifecycleEventType tells what happened to a scheduled fact
+type LifecycleEventType string
+
+const (
+	// EventFactLive is emitted when a published fact reaches its PublishAt time
+	EventFactLive LifecycleEventType = "fact.live"
+	// EventFactExpired is emitted when a published fact reaches its ExpireAt time
+	EventFactExpired LifecycleEventType = "fact.expired"
+)
+
+// LifecycleEvent reports that a scheduled fact became visible to players or stopped being so
+type LifecycleEvent struct {
+	Type   LifecycleEventType
+	FactID int64
+	// At is the time the fact was scheduled to change at
+	At time.Time
+}
+
+// EventPublisher is the interface lifecycle events are emitted to
+type EventPublisher interface {
+	Publish(context.Context, LifecycleEvent) error
+}
+
+// Live reports whether players may see the fact at the given time: it must be
+// published, not in the trash and within its publication window, if any
+func (f Fact) Live(now time.Time) bool {
+	if !f.Published() || f.Deleted() {
+		return false
+	}
+	if !f.PublishAt.IsZero() && now.Before(f.PublishAt) {
+		return false
+	}
+	return f.ExpireAt.IsZero() || now.Before(f.ExpireAt)
+}
+
+// ScheduledEvents returns the lifecycle events of the published facts
+// scheduled after the given time and up to until, oldest first
+func ScheduledEvents(facts []Fact, after, until time.Time) []LifecycleEvent {
+	var events []LifecycleEvent
+	within := func(t time.Time) bool { return !t.IsZero() && t.After(after) && !t.After(until) }
+	for _, fact := range facts {
+		if !fact.Published() || fact.Deleted() {
+			continue
+		}
+		if within(fact.PublishAt) {
+			events = append(events, LifecycleEvent{Type: EventFactLive, FactID: fact.ID, At: fact.PublishAt})
+		}
+		if within(fact.ExpireAt) {
+			events = append(events, LifecycleEvent{Type: EventFactExpired, FactID: fact.ID, At: fact.ExpireAt})
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		if !events[i].At.Equal(events[j].At) {
+			return events[i].At.Before(events[j].At)
+		}
+		return events[i].FactID < events[j].FactID
+	})
+	return events
+}
@@ -0,0 +1,23 @@
+package pkg
+
+import "context"
+
+// SearchResult is a Fact matching a search query
+type SearchResult struct {
+	Fact  Fact
+	Score float64
+	// Question and Answer hold the HTML-escaped text of the fact with every
+	// matched term wrapped in <mark> tags
+	Question string
+	Answer   string
+}
+
+// SearchIndex is the interface used to find Fact(s) by the words in their
+// question and answer. Only published facts are expected to be indexed.
+type SearchIndex interface {
+	// Index adds fact to the index, replacing any previous entry with the same ID
+	Index(context.Context, Fact) error
+	Remove(context.Context, int64) error
+	// Search returns at most limit facts matching query, most relevant first
+	Search(ctx context.Context, query string, limit int) ([]SearchResult, error)
+}
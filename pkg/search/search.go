@@ -0,0 +1,87 @@
+// Package search holds the text analysis shared by search index implementations
+package search
+
+import (
+	"html"
+	"strings"
+	"unicode"
+
+	"github.com/kljensen/snowball/english"
+)
+
+// Token is a term found in a text along with the byte offsets of the word it
+// was derived from
+type Token struct {
+	Term  string
+	Start int
+	End   int
+}
+
+// stopWords are too common in questions to say anything about relevance
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "by": true, "did": true, "do": true, "does": true, "for": true,
+	"from": true, "how": true, "in": true, "is": true, "it": true, "of": true,
+	"on": true, "or": true, "s": true, "that": true, "the": true, "this": true,
+	"to": true, "was": true, "what": true, "when": true, "where": true,
+	"which": true, "who": true, "why": true, "with": true,
+}
+
+// Analyze splits text into lower-cased, stemmed English terms, dropping stop words
+func Analyze(text string) []Token {
+	var tokens []Token
+	start := -1
+	for i, r := range text + " " {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			word := strings.ToLower(text[start:i])
+			if !stopWords[word] {
+				tokens = append(tokens, Token{Term: english.Stem(word, true), Start: start, End: i})
+			}
+			start = -1
+		}
+	}
+	return tokens
+}
+
+// Terms returns the distinct terms of text in the order they first appear
+func Terms(text string) []string {
+	var terms []string
+	seen := make(map[string]bool)
+	for _, token := range Analyze(text) {
+		if !seen[token.Term] {
+			seen[token.Term] = true
+			terms = append(terms, token.Term)
+		}
+	}
+	return terms
+}
+
+// Highlight HTML-escapes text and wraps every word whose term is one of terms
+// in <mark> tags
+func Highlight(text string, terms []string) string {
+	matched := make(map[string]bool, len(terms))
+	for _, term := range terms {
+		matched[term] = true
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, token := range Analyze(text) {
+		if !matched[token.Term] {
+			continue
+		}
+		b.WriteString(html.EscapeString(text[last:token.Start]))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(text[token.Start:token.End]))
+		b.WriteString("</mark>")
+		last = token.End
+	}
+	b.WriteString(html.EscapeString(text[last:]))
+	return b.String()
+}
@@ -0,0 +1,77 @@
+package search_test
+
+import (
+	"testing"
+
+	"github.com/markhaur/trivia/pkg/search"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTerms(t *testing.T) {
+	tt := []struct {
+		Name     string
+		Text     string
+		Expected []string
+	}{
+		{
+			Name:     "Returns no terms for blank text",
+			Text:     "   ",
+			Expected: nil,
+		},
+		{
+			Name:     "Drops stop words and punctuation",
+			Text:     "What is the capital of France?",
+			Expected: []string{"capit", "franc"},
+		},
+		{
+			Name:     "Stems inflected words to the same term",
+			Text:     "Running runners run",
+			Expected: []string{"run", "runner"},
+		},
+		{
+			Name:     "Lower-cases words and keeps numbers",
+			Text:     "Apollo 11 LANDED in 1969",
+			Expected: []string{"apollo", "11", "land", "1969"},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			assert.Equal(t, tc.Expected, search.Terms(tc.Text))
+		})
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	tt := []struct {
+		Name     string
+		Text     string
+		Query    string
+		Expected string
+	}{
+		{
+			Name:     "Returns the text unchanged if nothing matches",
+			Text:     "Go was announced in 2009",
+			Query:    "rust",
+			Expected: "Go was announced in 2009",
+		},
+		{
+			Name:     "Marks every word sharing a stem with the query",
+			Text:     "Which languages compile to a single language binary?",
+			Query:    "language",
+			Expected: "Which <mark>languages</mark> compile to a single <mark>language</mark> binary?",
+		},
+		{
+			Name:     "Escapes HTML around and inside matches",
+			Text:     "Is <b>bold</b> a tag?",
+			Query:    "bold tag",
+			Expected: "Is &lt;b&gt;<mark>bold</mark>&lt;/b&gt; a <mark>tag</mark>?",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			assert.Equal(t, tc.Expected, search.Highlight(tc.Text, search.Terms(tc.Query)))
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package securityheaders_test
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/markhaur/trivia/pkg/securityheaders"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	tt := []struct {
+		Name            string
+		TLS             bool
+		ExpectedHeaders map[string]string
+	}{
+		{
+			Name: "Sets the security headers over HTTP",
+			ExpectedHeaders: map[string]string{
+				"X-Content-Type-Options":    "nosniff",
+				"X-Frame-Options":           "DENY",
+				"Referrer-Policy":           "no-referrer",
+				"Content-Security-Policy":   "default-src 'none'; style-src 'unsafe-inline'; frame-ancestors 'none'",
+				"Strict-Transport-Security": "",
+			},
+		},
+		{
+			Name: "Only asks for HTTPS over HTTPS",
+			TLS:  true,
+			ExpectedHeaders: map[string]string{
+				"Strict-Transport-Security": "max-age=15552000",
+			},
+		},
+	}
+
+	handler := securityheaders.Middleware(securityheaders.DefaultConfig())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/factlist/v1/fact", nil)
+			if tc.TLS {
+				req.TLS = &tls.ConnectionState{}
+			}
+			handler.ServeHTTP(rec, req)
+
+			for k, v := range tc.ExpectedHeaders {
+				assert.Equal(t, v, rec.Header().Get(k), "unexpected %s header", k)
+			}
+		})
+	}
+}
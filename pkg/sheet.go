@@ -0,0 +1,140 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrInvalidRounds         = errors.New("rounds must be a number between 1 and 20")
+	ErrInvalidNumbering      = errors.New("numbering must be continuous or per-round")
+	ErrSheetTemplateNotFound = errors.New("sheet template not found")
+)
+
+// MaxRounds bounds the number of rounds a quiz sheet may be split into
+const MaxRounds = 20
+
+// Numbering tells how the questions of a quiz sheet are numbered
+type Numbering string
+
+const (
+	// NumberingContinuous numbers the questions of every round in a single sequence
+	NumberingContinuous Numbering = "continuous"
+	// NumberingPerRound starts numbering again at 1 in every round
+	NumberingPerRound Numbering = "per-round"
+)
+
+// SheetLayout tells how the questions of a quiz sheet are structured
+type SheetLayout struct {
+	// Rounds is the number of rounds the questions are split evenly into, 1 unless set
+	Rounds    int
+	Numbering Numbering
+}
+
+// Validate checks the number of rounds and the numbering of the layout
+func (l SheetLayout) Validate() error {
+	if l.Rounds < 0 || l.Rounds > MaxRounds {
+		return ErrInvalidRounds
+	}
+	switch l.Numbering {
+	case "", NumberingContinuous, NumberingPerRound:
+		return nil
+	}
+	return ErrInvalidNumbering
+}
+
+// QuizSheet is a printable list of questions followed by their answer key
+type QuizSheet struct {
+	Title       string
+	Description string
+	Rounds      []QuizRound
+}
+
+// QuizRound is a numbered group of questions of a QuizSheet
+type QuizRound struct {
+	Number    int
+	Questions []QuizQuestion
+}
+
+// QuizQuestion is a numbered question of a QuizSheet
+type QuizQuestion struct {
+	Number     int
+	FactID     int64
+	Question   string
+	Answer     string
+	Category   string
+	Difficulty Difficulty
+}
+
+// NewQuizSheet numbers facts in order, splitting them into the rounds of
+// layout and localizing them to the first language of chain they are
+// available in. Earlier rounds get the extra question when facts can't be
+// split evenly, and there are never more rounds than facts.
+func NewQuizSheet(title, description string, facts []Fact, chain []string, layout SheetLayout) QuizSheet {
+	rounds := layout.Rounds
+	if rounds < 1 {
+		rounds = 1
+	}
+	if rounds > len(facts) && len(facts) > 0 {
+		rounds = len(facts)
+	}
+
+	sheet := QuizSheet{Title: title, Description: description, Rounds: make([]QuizRound, 0, rounds)}
+	next := 0
+	for r := 0; r < rounds; r++ {
+		size := len(facts) / rounds
+		if r < len(facts)%rounds {
+			size++
+		}
+		round := QuizRound{Number: r + 1, Questions: make([]QuizQuestion, 0, size)}
+		for i, fact := range facts[next : next+size] {
+			number := next + i + 1
+			if layout.Numbering == NumberingPerRound {
+				number = i + 1
+			}
+			t, _ := fact.Localize(chain)
+			round.Questions = append(round.Questions, QuizQuestion{
+				Number:     number,
+				FactID:     fact.ID,
+				Question:   t.Question,
+				Answer:     t.Answer,
+				Category:   fact.Category,
+				Difficulty: fact.Difficulty,
+			})
+		}
+		next += size
+		sheet.Rounds = append(sheet.Rounds, round)
+	}
+	return sheet
+}
+
+// FactSelection tells which facts a quiz sheet is made of
+type FactSelection struct {
+	Category   string
+	Difficulty Difficulty
+	// CollectionID, when set, restricts the selection to the facts of a collection, in its order
+	CollectionID int64
+	// Random, when set, picks that many of the matching facts at random
+	Random int
+}
+
+// SheetTemplate is a custom Go template quiz sheets are rendered with. Its
+// source must define a "questions" and an "answers" template, and may
+// define a "sheet" template rendering both.
+type SheetTemplate struct {
+	Name string
+	// Format is the format the template renders, which decides how it escapes the text of facts
+	Format    string
+	Source    string
+	Owner     string
+	UpdatedAt time.Time
+}
+
+// SheetTemplateRepository is the interface used to persist the SheetTemplate(s), keyed by name
+type SheetTemplateRepository interface {
+	Save(context.Context, *SheetTemplate) error
+	FindAll(context.Context) ([]SheetTemplate, error)
+	FindByName(context.Context, string) (*SheetTemplate, error)
+	DeleteByName(context.Context, string) error
+}
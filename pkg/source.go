@@ -0,0 +1,61 @@
+package pkg
+
+import (
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Source is a reference backing up the answer of a Fact
+type Source struct {
+	URL        string
+	Title      string
+	AccessedAt time.Time
+	// Problem describes why the link checker last failed to fetch URL, empty
+	// if it succeeded or hasn't checked it yet
+	Problem string
+	// BrokenSince is when the link checker first failed to fetch URL
+	BrokenSince time.Time
+}
+
+// Broken reports whether the link checker failed to fetch the source
+func (s Source) Broken() bool { return s.Problem != "" }
+
+// SourceCheck is the outcome of fetching the URL of a Source
+type SourceCheck struct {
+	URL string
+	// Problem describes why URL couldn't be fetched, empty if it could
+	Problem string
+}
+
+// HasBrokenSources reports whether any source of the fact is broken
+func (f Fact) HasBrokenSources() bool {
+	for _, source := range f.Sources {
+		if source.Broken() {
+			return true
+		}
+	}
+	return false
+}
+
+// validSourceURL reports whether raw is an absolute http or https URL
+func validSourceURL(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// formatSources renders sources as a single line, for the history of a fact
+func formatSources(sources []Source) string {
+	formatted := make([]string, 0, len(sources))
+	for _, source := range sources {
+		if source.Title != "" {
+			formatted = append(formatted, source.Title+" <"+source.URL+">")
+			continue
+		}
+		formatted = append(formatted, source.URL)
+	}
+	return strings.Join(formatted, ", ")
+}
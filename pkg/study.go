@@ -0,0 +1,126 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+)
+
+var (
+	ErrCardNotFound   = errors.New("card not found")
+	ErrInvalidGrade   = errors.New("grade must be a number between 0 and 5")
+	ErrInvalidLearner = errors.New("learner must not be blank")
+)
+
+// Grade rates how well a learner recalled the answer of a Fact, following SM-2
+type Grade int
+
+const (
+	GradeBlackout Grade = iota
+	GradeIncorrect
+	GradeIncorrectButFamiliar
+	GradeDifficult
+	GradeHesitant
+	GradePerfect
+)
+
+// Valid reports whether g is one of the six SM-2 grades
+func (g Grade) Valid() bool { return g >= GradeBlackout && g <= GradePerfect }
+
+// Recalled reports whether the answer was recalled, however hard it was
+func (g Grade) Recalled() bool { return g >= GradeDifficult }
+
+const (
+	// DefaultEase is the ease factor of a card that has never been reviewed
+	DefaultEase = 2.5
+	minEase     = 1.3
+	// matureInterval is the interval, in days, from which a card is considered learned
+	matureInterval = 21
+)
+
+// Card is what a learner remembers of a Fact studied as a flashcard
+type Card struct {
+	Learner string
+	FactID  int64
+	// Repetitions counts the reviews recalled in a row
+	Repetitions int
+	// Interval is the number of days until the next review
+	Interval int
+	Ease     float64
+	Due      time.Time
+	Reviews  int
+	// Lapses counts the reviews that weren't recalled
+	Lapses         int
+	LastReviewedAt time.Time
+}
+
+// NewCard returns the card of a fact the learner hasn't studied yet
+func NewCard(learner string, factID int64) Card {
+	return Card{Learner: learner, FactID: factID, Ease: DefaultEase}
+}
+
+// Review schedules the next review of the card with the SM-2 algorithm
+func (c *Card) Review(grade Grade, now time.Time) {
+	if grade.Recalled() {
+		switch c.Repetitions {
+		case 0:
+			c.Interval = 1
+		case 1:
+			c.Interval = 6
+		default:
+			c.Interval = int(math.Round(float64(c.Interval) * c.Ease))
+		}
+		c.Repetitions++
+	} else {
+		c.Repetitions, c.Interval = 0, 1
+		c.Lapses++
+	}
+
+	q := float64(GradePerfect - grade)
+	c.Ease += 0.1 - q*(0.08+q*0.02)
+	if c.Ease < minEase {
+		c.Ease = minEase
+	}
+
+	c.Reviews++
+	c.LastReviewedAt = now
+	c.Due = now.AddDate(0, 0, c.Interval)
+}
+
+// Mature reports whether the card is reviewed rarely enough to be considered learned
+func (c Card) Mature() bool { return c.Interval >= matureInterval }
+
+// DueCard is a Fact to study along with the state of its card
+type DueCard struct {
+	Card Card
+	Fact Fact
+	// New is set when the learner has never studied the fact
+	New bool
+}
+
+// StudyStats summarizes how well a learner retains the facts studied
+type StudyStats struct {
+	// Cards counts the published facts the learner has studied
+	Cards int
+	// New counts the published facts the learner has never studied
+	New     int
+	Due     int
+	Mature  int
+	Reviews int
+	Lapses  int
+	// Retention is the share of reviews whose answer was recalled
+	Retention float64
+}
+
+// CardRepository is the interface used to persist the Card(s) of learners
+type CardRepository interface {
+	Find(ctx context.Context, learner string, factID int64) (*Card, error)
+	FindByLearner(ctx context.Context, learner string) ([]Card, error)
+	// Save inserts card or replaces the card of the same learner and fact
+	Save(context.Context, *Card) error
+	// Modify atomically reads the card learner has of the fact with the given
+	// ID, or a NewCard if there's none, applies fn to it and stores the
+	// result, unless fn returns an error
+	Modify(ctx context.Context, learner string, factID int64, fn func(*Card) error) (*Card, error)
+}
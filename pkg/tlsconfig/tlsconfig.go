@@ -0,0 +1,139 @@
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+)
+
+var ErrNoCertificates = errors.New("no certificates found in CA bundle")
+
+// NewServerConfig returns a TLS config serving the certificate in certFile
+// and keyFile, which is loaded again whenever either file changes so that
+// rotated certificates are picked up without a restart. If clientCAFile
+// isn't empty, the certificates clients present must be signed by one of the
+// CAs it holds. Clients may still connect without one, e.g. health probes;
+// RequireClientCert keeps them off the routes that need one.
+func NewServerConfig(certFile, keyFile, clientCAFile string, logger log.Logger) (*tls.Config, error) {
+	kp := &keyPair{certFile: certFile, keyFile: keyFile, logger: logger}
+	if err := kp.load(); err != nil {
+		return nil, err
+	}
+
+	config := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: kp.GetCertificate,
+	}
+	if clientCAFile != "" {
+		pool, err := loadCertPool(clientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return config, nil
+}
+
+// RequireClientCert rejects requests with 403 Forbidden unless their client
+// presented a certificate verified against the CAs of the server config
+func RequireClientCert(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 {
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(map[string]string{"error": "client certificate required"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func loadCertPool(file string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("could not read CA bundle: %v", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, ErrNoCertificates
+	}
+	return pool, nil
+}
+
+// keyPair holds the certificate loaded from certFile and keyFile along with
+// the state of the files it was loaded from
+type keyPair struct {
+	certFile string
+	keyFile  string
+	logger   log.Logger
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	version [2]fileVersion
+}
+
+type fileVersion struct {
+	modTime time.Time
+	size    int64
+}
+
+// GetCertificate returns the current certificate, loading it again first if
+// its files changed. A certificate that can't be loaded, e.g. because only
+// one of the files has been replaced yet, leaves the previous one in use.
+func (kp *keyPair) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	version, err := kp.stat()
+	if err == nil {
+		kp.mu.RLock()
+		changed := version != kp.version
+		kp.mu.RUnlock()
+		if changed {
+			if err := kp.load(); err != nil {
+				level.Warn(kp.logger).Log("msg", "could not reload certificate", "err", err)
+			} else {
+				level.Info(kp.logger).Log("msg", "reloaded certificate", "file", kp.certFile)
+			}
+		}
+	}
+
+	kp.mu.RLock()
+	defer kp.mu.RUnlock()
+	return kp.cert, nil
+}
+
+func (kp *keyPair) load() error {
+	version, err := kp.stat()
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(kp.certFile, kp.keyFile)
+	if err != nil {
+		return fmt.Errorf("could not load certificate: %v", err)
+	}
+
+	kp.mu.Lock()
+	defer kp.mu.Unlock()
+	kp.cert, kp.version = &cert, version
+	return nil
+}
+
+func (kp *keyPair) stat() ([2]fileVersion, error) {
+	var version [2]fileVersion
+	for i, file := range []string{kp.certFile, kp.keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return version, fmt.Errorf("could not read certificate: %v", err)
+		}
+		version[i] = fileVersion{modTime: info.ModTime(), size: info.Size()}
+	}
+	return version, nil
+}
@@ -0,0 +1,247 @@
+package tlsconfig_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	stdlog "log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/markhaur/trivia/pkg/tlsconfig"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// authority is a CA generated for a test, issuing server and client certificates
+type authority struct {
+	t      *testing.T
+	cert   *x509.Certificate
+	key    *ecdsa.PrivateKey
+	pem    []byte
+	serial int64
+}
+
+func newAuthority(t *testing.T) *authority {
+	ca := &authority{t: t}
+	ca.cert, ca.key, ca.pem = ca.issue(&x509.Certificate{
+		Subject:               pkix.Name{CommonName: "trivia test CA"},
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	})
+	return ca
+}
+
+// issue signs template with the CA, or self-signs it if the CA has no certificate yet
+func (ca *authority) issue(template *x509.Certificate) (*x509.Certificate, *ecdsa.PrivateKey, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(ca.t, err, "could not generate key")
+
+	ca.serial++
+	template.SerialNumber = big.NewInt(ca.serial)
+	template.NotBefore, template.NotAfter = time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+	parent, signer := template, key
+	if ca.cert != nil {
+		parent, signer = ca.cert, ca.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, signer)
+	require.NoError(ca.t, err, "could not create certificate")
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(ca.t, err, "could not parse certificate")
+	return cert, key, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+// keyPair issues a certificate for name and returns it as a tls.Certificate and as PEM
+func (ca *authority) keyPair(name string, usage x509.ExtKeyUsage) (tls.Certificate, []byte, []byte) {
+	_, key, certPEM := ca.issue(&x509.Certificate{
+		Subject:     pkix.Name{CommonName: name},
+		DNSNames:    []string{name},
+		IPAddresses: []net.IP{net.IPv4(127, 0, 0, 1)},
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{usage},
+	})
+	der, err := x509.MarshalECPrivateKey(key)
+	require.NoError(ca.t, err, "could not marshal key")
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	require.NoError(ca.t, err, "could not load key pair")
+	return cert, certPEM, keyPEM
+}
+
+func writeFile(t *testing.T, path string, data []byte, modTime time.Time) {
+	require.NoError(t, os.WriteFile(path, data, 0o600), "could not write file")
+	require.NoError(t, os.Chtimes(path, modTime, modTime), "could not set modification time")
+}
+
+// serve starts an HTTPS server of handler with config and returns a function making a
+// request to it, trusting ca and presenting client if it has a certificate
+func serve(t *testing.T, config *tls.Config, ca *authority, handler http.Handler) func(client tls.Certificate) (*http.Response, error) {
+	// httptest's StartTLS would add a certificate of its own, so serve TLS with config alone
+	server := httptest.NewUnstartedServer(handler)
+	server.Listener = tls.NewListener(server.Listener, config)
+	server.Config.ErrorLog = stdlog.New(io.Discard, "", 0)
+	server.Start()
+	t.Cleanup(server.Close)
+	url := "https://" + server.Listener.Addr().String()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	return func(client tls.Certificate) (*http.Response, error) {
+		transport := &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}}
+		if client.Certificate != nil {
+			transport.TLSClientConfig.Certificates = []tls.Certificate{client}
+		}
+		defer transport.CloseIdleConnections()
+		resp, err := (&http.Client{Transport: transport}).Get(url)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return resp, err
+	}
+}
+
+func TestServerConfigReloadsCertificate(t *testing.T) {
+	var (
+		require  = require.New(t)
+		assert   = assert.New(t)
+		ca       = newAuthority(t)
+		dir      = t.TempDir()
+		certFile = filepath.Join(dir, "tls.crt")
+		keyFile  = filepath.Join(dir, "tls.key")
+		now      = time.Now()
+	)
+
+	_, certPEM, keyPEM := ca.keyPair("first.trivia.test", x509.ExtKeyUsageServerAuth)
+	writeFile(t, certFile, certPEM, now)
+	writeFile(t, keyFile, keyPEM, now)
+
+	config, err := tlsconfig.NewServerConfig(certFile, keyFile, "", log.NewNopLogger())
+	require.NoError(err, "could not create tls config")
+	get := serve(t, config, ca, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	resp, err := get(tls.Certificate{})
+	require.NoError(err, "could not make request")
+	assert.Equal("first.trivia.test", resp.TLS.PeerCertificates[0].Subject.CommonName)
+
+	_, certPEM, keyPEM = ca.keyPair("second.trivia.test", x509.ExtKeyUsageServerAuth)
+	writeFile(t, certFile, certPEM, now.Add(time.Minute))
+	resp, err = get(tls.Certificate{})
+	require.NoError(err, "could not make request")
+	assert.Equal("first.trivia.test", resp.TLS.PeerCertificates[0].Subject.CommonName, "expected the previous certificate until the key is rotated too")
+
+	writeFile(t, keyFile, keyPEM, now.Add(time.Minute))
+	resp, err = get(tls.Certificate{})
+	require.NoError(err, "could not make request")
+	assert.Equal("second.trivia.test", resp.TLS.PeerCertificates[0].Subject.CommonName, "expected the rotated certificate")
+}
+
+func TestServerConfigVerifiesClients(t *testing.T) {
+	var (
+		require  = require.New(t)
+		assert   = assert.New(t)
+		ca       = newAuthority(t)
+		other    = newAuthority(t)
+		dir      = t.TempDir()
+		certFile = filepath.Join(dir, "tls.crt")
+		keyFile  = filepath.Join(dir, "tls.key")
+		caFile   = filepath.Join(dir, "ca.crt")
+	)
+
+	_, certPEM, keyPEM := ca.keyPair("trivia.test", x509.ExtKeyUsageServerAuth)
+	writeFile(t, certFile, certPEM, time.Now())
+	writeFile(t, keyFile, keyPEM, time.Now())
+	writeFile(t, caFile, ca.pem, time.Now())
+
+	config, err := tlsconfig.NewServerConfig(certFile, keyFile, caFile, log.NewNopLogger())
+	require.NoError(err, "could not create tls config")
+	get := serve(t, config, ca, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	trusted, _, _ := ca.keyPair("quiz-app", x509.ExtKeyUsageClientAuth)
+	untrusted, _, _ := other.keyPair("intruder", x509.ExtKeyUsageClientAuth)
+
+	_, err = get(trusted)
+	assert.NoError(err, "expected a client certificate signed by the CA to be accepted")
+	_, err = get(untrusted)
+	assert.Error(err, "expected a client certificate signed by another CA to be rejected")
+	_, err = get(tls.Certificate{})
+	assert.NoError(err, "expected clients without a certificate to connect, e.g. health probes")
+}
+
+func TestRequireClientCert(t *testing.T) {
+	var (
+		require  = require.New(t)
+		ca       = newAuthority(t)
+		dir      = t.TempDir()
+		certFile = filepath.Join(dir, "tls.crt")
+		keyFile  = filepath.Join(dir, "tls.key")
+		caFile   = filepath.Join(dir, "ca.crt")
+	)
+
+	_, certPEM, keyPEM := ca.keyPair("trivia.test", x509.ExtKeyUsageServerAuth)
+	writeFile(t, certFile, certPEM, time.Now())
+	writeFile(t, keyFile, keyPEM, time.Now())
+	writeFile(t, caFile, ca.pem, time.Now())
+
+	config, err := tlsconfig.NewServerConfig(certFile, keyFile, caFile, log.NewNopLogger())
+	require.NoError(err, "could not create tls config")
+	get := serve(t, config, ca, tlsconfig.RequireClientCert(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+	trusted, _, _ := ca.keyPair("quiz-app", x509.ExtKeyUsageClientAuth)
+
+	tt := []struct {
+		Name           string
+		Client         tls.Certificate
+		ExpectedStatus int
+	}{
+		{
+			Name:           "Serves clients with a verified certificate",
+			Client:         trusted,
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:           "Returns 403 to clients without a certificate",
+			ExpectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			resp, err := get(tc.Client)
+			require.NoError(err, "could not make request")
+			assert.Equal(t, tc.ExpectedStatus, resp.StatusCode, "unexpected http status code")
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	tlsconfig.RequireClientCert(http.NotFoundHandler()).ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, http.StatusForbidden, rec.Code, "expected plain HTTP requests to be rejected")
+}
+
+func TestNewServerConfigInvalid(t *testing.T) {
+	dir := t.TempDir()
+	empty := filepath.Join(dir, "empty.pem")
+	writeFile(t, empty, []byte("not a certificate"), time.Now())
+
+	_, err := tlsconfig.NewServerConfig(filepath.Join(dir, "missing.crt"), filepath.Join(dir, "missing.key"), "", log.NewNopLogger())
+	assert.Error(t, err, "expected missing files to be rejected")
+
+	ca := newAuthority(t)
+	_, certPEM, keyPEM := ca.keyPair("trivia.test", x509.ExtKeyUsageServerAuth)
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	writeFile(t, certFile, certPEM, time.Now())
+	writeFile(t, keyFile, keyPEM, time.Now())
+	_, err = tlsconfig.NewServerConfig(certFile, keyFile, empty, log.NewNopLogger())
+	assert.Equal(t, tlsconfig.ErrNoCertificates, err, "expected a CA bundle without certificates to be rejected")
+}
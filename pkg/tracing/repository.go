@@ -0,0 +1,117 @@
+package tracing
+
+import (
+	"context"
+	"time"
+
+	"github.com/markhaur/trivia/pkg"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type factRepository struct {
+	tracer trace.Tracer
+	next   pkg.FactRepository
+}
+
+// NewFactRepository decorates a FactRepository so that every call is recorded as a span
+func NewFactRepository(next pkg.FactRepository, tracer trace.Tracer) pkg.FactRepository {
+	return &factRepository{tracer: tracer, next: next}
+}
+
+func (r *factRepository) Insert(ctx context.Context, fact *pkg.Fact) (err error) {
+	ctx, span := r.tracer.Start(ctx, "FactRepository.Insert", trace.WithSpanKind(trace.SpanKindClient))
+	defer func() { end(span, err) }()
+	return r.next.Insert(ctx, fact)
+}
+
+func (r *factRepository) FindAll(ctx context.Context) (_ []pkg.Fact, err error) {
+	ctx, span := r.tracer.Start(ctx, "FactRepository.FindAll", trace.WithSpanKind(trace.SpanKindClient))
+	defer func() { end(span, err) }()
+	return r.next.FindAll(ctx)
+}
+
+func (r *factRepository) FindByID(ctx context.Context, id int64) (_ *pkg.Fact, err error) {
+	ctx, span := r.tracer.Start(ctx, "FactRepository.FindByID", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.Int64("fact.id", id)))
+	defer func() { end(span, err) }()
+	return r.next.FindByID(ctx, id)
+}
+
+func (r *factRepository) Update(ctx context.Context, fact *pkg.Fact) (err error) {
+	ctx, span := r.tracer.Start(ctx, "FactRepository.Update", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.Int64("fact.id", fact.ID)))
+	defer func() { end(span, err) }()
+	return r.next.Update(ctx, fact)
+}
+
+func (r *factRepository) Modify(ctx context.Context, id int64, version int64, fn func(*pkg.Fact) error) (_ *pkg.Fact, err error) {
+	ctx, span := r.tracer.Start(ctx, "FactRepository.Modify", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.Int64("fact.id", id)))
+	defer func() { end(span, err) }()
+	return r.next.Modify(ctx, id, version, fn)
+}
+
+func (r *factRepository) DeleteByID(ctx context.Context, id int64, version int64) (err error) {
+	ctx, span := r.tracer.Start(ctx, "FactRepository.DeleteByID", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.Int64("fact.id", id)))
+	defer func() { end(span, err) }()
+	return r.next.DeleteByID(ctx, id, version)
+}
+
+func (r *factRepository) Merge(ctx context.Context, id int64, version int64, duplicates map[int64]int64) (_ *pkg.Fact, err error) {
+	ctx, span := r.tracer.Start(ctx, "FactRepository.Merge", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.Int64("fact.id", id)))
+	defer func() { end(span, err) }()
+	return r.next.Merge(ctx, id, version, duplicates)
+}
+
+func (r *factRepository) FindDeleted(ctx context.Context) (_ []pkg.Fact, err error) {
+	ctx, span := r.tracer.Start(ctx, "FactRepository.FindDeleted", trace.WithSpanKind(trace.SpanKindClient))
+	defer func() { end(span, err) }()
+	return r.next.FindDeleted(ctx)
+}
+
+func (r *factRepository) Restore(ctx context.Context, id int64, version int64) (_ *pkg.Fact, err error) {
+	ctx, span := r.tracer.Start(ctx, "FactRepository.Restore", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.Int64("fact.id", id)))
+	defer func() { end(span, err) }()
+	return r.next.Restore(ctx, id, version)
+}
+
+func (r *factRepository) Purge(ctx context.Context, before time.Time) (_ []pkg.Fact, err error) {
+	ctx, span := r.tracer.Start(ctx, "FactRepository.Purge", trace.WithSpanKind(trace.SpanKindClient))
+	defer func() { end(span, err) }()
+	return r.next.Purge(ctx, before)
+}
+
+// Ping forwards to the decorated repository when it implements pkg.Pinger
+func (r *factRepository) Ping(ctx context.Context) (err error) {
+	pinger, ok := r.next.(pkg.Pinger)
+	if !ok {
+		return nil
+	}
+	ctx, span := r.tracer.Start(ctx, "FactRepository.Ping", trace.WithSpanKind(trace.SpanKindClient))
+	defer func() { end(span, err) }()
+	return pinger.Ping(ctx)
+}
+
+// Flush forwards to the decorated repository when it implements pkg.Flusher
+func (r *factRepository) Flush(ctx context.Context) (err error) {
+	flusher, ok := r.next.(pkg.Flusher)
+	if !ok {
+		return nil
+	}
+	ctx, span := r.tracer.Start(ctx, "FactRepository.Flush", trace.WithSpanKind(trace.SpanKindClient))
+	defer func() { end(span, err) }()
+	return flusher.Flush(ctx)
+}
+
+func end(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,76 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/go-kit/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+var ErrUnknownExporter = errors.New("unknown trace exporter")
+
+// Config describes where finished spans are exported to
+type Config struct {
+	ServiceName  string
+	Exporter     string
+	OTLPEndpoint string
+	OTLPInsecure bool
+}
+
+// NewTracerProvider builds a TracerProvider exporting spans as described by config.
+// Callers must Shutdown the provider to flush pending spans.
+func NewTracerProvider(ctx context.Context, config Config) (*sdktrace.TracerProvider, error) {
+	var exporter sdktrace.SpanExporter
+	switch config.Exporter {
+	case ExporterNone, "":
+	case ExporterStdout:
+		e, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		if err != nil {
+			return nil, fmt.Errorf("could not create stdout exporter: %v", err)
+		}
+		exporter = e
+	case ExporterOTLP:
+		opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(config.OTLPEndpoint)}
+		if config.OTLPInsecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		e, err := otlptracehttp.New(ctx, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("could not create otlp exporter: %v", err)
+		}
+		exporter = e
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownExporter, config.Exporter)
+	}
+
+	res := resource.NewWithAttributes("", attribute.String("service.name", config.ServiceName))
+	opts := []sdktrace.TracerProviderOption{sdktrace.WithResource(res)}
+	if exporter != nil {
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	}
+	return sdktrace.NewTracerProvider(opts...), nil
+}
+
+// Logger returns a logger that annotates every line with the trace and span
+// IDs found in ctx, if any.
+func Logger(ctx context.Context, logger log.Logger) log.Logger {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return logger
+	}
+	return log.With(logger, "traceID", sc.TraceID().String(), "spanID", sc.SpanID().String())
+}
@@ -0,0 +1,75 @@
+package tracing_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/markhaur/trivia/pkg"
+	"github.com/markhaur/trivia/pkg/inmem"
+	"github.com/markhaur/trivia/pkg/tracing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestFactRepository(t *testing.T) {
+	var (
+		require  = require.New(t)
+		assert   = assert.New(t)
+		recorder = tracetest.NewSpanRecorder()
+		provider = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+		repo     = tracing.NewFactRepository(inmem.NewFactRepository(), provider.Tracer("test"))
+	)
+
+	fact := pkg.Fact{Question: "what is your github username?", Answer: "markhaur"}
+	require.NoError(repo.Insert(context.TODO(), &fact), "could not insert fact")
+
+	_, err := repo.FindByID(context.TODO(), 1337)
+	assert.ErrorIs(err, pkg.ErrFactNotFound)
+
+	spans := recorder.Ended()
+	require.Len(spans, 2, "expected one span per repository call")
+	assert.Equal("FactRepository.Insert", spans[0].Name())
+	assert.Equal("FactRepository.FindByID", spans[1].Name())
+	assert.Len(spans[1].Events(), 1, "expected error to be recorded on span")
+}
+
+func TestNewTracerProviderOTLP(t *testing.T) {
+	var (
+		require  = require.New(t)
+		assert   = assert.New(t)
+		received int32
+	)
+
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && r.URL.Path == "/v1/traces" {
+			atomic.AddInt32(&received, 1)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer collector.Close()
+
+	provider, err := tracing.NewTracerProvider(context.TODO(), tracing.Config{
+		ServiceName:  "trivia-test",
+		Exporter:     tracing.ExporterOTLP,
+		OTLPEndpoint: strings.TrimPrefix(collector.URL, "http://"),
+		OTLPInsecure: true,
+	})
+	require.NoError(err, "could not create tracer provider")
+
+	_, span := provider.Tracer("test").Start(context.TODO(), "operation")
+	span.End()
+
+	require.NoError(provider.Shutdown(context.TODO()), "could not flush spans")
+	assert.Equal(int32(1), atomic.LoadInt32(&received), "expected collector to receive exported spans")
+}
+
+func TestNewTracerProviderUnknownExporter(t *testing.T) {
+	_, err := tracing.NewTracerProvider(context.TODO(), tracing.Config{Exporter: "zipkin"})
+	assert.ErrorIs(t, err, tracing.ErrUnknownExporter)
+}
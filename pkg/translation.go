@@ -0,0 +1,69 @@
+package pkg
+
+import (
+	"errors"
+
+	"golang.org/x/text/language"
+)
+
+var (
+	ErrInvalidLanguage      = errors.New("invalid language tag")
+	ErrTranslationRedundant = errors.New("trivia is already written in that language")
+)
+
+// Translation holds the question and answer of a Fact in another language
+type Translation struct {
+	Question string
+	Answer   string
+}
+
+// ParseLanguage returns the canonical form of a BCP 47 language tag
+func ParseLanguage(tag string) (string, error) {
+	t, err := language.Parse(tag)
+	if err != nil || t == language.Und {
+		return "", ErrInvalidLanguage
+	}
+	return t.String(), nil
+}
+
+// FallbackChain expands the preferred languages, most preferred first, with
+// their more general parents so that, for example, de-AT falls back to de
+// before moving on to the next preferred language. Invalid tags are skipped.
+func FallbackChain(preferred ...string) []string {
+	var chain []string
+	seen := make(map[string]bool)
+	for _, tag := range preferred {
+		t, err := language.Parse(tag)
+		if err != nil {
+			continue
+		}
+		for ; t != language.Und; t = t.Parent() {
+			if !seen[t.String()] {
+				seen[t.String()] = true
+				chain = append(chain, t.String())
+			}
+		}
+	}
+	return chain
+}
+
+// Localize returns the question and answer of the fact in the first language
+// of chain it is available in, along with that language. It falls back to the
+// language the fact is written in.
+func (f Fact) Localize(chain []string) (Translation, string) {
+	for _, lang := range chain {
+		if lang == f.Language {
+			break
+		}
+		if t, ok := f.Translations[lang]; ok {
+			return t, lang
+		}
+	}
+	return Translation{Question: f.Question, Answer: f.Answer}, f.Language
+}
+
+// Translated reports whether the fact is available in lang
+func (f Fact) Translated(lang string) bool {
+	_, ok := f.Translations[lang]
+	return ok || f.Language == lang
+}
@@ -0,0 +1,62 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrUserNotFound       = errors.New("user not found")
+	ErrUsernameTaken      = errors.New("username is already taken")
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrSessionNotFound    = errors.New("session not found")
+)
+
+// User is a player with an account
+type User struct {
+	ID int64
+	// Username is unique and lower-cased
+	Username     string
+	PasswordHash []byte
+	Profile      Profile
+	CreatedAt    time.Time
+}
+
+// Profile holds what a player tells about themselves
+type Profile struct {
+	DisplayName string
+	// Language is the BCP 47 tag of the language the player prefers facts in
+	Language string
+	// Categories lists the topics the player is interested in
+	Categories []string
+}
+
+// Session authenticates the requests of a logged in User
+type Session struct {
+	// TokenHash is the hex encoded SHA-256 of the token handed to the user,
+	// so that a leaked repository doesn't leak usable tokens
+	TokenHash string
+	UserID    int64
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// Expired reports whether the session can no longer be used at the given time
+func (s Session) Expired(now time.Time) bool { return !now.Before(s.ExpiresAt) }
+
+// UserRepository is the interface used to persist the User(s).
+// Insert fails with ErrUsernameTaken if the username is already used.
+type UserRepository interface {
+	Insert(context.Context, *User) error
+	FindByID(context.Context, int64) (*User, error)
+	FindByUsername(context.Context, string) (*User, error)
+	Update(context.Context, *User) error
+}
+
+// SessionRepository is the interface used to persist the Session(s) of users
+type SessionRepository interface {
+	Insert(context.Context, *Session) error
+	Find(ctx context.Context, tokenHash string) (*Session, error)
+	Delete(ctx context.Context, tokenHash string) error
+}
@@ -0,0 +1,389 @@
+package users
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/markhaur/trivia/pkg"
+	"github.com/markhaur/trivia/pkg/requestid"
+	"github.com/matryer/way"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func NewServer(service Service, logger log.Logger) http.Handler {
+	s := server{service: service}
+
+	var handleRegister http.Handler
+	handleRegister = s.handleRegister()
+	handleRegister = httpLoggingMiddleware(logger, "handleRegister")(handleRegister)
+	handleRegister = httpTracingMiddleware("handleRegister")(handleRegister)
+
+	var handleGetUser http.Handler
+	handleGetUser = s.handleGetUser()
+	handleGetUser = httpLoggingMiddleware(logger, "handleGetUser")(handleGetUser)
+	handleGetUser = httpTracingMiddleware("handleGetUser")(handleGetUser)
+
+	var handleLogin http.Handler
+	handleLogin = s.handleLogin()
+	handleLogin = httpLoggingMiddleware(logger, "handleLogin")(handleLogin)
+	handleLogin = httpTracingMiddleware("handleLogin")(handleLogin)
+
+	var handleLogout http.Handler
+	handleLogout = s.handleLogout()
+	handleLogout = httpLoggingMiddleware(logger, "handleLogout")(handleLogout)
+	handleLogout = httpTracingMiddleware("handleLogout")(handleLogout)
+
+	var handleMe http.Handler
+	handleMe = s.handleMe()
+	handleMe = httpLoggingMiddleware(logger, "handleMe")(handleMe)
+	handleMe = httpTracingMiddleware("handleMe")(handleMe)
+
+	var handleUpdateProfile http.Handler
+	handleUpdateProfile = s.handleUpdateProfile()
+	handleUpdateProfile = httpLoggingMiddleware(logger, "handleUpdateProfile")(handleUpdateProfile)
+	handleUpdateProfile = httpTracingMiddleware("handleUpdateProfile")(handleUpdateProfile)
+
+	router := way.NewRouter()
+
+	router.Handle("POST", "/users/v1/users", handleRegister)
+	router.Handle("GET", "/users/v1/users/:username", handleGetUser)
+	router.Handle("POST", "/users/v1/sessions", handleLogin)
+	router.Handle("DELETE", "/users/v1/sessions/current", handleLogout)
+	router.Handle("GET", "/users/v1/me", handleMe)
+	router.Handle("PUT", "/users/v1/me/profile", handleUpdateProfile)
+
+	router.NotFound = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { writeError(w, ErrResourceNotFound) })
+
+	return AuthenticationMiddleware(service)(router)
+}
+
+const (
+	contentTypeKey    = "Content-Type"
+	contentTypeValue  = "application/json; charset=utf-8"
+	authorizationKey  = "Authorization"
+	authenticateKey   = "WWW-Authenticate"
+	bearerScheme      = "Bearer"
+	locationKey       = "Location"
+	cacheControlKey   = "Cache-Control"
+	authenticateValue = `Bearer realm="trivia"`
+)
+
+var ErrResourceNotFound = errors.New("resource not found")
+
+type ErrInvalidRequestBody struct{ err error }
+
+func (e ErrInvalidRequestBody) Error() string { return fmt.Sprintf("invalid request body: %v", e.err) }
+
+type server struct {
+	service Service
+}
+
+// profile is the JSON representation of a user, without their credentials
+type profile struct {
+	Username    string    `json:"username"`
+	DisplayName string    `json:"displayName,omitempty"`
+	Language    string    `json:"language,omitempty"`
+	Categories  []string  `json:"categories"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+func newProfile(user *pkg.User) profile {
+	categories := user.Profile.Categories
+	if categories == nil {
+		categories = []string{}
+	}
+	return profile{
+		Username:    user.Username,
+		DisplayName: user.Profile.DisplayName,
+		Language:    user.Profile.Language,
+		Categories:  categories,
+		CreatedAt:   user.CreatedAt,
+	}
+}
+
+func (s *server) handleRegister() http.HandlerFunc {
+	type request struct {
+		Username    string   `json:"username"`
+		Password    string   `json:"password"`
+		DisplayName string   `json:"displayName"`
+		Language    string   `json:"language"`
+		Categories  []string `json:"categories"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, ErrInvalidRequestBody{err})
+			return
+		}
+
+		user, err := s.service.Register(r.Context(), req.Username, req.Password, pkg.Profile{
+			DisplayName: req.DisplayName,
+			Language:    req.Language,
+			Categories:  req.Categories,
+		})
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		w.Header().Set(contentTypeKey, contentTypeValue)
+		w.Header().Set(locationKey, "/users/v1/users/"+user.Username)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(newProfile(user))
+	}
+}
+
+func (s *server) handleGetUser() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, err := s.service.Get(r.Context(), way.Param(r.Context(), "username"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		w.Header().Set(contentTypeKey, contentTypeValue)
+		json.NewEncoder(w).Encode(newProfile(user))
+	}
+}
+
+func (s *server) handleLogin() http.HandlerFunc {
+	type request struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+	type response struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expiresAt"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, ErrInvalidRequestBody{err})
+			return
+		}
+
+		token, session, err := s.service.Login(r.Context(), req.Username, req.Password)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		w.Header().Set(contentTypeKey, contentTypeValue)
+		w.Header().Set(cacheControlKey, "no-store")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(response{Token: token, ExpiresAt: session.ExpiresAt})
+	}
+}
+
+func (s *server) handleLogout() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := FromContext(r.Context()); !ok {
+			writeError(w, ErrUnauthenticated)
+			return
+		}
+		if err := s.service.Logout(r.Context(), bearerToken(r)); err != nil {
+			writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (s *server) handleMe() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := FromContext(r.Context())
+		if !ok {
+			writeError(w, ErrUnauthenticated)
+			return
+		}
+		w.Header().Set(contentTypeKey, contentTypeValue)
+		json.NewEncoder(w).Encode(newProfile(user))
+	}
+}
+
+func (s *server) handleUpdateProfile() http.HandlerFunc {
+	type request struct {
+		DisplayName string   `json:"displayName"`
+		Language    string   `json:"language"`
+		Categories  []string `json:"categories"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := FromContext(r.Context())
+		if !ok {
+			writeError(w, ErrUnauthenticated)
+			return
+		}
+
+		var req request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, ErrInvalidRequestBody{err})
+			return
+		}
+
+		user, err := s.service.UpdateProfile(r.Context(), user.ID, pkg.Profile{
+			DisplayName: req.DisplayName,
+			Language:    req.Language,
+			Categories:  req.Categories,
+		})
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		w.Header().Set(contentTypeKey, contentTypeValue)
+		json.NewEncoder(w).Encode(newProfile(user))
+	}
+}
+
+type userKey struct{}
+
+// WithUser returns a copy of ctx carrying the authenticated user
+func WithUser(ctx context.Context, user *pkg.User) context.Context {
+	return context.WithValue(ctx, userKey{}, user)
+}
+
+// FromContext returns the authenticated user stored in ctx, if any
+func FromContext(ctx context.Context) (*pkg.User, bool) {
+	user, ok := ctx.Value(userKey{}).(*pkg.User)
+	return user, ok && user != nil
+}
+
+// AuthenticationMiddleware authenticates requests carrying a bearer session
+// token, storing the user in their context and recording them as the author
+// of the changes the request makes. Requests with an invalid token are
+// rejected; requests without one are let through anonymously.
+func AuthenticationMiddleware(service Service) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get(authorizationKey) == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			user, err := service.Authenticate(r.Context(), bearerToken(r))
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+			ctx := pkg.WithAuthor(WithUser(r.Context(), user), user.Username)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// bearerToken returns the token of the bearer Authorization header, or an empty string
+func bearerToken(r *http.Request) string {
+	scheme, token, ok := strings.Cut(r.Header.Get(authorizationKey), " ")
+	if !ok || !strings.EqualFold(scheme, bearerScheme) {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	w.Header().Set(contentTypeKey, contentTypeValue)
+	switch err {
+	case ErrResourceNotFound, pkg.ErrUserNotFound:
+		w.WriteHeader(http.StatusNotFound)
+	case pkg.ErrUsernameTaken:
+		w.WriteHeader(http.StatusConflict)
+	case ErrUnauthenticated, pkg.ErrInvalidCredentials:
+		w.Header().Set(authenticateKey, authenticateValue)
+		w.WriteHeader(http.StatusUnauthorized)
+	default:
+		switch err.(type) {
+		case ErrInvalidRequestBody:
+			w.WriteHeader(http.StatusBadRequest)
+		case *pkg.ValidationError:
+			w.WriteHeader(http.StatusUnprocessableEntity)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}
+
+	type fieldError struct {
+		Field   string `json:"field"`
+		Message string `json:"message"`
+	}
+
+	body := map[string]interface{}{"error": err.Error()}
+	if verr, ok := err.(*pkg.ValidationError); ok {
+		details := make([]fieldError, 0, len(verr.Problems))
+		for _, p := range verr.Problems {
+			details = append(details, fieldError{Field: p.Field, Message: p.Message})
+		}
+		body["details"] = details
+	}
+	if id := w.Header().Get(requestid.HeaderKey); id != "" {
+		body["requestId"] = id
+	}
+	json.NewEncoder(w).Encode(body)
+}
+
+type loggingResponseWriter struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (lrw *loggingResponseWriter) WriteHeader(code int) {
+	lrw.statusCode = code
+	lrw.ResponseWriter.WriteHeader(code)
+}
+
+func httpLoggingMiddleware(logger log.Logger, operation string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			begin := time.Now()
+			lrw := &loggingResponseWriter{w, http.StatusOK}
+			next.ServeHTTP(lrw, r)
+			level.Info(contextLogger(r.Context(), logger)).Log(
+				"operation", operation,
+				"method", r.Method,
+				"path", r.URL.Path,
+				"took", time.Since(begin),
+				"status", lrw.statusCode,
+			)
+		})
+	}
+}
+
+const instrumentationName = "github.com/markhaur/trivia/pkg/users"
+
+// httpTracingMiddleware starts a server span for every request, continuing the
+// trace described by the incoming W3C traceparent header if present.
+func httpTracingMiddleware(operation string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+			ctx, span := otel.Tracer(instrumentationName).Start(ctx, operation,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					attribute.String("http.method", r.Method),
+					attribute.String("http.target", r.URL.Path),
+				),
+			)
+			defer span.End()
+			if id := requestid.FromContext(ctx); id != "" {
+				span.SetAttributes(attribute.String("http.request_id", id))
+			}
+
+			lrw := &loggingResponseWriter{w, http.StatusOK}
+			next.ServeHTTP(lrw, r.WithContext(ctx))
+
+			span.SetAttributes(attribute.Int("http.status_code", lrw.statusCode))
+			if lrw.statusCode >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(lrw.statusCode))
+			}
+		})
+	}
+}
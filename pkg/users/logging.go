@@ -0,0 +1,102 @@
+package users
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/markhaur/trivia/pkg"
+	"github.com/markhaur/trivia/pkg/requestid"
+	"github.com/markhaur/trivia/pkg/tracing"
+)
+
+// contextLogger annotates logger with the request and trace IDs found in ctx
+func contextLogger(ctx context.Context, logger log.Logger) log.Logger {
+	return requestid.Logger(ctx, tracing.Logger(ctx, logger))
+}
+
+// LoggingMiddleware logs every call to the service. Passwords and tokens are never logged.
+func LoggingMiddleware(logger log.Logger) Middleware {
+	return func(s Service) Service { return &loggingMiddleware{logger, s} }
+}
+
+type loggingMiddleware struct {
+	logger log.Logger
+	Service
+}
+
+func (s *loggingMiddleware) Register(ctx context.Context, username, password string, profile pkg.Profile) (_ *pkg.User, err error) {
+	defer func(begin time.Time) {
+		level.Info(contextLogger(ctx, s.logger)).Log(
+			"method", "register",
+			"username", username,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+	return s.Service.Register(ctx, username, password, profile)
+}
+
+func (s *loggingMiddleware) Login(ctx context.Context, username, password string) (_ string, _ *pkg.Session, err error) {
+	defer func(begin time.Time) {
+		level.Info(contextLogger(ctx, s.logger)).Log(
+			"method", "login",
+			"username", username,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+	return s.Service.Login(ctx, username, password)
+}
+
+func (s *loggingMiddleware) Logout(ctx context.Context, token string) (err error) {
+	defer func(begin time.Time) {
+		level.Info(contextLogger(ctx, s.logger)).Log(
+			"method", "logout",
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+	return s.Service.Logout(ctx, token)
+}
+
+func (s *loggingMiddleware) Authenticate(ctx context.Context, token string) (user *pkg.User, err error) {
+	defer func(begin time.Time) {
+		var username string
+		if user != nil {
+			username = user.Username
+		}
+		level.Debug(contextLogger(ctx, s.logger)).Log(
+			"method", "authenticate",
+			"username", username,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+	return s.Service.Authenticate(ctx, token)
+}
+
+func (s *loggingMiddleware) Get(ctx context.Context, username string) (_ *pkg.User, err error) {
+	defer func(begin time.Time) {
+		level.Info(contextLogger(ctx, s.logger)).Log(
+			"method", "get",
+			"username", username,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+	return s.Service.Get(ctx, username)
+}
+
+func (s *loggingMiddleware) UpdateProfile(ctx context.Context, id int64, profile pkg.Profile) (_ *pkg.User, err error) {
+	defer func(begin time.Time) {
+		level.Info(contextLogger(ctx, s.logger)).Log(
+			"method", "updateProfile",
+			"id", id,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+	return s.Service.UpdateProfile(ctx, id, profile)
+}
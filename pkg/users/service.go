@@ -0,0 +1,294 @@
+package users
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/markhaur/trivia/pkg"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Service is an application service that lets players register, log in and
+// manage their profile
+type Service interface {
+	Register(ctx context.Context, username, password string, profile pkg.Profile) (*pkg.User, error)
+	// Login returns a new session along with the token authenticating it
+	Login(ctx context.Context, username, password string) (string, *pkg.Session, error)
+	Logout(ctx context.Context, token string) error
+	// Authenticate returns the user the token was issued to
+	Authenticate(ctx context.Context, token string) (*pkg.User, error)
+	Get(ctx context.Context, username string) (*pkg.User, error)
+	UpdateProfile(ctx context.Context, id int64, profile pkg.Profile) (*pkg.User, error)
+}
+
+var ErrUnauthenticated = errors.New("missing, invalid or expired session token")
+
+const (
+	minUsernameLength = 3
+	maxUsernameLength = 32
+	minPasswordLength = 8
+	// maxPasswordLength is the number of bytes bcrypt takes into account
+	maxPasswordLength    = 72
+	maxDisplayNameLength = 50
+	maxCategories        = 20
+	maxCategoryLength    = 50
+	tokenLength          = 32
+)
+
+// Middleware describes a Service Middleware
+type Middleware func(Service) Service
+
+// Option configures the Service returned by NewService
+type Option func(*service)
+
+// WithSessionTTL sets how long a session lasts after login, 30 days unless
+// configured otherwise
+func WithSessionTTL(ttl time.Duration) Option {
+	return func(s *service) { s.ttl = ttl }
+}
+
+// WithClock replaces the system clock used to tell when sessions expire
+func WithClock(clock pkg.Clock) Option {
+	return func(s *service) { s.clock = clock }
+}
+
+// WithHashCost sets the bcrypt cost passwords are hashed with
+func WithHashCost(cost int) Option {
+	return func(s *service) { s.cost = cost }
+}
+
+type service struct {
+	users    pkg.UserRepository
+	sessions pkg.SessionRepository
+	ttl      time.Duration
+	cost     int
+	clock    pkg.Clock
+	// dummyHash is compared against the password of unknown users so that
+	// logins take as long whether the username exists or not
+	dummyHash []byte
+}
+
+func NewService(users pkg.UserRepository, sessions pkg.SessionRepository, opts ...Option) Service {
+	s := &service{users: users, sessions: sessions, ttl: 30 * 24 * time.Hour, cost: bcrypt.DefaultCost, clock: pkg.SystemClock}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.dummyHash, _ = bcrypt.GenerateFromPassword([]byte("not a password"), s.cost)
+	return s
+}
+
+// Register creates the account of a new player
+func (s *service) Register(ctx context.Context, username, password string, profile pkg.Profile) (*pkg.User, error) {
+	username = normalizeUsername(username)
+
+	verr := &pkg.ValidationError{Subject: "account"}
+	validateUsername(verr, username)
+	validatePassword(verr, password)
+	profile = normalizeProfile(verr, profile)
+	if err := verr.Err(); err != nil {
+		return nil, err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), s.cost)
+	if err != nil {
+		return nil, fmt.Errorf("could not hash password: %v", err)
+	}
+
+	user := pkg.User{Username: username, PasswordHash: hash, Profile: profile, CreatedAt: s.clock.Now()}
+	if err := s.users.Insert(ctx, &user); err != nil {
+		if err == pkg.ErrUsernameTaken {
+			return nil, err
+		}
+		return nil, fmt.Errorf("could not register user: %v", err)
+	}
+	return &user, nil
+}
+
+// Login checks the password of the user and opens a new session
+func (s *service) Login(ctx context.Context, username, password string) (string, *pkg.Session, error) {
+	user, err := s.users.FindByUsername(ctx, normalizeUsername(username))
+	switch {
+	case err == pkg.ErrUserNotFound:
+		bcrypt.CompareHashAndPassword(s.dummyHash, []byte(password))
+		return "", nil, pkg.ErrInvalidCredentials
+	case err != nil:
+		return "", nil, fmt.Errorf("could not find user: %v", err)
+	}
+	if err := bcrypt.CompareHashAndPassword(user.PasswordHash, []byte(password)); err != nil {
+		return "", nil, pkg.ErrInvalidCredentials
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return "", nil, fmt.Errorf("could not generate session token: %v", err)
+	}
+	now := s.clock.Now()
+	session := pkg.Session{TokenHash: hashToken(token), UserID: user.ID, CreatedAt: now, ExpiresAt: now.Add(s.ttl)}
+	if err := s.sessions.Insert(ctx, &session); err != nil {
+		return "", nil, fmt.Errorf("could not open session: %v", err)
+	}
+	return token, &session, nil
+}
+
+// Logout closes the session authenticated by token
+func (s *service) Logout(ctx context.Context, token string) error {
+	if err := s.sessions.Delete(ctx, hashToken(token)); err != nil {
+		return fmt.Errorf("could not close session: %v", err)
+	}
+	return nil
+}
+
+func (s *service) Authenticate(ctx context.Context, token string) (*pkg.User, error) {
+	if token == "" {
+		return nil, ErrUnauthenticated
+	}
+
+	session, err := s.sessions.Find(ctx, hashToken(token))
+	switch {
+	case err == pkg.ErrSessionNotFound:
+		return nil, ErrUnauthenticated
+	case err != nil:
+		return nil, fmt.Errorf("could not find session: %v", err)
+	}
+	if session.Expired(s.clock.Now()) {
+		if err := s.sessions.Delete(ctx, session.TokenHash); err != nil {
+			return nil, fmt.Errorf("could not close expired session: %v", err)
+		}
+		return nil, ErrUnauthenticated
+	}
+
+	user, err := s.users.FindByID(ctx, session.UserID)
+	switch {
+	case err == pkg.ErrUserNotFound:
+		return nil, ErrUnauthenticated
+	case err != nil:
+		return nil, fmt.Errorf("could not find user: %v", err)
+	}
+	return user, nil
+}
+
+func (s *service) Get(ctx context.Context, username string) (*pkg.User, error) {
+	user, err := s.users.FindByUsername(ctx, normalizeUsername(username))
+	if err != nil {
+		if err == pkg.ErrUserNotFound {
+			return nil, err
+		}
+		return nil, fmt.Errorf("could not find user: %v", err)
+	}
+	return user, nil
+}
+
+// UpdateProfile replaces the profile of the user with the given ID
+func (s *service) UpdateProfile(ctx context.Context, id int64, profile pkg.Profile) (*pkg.User, error) {
+	verr := &pkg.ValidationError{Subject: "account"}
+	profile = normalizeProfile(verr, profile)
+	if err := verr.Err(); err != nil {
+		return nil, err
+	}
+
+	user, err := s.users.FindByID(ctx, id)
+	if err != nil {
+		if err == pkg.ErrUserNotFound {
+			return nil, err
+		}
+		return nil, fmt.Errorf("could not find user: %v", err)
+	}
+	user.Profile = profile
+	if err := s.users.Update(ctx, user); err != nil {
+		return nil, fmt.Errorf("could not update profile: %v", err)
+	}
+	return user, nil
+}
+
+func normalizeUsername(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
+
+func validateUsername(verr *pkg.ValidationError, username string) {
+	if n := utf8.RuneCountInString(username); n < minUsernameLength || n > maxUsernameLength {
+		verr.Add("username", fmt.Sprintf("must be between %d and %d characters", minUsernameLength, maxUsernameLength))
+		return
+	}
+	for _, r := range username {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_' || r == '-' || r == '.') {
+			verr.Add("username", "may only contain letters, digits, '_', '-' and '.'")
+			return
+		}
+	}
+}
+
+func validatePassword(verr *pkg.ValidationError, password string) {
+	switch {
+	case utf8.RuneCountInString(password) < minPasswordLength:
+		verr.Add("password", fmt.Sprintf("must be at least %d characters", minPasswordLength))
+	case len(password) > maxPasswordLength:
+		verr.Add("password", fmt.Sprintf("must be at most %d bytes", maxPasswordLength))
+	}
+}
+
+// normalizeProfile trims the fields of profile, canonicalizes its language
+// and deduplicates its categories, recording problems in verr
+func normalizeProfile(verr *pkg.ValidationError, profile pkg.Profile) pkg.Profile {
+	profile.DisplayName = strings.TrimSpace(profile.DisplayName)
+	if utf8.RuneCountInString(profile.DisplayName) > maxDisplayNameLength {
+		verr.Add("displayName", fmt.Sprintf("must be at most %d characters", maxDisplayNameLength))
+	}
+
+	if profile.Language != "" {
+		lang, err := pkg.ParseLanguage(profile.Language)
+		if err != nil {
+			verr.Add("language", "must be a BCP 47 language tag")
+		}
+		profile.Language = lang
+	}
+
+	var (
+		categories = make([]string, 0, len(profile.Categories))
+		seen       = make(map[string]bool, len(profile.Categories))
+	)
+	for _, category := range profile.Categories {
+		category = strings.ToLower(strings.TrimSpace(category))
+		switch {
+		case category == "":
+			verr.Add("categories", "must not be blank")
+			continue
+		case utf8.RuneCountInString(category) > maxCategoryLength:
+			verr.Add("categories", fmt.Sprintf("must be at most %d characters each", maxCategoryLength))
+			continue
+		case seen[category]:
+			continue
+		}
+		seen[category] = true
+		categories = append(categories, category)
+	}
+	if len(categories) > maxCategories {
+		verr.Add("categories", fmt.Sprintf("must list at most %d topics", maxCategories))
+	}
+	profile.Categories = nil
+	if len(categories) > 0 {
+		profile.Categories = categories
+	}
+	return profile
+}
+
+// newToken returns a random session token, safe to use in headers and URLs
+func newToken() (string, error) {
+	b := make([]byte, tokenLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
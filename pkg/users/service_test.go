@@ -0,0 +1,55 @@
+package users_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/markhaur/trivia/pkg"
+	"github.com/markhaur/trivia/pkg/inmem"
+	"github.com/markhaur/trivia/pkg/users"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestRegisterHashesPassword(t *testing.T) {
+	var (
+		require = require.New(t)
+		assert  = assert.New(t)
+		svc     = users.NewService(inmem.NewUserRepository(), inmem.NewSessionRepository(), users.WithHashCost(bcrypt.MinCost))
+	)
+
+	user, err := svc.Register(context.TODO(), "alice", "correct horse", pkg.Profile{})
+	require.NoError(err, "could not register user")
+	assert.NotContains(string(user.PasswordHash), "correct horse", "expected the password not to be stored")
+	assert.NoError(bcrypt.CompareHashAndPassword(user.PasswordHash, []byte("correct horse")), "expected a bcrypt hash of the password")
+}
+
+func TestSessionExpiry(t *testing.T) {
+	var (
+		require = require.New(t)
+		assert  = assert.New(t)
+		now     = time.Now()
+		svc     = users.NewService(
+			inmem.NewUserRepository(),
+			inmem.NewSessionRepository(),
+			users.WithHashCost(bcrypt.MinCost),
+			users.WithSessionTTL(time.Hour),
+			users.WithClock(pkg.ClockFunc(func() time.Time { return now })),
+		)
+	)
+
+	_, err := svc.Register(context.TODO(), "alice", "correct horse", pkg.Profile{})
+	require.NoError(err, "could not register user")
+	token, _, err := svc.Login(context.TODO(), "alice", "correct horse")
+	require.NoError(err, "could not log in")
+
+	user, err := svc.Authenticate(context.TODO(), token)
+	require.NoError(err, "could not authenticate")
+	assert.Equal("alice", user.Username, "unexpected user")
+
+	now = now.Add(time.Hour)
+	_, err = svc.Authenticate(context.TODO(), token)
+	assert.Equal(users.ErrUnauthenticated, err, "expected the session to expire")
+}
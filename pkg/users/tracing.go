@@ -0,0 +1,63 @@
+package users
+
+import (
+	"context"
+
+	"github.com/markhaur/trivia/pkg"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TracingMiddleware(tracer trace.Tracer) Middleware {
+	return func(s Service) Service { return &tracingMiddleware{tracer, s} }
+}
+
+type tracingMiddleware struct {
+	tracer trace.Tracer
+	Service
+}
+
+func (s *tracingMiddleware) Register(ctx context.Context, username, password string, profile pkg.Profile) (_ *pkg.User, err error) {
+	ctx, span := s.tracer.Start(ctx, "users.Register", trace.WithAttributes(attribute.String("user.name", username)))
+	defer func() { endSpan(span, err) }()
+	return s.Service.Register(ctx, username, password, profile)
+}
+
+func (s *tracingMiddleware) Login(ctx context.Context, username, password string) (_ string, _ *pkg.Session, err error) {
+	ctx, span := s.tracer.Start(ctx, "users.Login", trace.WithAttributes(attribute.String("user.name", username)))
+	defer func() { endSpan(span, err) }()
+	return s.Service.Login(ctx, username, password)
+}
+
+func (s *tracingMiddleware) Logout(ctx context.Context, token string) (err error) {
+	ctx, span := s.tracer.Start(ctx, "users.Logout")
+	defer func() { endSpan(span, err) }()
+	return s.Service.Logout(ctx, token)
+}
+
+func (s *tracingMiddleware) Authenticate(ctx context.Context, token string) (_ *pkg.User, err error) {
+	ctx, span := s.tracer.Start(ctx, "users.Authenticate")
+	defer func() { endSpan(span, err) }()
+	return s.Service.Authenticate(ctx, token)
+}
+
+func (s *tracingMiddleware) Get(ctx context.Context, username string) (_ *pkg.User, err error) {
+	ctx, span := s.tracer.Start(ctx, "users.Get", trace.WithAttributes(attribute.String("user.name", username)))
+	defer func() { endSpan(span, err) }()
+	return s.Service.Get(ctx, username)
+}
+
+func (s *tracingMiddleware) UpdateProfile(ctx context.Context, id int64, profile pkg.Profile) (_ *pkg.User, err error) {
+	ctx, span := s.tracer.Start(ctx, "users.UpdateProfile", trace.WithAttributes(attribute.Int64("user.id", id)))
+	defer func() { endSpan(span, err) }()
+	return s.Service.UpdateProfile(ctx, id, profile)
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,163 @@
+package pkg
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// FieldError describes a problem with a single field of a Fact
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+// ValidationError lists every field-level problem found in a Fact
+type ValidationError struct {
+	// Subject names what is invalid in the error message, a trivia unless set
+	Subject  string
+	Problems []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	problems := make([]string, 0, len(e.Problems))
+	for _, p := range e.Problems {
+		problems = append(problems, fmt.Sprintf("%s %s", p.Field, p.Message))
+	}
+	subject := e.Subject
+	if subject == "" {
+		subject = "trivia"
+	}
+	return fmt.Sprintf("invalid %s: %s", subject, strings.Join(problems, "; "))
+}
+
+// Add records a problem with field
+func (e *ValidationError) Add(field, message string) {
+	e.Problems = append(e.Problems, FieldError{Field: field, Message: message})
+}
+
+// Err returns e if any problem has been recorded, nil otherwise
+func (e *ValidationError) Err() error {
+	if len(e.Problems) == 0 {
+		return nil
+	}
+	return e
+}
+
+// maxCategoryLength bounds the length of the category of a Fact
+const maxCategoryLength = 50
+
+// FactPolicy holds the rules every Fact must satisfy before it is stored
+type FactPolicy struct {
+	MaxQuestionLength int
+	MaxAnswerLength   int
+	MaxSources        int
+	// Blocklist holds words or phrases, matched case-insensitively on word
+	// boundaries, that may not appear in a question or answer
+	Blocklist []string
+	// Clock tells the time sources must have been accessed by, SystemClock if nil
+	Clock Clock
+}
+
+func DefaultFactPolicy() FactPolicy {
+	return FactPolicy{MaxQuestionLength: 500, MaxAnswerLength: 200, MaxSources: 10}
+}
+
+// Normalize trims surrounding whitespace from the text fields of fact and
+// lower-cases its category and difficulty
+func (p FactPolicy) Normalize(fact *Fact) {
+	fact.Question = strings.TrimSpace(fact.Question)
+	fact.Answer = strings.TrimSpace(fact.Answer)
+	fact.Category = strings.ToLower(strings.TrimSpace(fact.Category))
+	fact.Difficulty = Difficulty(strings.ToLower(strings.TrimSpace(string(fact.Difficulty))))
+	for i := range fact.Sources {
+		fact.Sources[i].URL = strings.TrimSpace(fact.Sources[i].URL)
+		fact.Sources[i].Title = strings.TrimSpace(fact.Sources[i].Title)
+	}
+}
+
+// Validate checks fact against the policy and against the existing facts,
+// none of which may have the same question. It returns a *ValidationError
+// listing every problem found, or nil.
+func (p FactPolicy) Validate(fact Fact, existing []Fact) error {
+	verr := &ValidationError{}
+	p.validateText(verr, "question", fact.Question, p.MaxQuestionLength)
+	p.validateText(verr, "answer", fact.Answer, p.MaxAnswerLength)
+	p.validateSources(verr, fact.Sources)
+	if utf8.RuneCountInString(fact.Category) > maxCategoryLength {
+		verr.Add("category", fmt.Sprintf("must be at most %d characters", maxCategoryLength))
+	}
+	if !fact.Difficulty.Valid() {
+		verr.Add("difficulty", "must be easy, medium or hard")
+	}
+	if !fact.PublishAt.IsZero() && !fact.ExpireAt.IsZero() && !fact.ExpireAt.After(fact.PublishAt) {
+		verr.Add("expireAt", "must be after publishAt")
+	}
+
+	question := normalizeText(fact.Question)
+	for _, other := range existing {
+		if other.ID != fact.ID && question != "" && normalizeText(other.Question) == question {
+			verr.Add("question", fmt.Sprintf("duplicates trivia %d", other.ID))
+			break
+		}
+	}
+	return verr.Err()
+}
+
+func (p FactPolicy) validateText(verr *ValidationError, field, text string, max int) {
+	if strings.TrimSpace(text) == "" {
+		verr.Add(field, "must not be blank")
+		return
+	}
+	if max > 0 && utf8.RuneCountInString(text) > max {
+		verr.Add(field, fmt.Sprintf("must be at most %d characters", max))
+	}
+
+	padded := " " + normalizeText(text) + " "
+	for _, blocked := range p.Blocklist {
+		blocked = normalizeText(blocked)
+		if blocked != "" && strings.Contains(padded, " "+blocked+" ") {
+			verr.Add(field, "contains blocked language")
+			return
+		}
+	}
+}
+
+func (p FactPolicy) validateSources(verr *ValidationError, sources []Source) {
+	if p.MaxSources > 0 && len(sources) > p.MaxSources {
+		verr.Add("sources", fmt.Sprintf("must list at most %d references", p.MaxSources))
+	}
+
+	seen := make(map[string]bool, len(sources))
+	for i, source := range sources {
+		field := fmt.Sprintf("sources[%d].url", i)
+		switch {
+		case !validSourceURL(source.URL):
+			verr.Add(field, "must be an absolute http or https URL")
+		case seen[source.URL]:
+			verr.Add(field, "is listed more than once")
+		}
+		seen[source.URL] = true
+
+		if source.AccessedAt.After(p.now()) {
+			verr.Add(fmt.Sprintf("sources[%d].accessedAt", i), "must not be in the future")
+		}
+	}
+}
+
+func (p FactPolicy) now() time.Time {
+	if p.Clock == nil {
+		return SystemClock.Now()
+	}
+	return p.Clock.Now()
+}
+
+// normalizeText lower-cases text and collapses punctuation and whitespace into single spaces
+func normalizeText(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r) && r != '\''
+	})
+	return strings.Join(words, " ")
+}